@@ -8,18 +8,26 @@ import (
 
 	"example.com/m/internal/api/v1/adapters/controllers"
 	"example.com/m/internal/api/v1/adapters/repositories"
+	"example.com/m/internal/api/v1/core/application/services/account_service"
 	"example.com/m/internal/api/v1/core/application/services/auth_service"
 	"example.com/m/internal/api/v1/core/application/services/booking_service"
+	"example.com/m/internal/api/v1/core/application/services/exchange_service"
 	"example.com/m/internal/api/v1/core/application/services/gpt_service"
+	"example.com/m/internal/api/v1/core/application/services/loan_service"
 	"example.com/m/internal/api/v1/core/application/services/place_service"
 	"example.com/m/internal/api/v1/core/application/services/post_service"
 	"example.com/m/internal/api/v1/core/application/services/review_service"
+	"example.com/m/internal/api/v1/core/application/services/role_service"
+	"example.com/m/internal/api/v1/core/application/services/swap_service"
+	"example.com/m/internal/api/v1/core/application/services/throttle_service"
 	"example.com/m/internal/api/v1/core/application/services/user_service"
 	"example.com/m/internal/api/v1/infrastructure/cache"
 	database "example.com/m/internal/api/v1/infrastructure/database"
 	"example.com/m/internal/api/v1/infrastructure/logger"
+	"example.com/m/internal/api/v1/infrastructure/mail"
 	"example.com/m/internal/api/v1/infrastructure/middlewares"
 	"example.com/m/internal/api/v1/infrastructure/notifications"
+	"example.com/m/internal/api/v1/infrastructure/oidc"
 	"example.com/m/internal/api/v1/infrastructure/router"
 	object_storage "example.com/m/internal/api/v1/infrastructure/s3"
 	"example.com/m/internal/api/v1/utils"
@@ -67,6 +75,66 @@ func startTokenUpdater(logger *zap.Logger) {
 	}
 }
 
+func startBookingExpirer(logger *zap.Logger, bs *booking_service.BookingService) {
+	ticker := time.NewTicker(config.Config.BookingExpirationCheckInterval)
+	defer ticker.Stop()
+	defer handlePanic()
+
+	for {
+		select {
+		case <-ticker.C:
+			if exc := bs.ExpireStaleBookings(context.Background()); exc != nil {
+				logger.Error("Failed to expire stale bookings", zap.String("error", exc.Message))
+			}
+		}
+	}
+}
+
+func startAccountDeleter(logger *zap.Logger, as *account_service.AccountService) {
+	ticker := time.NewTicker(config.Config.AccountDeletionCheckInterval)
+	defer ticker.Stop()
+	defer handlePanic()
+
+	for {
+		select {
+		case <-ticker.C:
+			if exc := as.DeleteScheduledAccounts(context.Background()); exc != nil {
+				logger.Error("Failed to delete scheduled accounts", zap.String("error", exc.Message))
+			}
+		}
+	}
+}
+
+func startLoanReminder(logger *zap.Logger, ls *loan_service.LoanService) {
+	ticker := time.NewTicker(config.Config.LoanReminderCheckInterval)
+	defer ticker.Stop()
+	defer handlePanic()
+
+	for {
+		select {
+		case <-ticker.C:
+			if exc := ls.SendReminders(context.Background()); exc != nil {
+				logger.Error("Failed to send loan reminders", zap.String("error", exc.Message))
+			}
+		}
+	}
+}
+
+func startPickupReminder(logger *zap.Logger, bs *booking_service.BookingService) {
+	ticker := time.NewTicker(config.Config.PickupReminderCheckInterval)
+	defer ticker.Stop()
+	defer handlePanic()
+
+	for {
+		select {
+		case <-ticker.C:
+			if exc := bs.SendPickupReminders(context.Background()); exc != nil {
+				logger.Error("Failed to send pickup reminders", zap.String("error", exc.Message))
+			}
+		}
+	}
+}
+
 func main() {
 	logger.NewLogger()
 	loadEnv()
@@ -76,6 +144,8 @@ func main() {
 	database.MigrateDB()
 	cache.ConnectToRedis()
 	notifications.InitClient(context.Background())
+	mail.InitMailer(logger.Logger)
+	oidc.InitProvider(logger.Logger)
 	go startTokenUpdater(logger.Logger)
 	metrics := middlewares.NewPrometheusMetrics()
 	logger.Logger.Info("SERVICE STARTED")
@@ -84,24 +154,53 @@ func main() {
 
 	fcmRepository := repositories.NewFcmRepository(notifications.FirebaseClient, logger.Logger)
 	userRepository := repositories.NewUserRepository(database.Db, logger.Logger)
-	tokenRepository := repositories.NewTokenRepository(cache.Redis, logger.Logger)
+	sessionRepository := repositories.NewSessionRepository(cache.Redis, logger.Logger)
 	postRepository := repositories.NewPostRepository(database.Db, logger.Logger, &object_storage.S3Client)
 	placeRepository := repositories.NewPlaceRepository(database.Db, logger.Logger)
 	bookingRepository := repositories.NewBookingRepository(database.Db, logger.Logger)
+	waitlistRepository := repositories.NewWaitlistRepository(database.Db, logger.Logger)
 	reviewRepository := repositories.NewReviewRepository(database.Db, logger.Logger)
 	pushTokenRepository := repositories.NewPushTokenRepository(cache.Redis, logger.Logger)
+	codeRepository := repositories.NewCodeRepository(cache.Redis, logger.Logger)
+	mailRepository := repositories.NewMailRepository(mail.Client, logger.Logger)
+	identityRepository := repositories.NewIdentityRepository(database.Db, logger.Logger)
+	roleRepository := repositories.NewRoleRepository(database.Db, logger.Logger)
+	throttleRepository := repositories.NewThrottleRepository(cache.Redis, logger.Logger)
+	dataExportRepository := repositories.NewDataExportRepository(database.Db, logger.Logger)
+	avatarRepository := repositories.NewAvatarRepository(logger.Logger, &object_storage.S3Client)
+	oidcRepository := repositories.NewOIDCRepository(oidc.Client, cache.Redis, logger.Logger)
 	chatRepository := repositories.NewChatRepository(database.Db, logger.Logger)
+	exchangeRepository := repositories.NewExchangeRepository(database.Db, logger.Logger)
+	unitOfWork := repositories.NewUnitOfWork(database.Db, logger.Logger)
+	swapRepository := repositories.NewSwapRepository(database.Db, logger.Logger)
+	pickupSlotRepository := repositories.NewPickupSlotRepository(database.Db, logger.Logger)
 
 	gptService := gpt_service.NewGPTService(config.Config.YandexCatalogID, logger.Logger, chatRepository)
-	userService := user_service.NewUserService(userRepository, pushTokenRepository)
-	placeService := place_service.NewPlaceService(placeRepository)
-	authService := auth_service.NewAuthService(userService, tokenRepository)
-	postService := post_service.NewPostService(postRepository, placeService, userService, gptService)
-	bookingService := booking_service.NewBookingService(*bookingRepository, *userService, *postService, fcmRepository, pushTokenRepository)
-	reviewService := review_service.NewReviewService(reviewRepository, userService)
+	userService := user_service.NewUserService(userRepository, pushTokenRepository, codeRepository, mailRepository, avatarRepository)
+	placeService := place_service.NewPlaceService(placeRepository, pickupSlotRepository)
+	authService := auth_service.NewAuthService(userService, sessionRepository, identityRepository, oidcRepository, logger.Logger)
+	postService := post_service.NewPostService(postRepository, placeService, userService, gptService, unitOfWork, logger.Logger)
+	bookingService := booking_service.NewBookingService(*bookingRepository, *waitlistRepository, *userService, unitOfWork, fcmRepository, pushTokenRepository, codeRepository, logger.Logger)
+	reviewService := review_service.NewReviewService(reviewRepository, exchangeRepository, userService)
+	exchangeService := exchange_service.NewExchangeService(exchangeRepository, userService)
+	loanService := loan_service.NewLoanService(exchangeRepository, userService, unitOfWork, fcmRepository, pushTokenRepository)
+	swapService := swap_service.NewSwapService(swapRepository, userService, unitOfWork, fcmRepository, pushTokenRepository)
+	roleService := role_service.NewRoleService(roleRepository, userService, placeService)
+	throttleService := throttle_service.NewThrottleService(throttleRepository)
+	accountService := account_service.NewAccountService(
+		userService, bookingService, userRepository, dataExportRepository,
+		postRepository, sessionRepository, pushTokenRepository, mailRepository,
+		avatarRepository, logger.Logger,
+	)
+
+	go startBookingExpirer(logger.Logger, bookingService)
+	go startAccountDeleter(logger.Logger, accountService)
+	go startLoanReminder(logger.Logger, loanService)
+	go startPickupReminder(logger.Logger, bookingService)
 
 	authMiddleware := middlewares.NewAuthMiddleware(authService)
-	adminMiddleware := middlewares.NewAdminMiddleware(userService)
+	permissionMiddleware := middlewares.NewPermissionMiddleware(roleService)
+	throttleMiddleware := middlewares.NewThrottleMiddleware(throttleService, metrics, logger.Logger)
 
 	userController := controllers.NewUserController(userService)
 	authController := controllers.NewAuthController(authService)
@@ -111,11 +210,16 @@ func main() {
 	bookingController := controllers.NewBookingController(bookingService)
 	reviewController := controllers.NewReviewController(reviewService)
 	chatBotController := controllers.NewChatBotController(gptService)
+	exchangeController := controllers.NewExchangeController(exchangeService)
+	roleController := controllers.NewRoleController(roleService)
+	accountController := controllers.NewAccountController(accountService)
+	loanController := controllers.NewLoanController(loanService)
+	swapController := controllers.NewSwapController(swapService)
 
 	// gin.SetMode(gin.ReleaseMode)
 	engine := gin.New()
 	engine.Use(metrics.Middleware())
-	router := router.NewRouter(engine, authMiddleware, adminMiddleware)
+	router := router.NewRouter(engine, authMiddleware, permissionMiddleware, throttleMiddleware)
 
 	router.BindAuthRoutes(authController)
 	router.BindMetricsRoutes(metricController)
@@ -126,6 +230,11 @@ func main() {
 	router.BindBookingRoutes(bookingController)
 	router.BindReviewRoutes(reviewController)
 	router.BindChatBotRoutes(chatBotController)
+	router.BindExchangeRoutes(exchangeController)
+	router.BindRoleRoutes(roleController)
+	router.BindAccountRoutes(accountController)
+	router.BindLoanRoutes(loanController)
+	router.BindSwapRoutes(swapController)
 
 	engine.Run(":8000")
 }
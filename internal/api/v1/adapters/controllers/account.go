@@ -0,0 +1,119 @@
+package controllers
+
+import (
+	"example.com/m/internal/api/v1/core/application/services/account_service"
+	"example.com/m/internal/api/v1/utils"
+	"github.com/gin-gonic/gin"
+)
+
+type AccountController struct {
+	as account_service.AccountService
+}
+
+func NewAccountController(as *account_service.AccountService) *AccountController {
+	return &AccountController{
+		as: *as,
+	}
+}
+
+// ExportData выгружает данные пользователя
+// @Summary Выгрузка персональных данных
+// @Description Возвращает ZIP-архив с JSON-файлами профиля, объявлений, бронирований, избранного, обменов, написанных и полученных отзывов и истории чата с ботом, а также фотографиями объявлений.
+// @Tags users
+// @Produce application/zip
+// @Success 200 {file} file
+// @Failure 401 {object} exceptions.Error_
+// @Failure 404 {object} exceptions.Error_
+// @Failure 500 {object} exceptions.Error_
+// @Failure 503 {object} exceptions.Error_
+// @Security BearerAuth
+// @Param Authorization header string true "Bearer JWT token"
+// @Router /users/me/export [get]
+func (c *AccountController) ExportData(ctx *gin.Context) {
+	token, err := utils.ExtractTokenFromHeaders(ctx)
+	if err != nil {
+		ctx.JSON(int(err.StatusCode), err)
+		return
+	}
+	payload, err := utils.ExtractPayloadFromJWT(*token)
+	if err != nil {
+		ctx.JSON(int(err.StatusCode), err)
+		return
+	}
+	email := payload["email"].(string)
+
+	data, err := c.as.ExportData(ctx, email)
+	if err != nil {
+		ctx.JSON(int(err.StatusCode), err)
+		return
+	}
+
+	ctx.Header("Content-Disposition", `attachment; filename="data.zip"`)
+	ctx.Data(200, "application/zip", data)
+}
+
+// ScheduleDeletion запрашивает удаление аккаунта
+// @Summary Удалить аккаунт
+// @Description Планирует удаление аккаунта по истечении периода ожидания, в течение которого удаление можно отменить. Отзывы пользователя и история обменов сохраняются без указания пользователя. При удалении брони пользователя и брони его объявлений отменяются, его объявления удаляются, а предложения обмена с ним отменяются.
+// @Tags users
+// @Produce json
+// @Success 200 {object} dto.AccountDeletionDto
+// @Failure 401 {object} exceptions.Error_
+// @Failure 404 {object} exceptions.Error_
+// @Failure 503 {object} exceptions.Error_
+// @Security BearerAuth
+// @Param Authorization header string true "Bearer JWT token"
+// @Router /users/me/deletion [post]
+func (c *AccountController) ScheduleDeletion(ctx *gin.Context) {
+	token, err := utils.ExtractTokenFromHeaders(ctx)
+	if err != nil {
+		ctx.JSON(int(err.StatusCode), err)
+		return
+	}
+	payload, err := utils.ExtractPayloadFromJWT(*token)
+	if err != nil {
+		ctx.JSON(int(err.StatusCode), err)
+		return
+	}
+	email := payload["email"].(string)
+
+	deletion, err := c.as.ScheduleDeletion(ctx, email)
+	if err != nil {
+		ctx.JSON(int(err.StatusCode), err)
+		return
+	}
+	ctx.JSON(200, deletion)
+}
+
+// CancelDeletion отменяет удаление аккаунта
+// @Summary Отменить удаление аккаунта
+// @Tags users
+// @Produce json
+// @Success 200
+// @Failure 401 {object} exceptions.Error_
+// @Failure 404 {object} exceptions.Error_
+// @Failure 503 {object} exceptions.Error_
+// @Security BearerAuth
+// @Param Authorization header string true "Bearer JWT token"
+// @Router /users/me/deletion [delete]
+func (c *AccountController) CancelDeletion(ctx *gin.Context) {
+	token, err := utils.ExtractTokenFromHeaders(ctx)
+	if err != nil {
+		ctx.JSON(int(err.StatusCode), err)
+		return
+	}
+	payload, err := utils.ExtractPayloadFromJWT(*token)
+	if err != nil {
+		ctx.JSON(int(err.StatusCode), err)
+		return
+	}
+	email := payload["email"].(string)
+
+	if err := c.as.CancelDeletion(ctx, email); err != nil {
+		ctx.JSON(int(err.StatusCode), err)
+		return
+	}
+	ctx.JSON(200, gin.H{
+		"success": true,
+	})
+}
@@ -2,6 +2,7 @@ package controllers
 
 import (
 	"example.com/m/internal/api/v1/core/application/dto"
+	"example.com/m/internal/api/v1/core/application/exceptions"
 	"example.com/m/internal/api/v1/core/application/services/auth_service"
 	"example.com/m/internal/api/v1/utils"
 	"github.com/gin-gonic/gin"
@@ -21,12 +22,12 @@ func NewAuthController(as *auth_service.AuthService) *AuthController {
 
 // Authorize user
 // @Schemes
-// @Description Authorizes user and returns JWT
+// @Description Authorizes user and starts a new session, returns a short-lived JWT and a refresh token
 // @Tags auth
 // @Accept json
 // @Produce json
 // @Param credentials body dto.AuthorizeUserDto true "User credentials"
-// @Success 200 {object} dto.GetUserDto
+// @Success 200 {object} dto.TokensDto
 // @Failure 500 {object} exceptions.Error_
 // @Failure 503 {object} exceptions.Error_
 // @Failure 400 {object} exceptions.Error_
@@ -38,20 +39,175 @@ func (c *AuthController) AuthorizeUser(ctx *gin.Context) {
 		return
 	}
 
-	token, err := c.as.Authorize(ctx, credentials.Email, credentials.Password)
+	tokens, err := c.as.Authorize(ctx, credentials.Email, credentials.Password, dto.SessionMetaDto{
+		DeviceName: credentials.DeviceName,
+		IP:         ctx.ClientIP(),
+		UserAgent:  ctx.Request.UserAgent(),
+	})
+	if err != nil {
+		ctx.JSON(int(err.StatusCode), err)
+		return
+	}
+
+	ctx.JSON(200, &tokens)
+}
+
+// SSO login
+// @Schemes
+// @Description Redirects to the login page of the university identity provider. After login the provider redirects back to /auth/oidc/callback.
+// @Tags auth
+// @Param device_name query string false "Device name shown in the list of sessions"
+// @Success 302
+// @Failure 404 {object} exceptions.Error_
+// @Failure 503 {object} exceptions.Error_
+// @Router /auth/oidc/login [get]
+func (c *AuthController) StartOIDCLogin(ctx *gin.Context) {
+	url, err := c.as.StartOIDCLogin(ctx, ctx.Query("device_name"))
+	if err != nil {
+		ctx.JSON(int(err.StatusCode), err)
+		return
+	}
+
+	ctx.Redirect(302, url)
+}
+
+// SSO login callback
+// @Schemes
+// @Description Finishes the login at the identity provider. The account is linked by the verified email or created on first login. Returns tokens the same way as /auth.
+// @Tags auth
+// @Produce json
+// @Param code query string true "Authorization code"
+// @Param state query string true "State"
+// @Success 200 {object} dto.TokensDto
+// @Failure 400 {object} exceptions.Error_
+// @Failure 401 {object} exceptions.Error_
+// @Failure 404 {object} exceptions.Error_
+// @Failure 409 {object} exceptions.Error_
+// @Failure 503 {object} exceptions.Error_
+// @Router /auth/oidc/callback [get]
+func (c *AuthController) FinishOIDCLogin(ctx *gin.Context) {
+	if ctx.Query("error") != "" {
+		ctx.JSON(int(exceptions.ErrOIDCLoginFailed.StatusCode), exceptions.ErrOIDCLoginFailed)
+		return
+	}
+	code, state := ctx.Query("code"), ctx.Query("state")
+	if code == "" || state == "" {
+		ctx.JSON(400, gin.H{"error": "code and state are required"})
+		return
+	}
+
+	tokens, err := c.as.FinishOIDCLogin(ctx, state, code, dto.SessionMetaDto{
+		IP:        ctx.ClientIP(),
+		UserAgent: ctx.Request.UserAgent(),
+	})
+	if err != nil {
+		ctx.JSON(int(err.StatusCode), err)
+		return
+	}
+
+	ctx.JSON(200, &tokens)
+}
+
+// Refresh tokens
+// @Schemes
+// @Description Exchanges a refresh token for a new JWT and refresh token. Every refresh token can be used only once.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param token body dto.RefreshTokenDto true "Refresh token"
+// @Success 200 {object} dto.TokensDto
+// @Failure 400 {object} exceptions.Error_
+// @Failure 401 {object} exceptions.Error_
+// @Failure 503 {object} exceptions.Error_
+// @Router /auth/refresh [post]
+func (c *AuthController) Refresh(ctx *gin.Context) {
+	var body dto.RefreshTokenDto
+	if err := ctx.ShouldBindBodyWithJSON(&body); err != nil {
+		ctx.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	tokens, err := c.as.Refresh(ctx, body.RefreshToken)
+	if err != nil {
+		ctx.JSON(int(err.StatusCode), err)
+		return
+	}
+
+	ctx.JSON(200, &tokens)
+}
+
+// Logout
+// @Schemes
+// @Description Ends the current session
+// @Tags auth
+// @Produce json
+// @Success 200
+// @Failure 401 {object} exceptions.Error_
+// @Failure 503 {object} exceptions.Error_
+// @Security BearerAuth
+// @Param Authorization header string true "Bearer JWT token"
+// @Router /auth/logout [post]
+func (c *AuthController) Logout(ctx *gin.Context) {
+	token, err := utils.ExtractTokenFromHeaders(ctx)
+	if err != nil {
+		ctx.JSON(int(err.StatusCode), err)
+		return
+	}
+	payload, err := utils.ExtractPayloadFromJWT(*token)
+	if err != nil {
+		ctx.JSON(int(err.StatusCode), err)
+		return
+	}
+	email := payload["email"].(string)
+	sessionID := payload["sid"].(string)
+
+	if err := c.as.Logout(ctx, email, sessionID); err != nil {
+		ctx.JSON(int(err.StatusCode), err)
+		return
+	}
+
+	ctx.JSON(200, gin.H{
+		"success": true,
+	})
+}
+
+// Logout everywhere
+// @Schemes
+// @Description Ends all sessions of the user on all devices
+// @Tags auth
+// @Produce json
+// @Success 200
+// @Failure 401 {object} exceptions.Error_
+// @Failure 503 {object} exceptions.Error_
+// @Security BearerAuth
+// @Param Authorization header string true "Bearer JWT token"
+// @Router /auth/logout-all [post]
+func (c *AuthController) LogoutEverywhere(ctx *gin.Context) {
+	token, err := utils.ExtractTokenFromHeaders(ctx)
 	if err != nil {
 		ctx.JSON(int(err.StatusCode), err)
 		return
 	}
+	payload, err := utils.ExtractPayloadFromJWT(*token)
+	if err != nil {
+		ctx.JSON(int(err.StatusCode), err)
+		return
+	}
+	email := payload["email"].(string)
+
+	if err := c.as.LogoutEverywhere(ctx, email); err != nil {
+		ctx.JSON(int(err.StatusCode), err)
+		return
+	}
 
 	ctx.JSON(200, gin.H{
-		"token": token,
+		"success": true,
 	})
 }
 
 // Change password
 // @Schemes
-// @Description Changes user password and makes current token invalid
+// @Description Changes user password and ends all sessions of the user
 // @Tags auth
 // @Accept json
 // @Produce json
@@ -91,3 +247,158 @@ func (c *AuthController) ChangePassword(ctx *gin.Context) {
 		"success": true,
 	})
 }
+
+// Forgot password
+// @Schemes
+// @Description Sends a password reset code to the email. The response is the same whether the email is registered or not.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param body body dto.ForgotPasswordDto true "User email"
+// @Success 200
+// @Failure 400 {object} exceptions.Error_
+// @Router /auth/password/forgot [post]
+func (c *AuthController) ForgotPassword(ctx *gin.Context) {
+	var body dto.ForgotPasswordDto
+	if err := ctx.ShouldBindBodyWithJSON(&body); err != nil {
+		ctx.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.as.ForgotPassword(ctx, body.Email)
+
+	ctx.JSON(200, gin.H{
+		"success": true,
+	})
+}
+
+// Reset password
+// @Schemes
+// @Description Sets a new password using the code sent to the email and ends all sessions of the user
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param body body dto.ResetPasswordDto true "Email, code and new password"
+// @Success 200
+// @Failure 400 {object} exceptions.Error_
+// @Failure 429 {object} exceptions.Error_
+// @Failure 503 {object} exceptions.Error_
+// @Router /auth/password/reset [post]
+func (c *AuthController) ResetPassword(ctx *gin.Context) {
+	var body dto.ResetPasswordDto
+	if err := ctx.ShouldBindBodyWithJSON(&body); err != nil {
+		ctx.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := c.as.ResetPassword(ctx, body.Email, body.Code, body.NewPassword); err != nil {
+		ctx.JSON(int(err.StatusCode), err)
+		return
+	}
+
+	ctx.JSON(200, gin.H{
+		"success": true,
+	})
+}
+
+// @Summary Активные сессии
+// @Description Возвращает устройства, на которых выполнен вход. Текущая сессия отмечена флагом current.
+// @Tags auth
+// @Produce json
+// @Success 200 {array} dto.SessionDto
+// @Failure 401 {object} exceptions.Error_
+// @Failure 503 {object} exceptions.Error_
+// @Security BearerAuth
+// @Param Authorization header string true "Bearer JWT token"
+// @Router /auth/sessions [get]
+func (c *AuthController) GetSessions(ctx *gin.Context) {
+	token, err := utils.ExtractTokenFromHeaders(ctx)
+	if err != nil {
+		ctx.JSON(int(err.StatusCode), err)
+		return
+	}
+	payload, err := utils.ExtractPayloadFromJWT(*token)
+	if err != nil {
+		ctx.JSON(int(err.StatusCode), err)
+		return
+	}
+	email := payload["email"].(string)
+	sessionID := payload["sid"].(string)
+
+	sessions, err := c.as.GetSessions(ctx, email, sessionID)
+	if err != nil {
+		ctx.JSON(int(err.StatusCode), err)
+		return
+	}
+
+	ctx.JSON(200, sessions)
+}
+
+// @Summary Завершить сессию
+// @Description Завершает одну сессию пользователя, например на потерянном устройстве.
+// @Tags auth
+// @Produce json
+// @Param id path string true "Session ID"
+// @Success 200
+// @Failure 401 {object} exceptions.Error_
+// @Failure 404 {object} exceptions.Error_
+// @Failure 503 {object} exceptions.Error_
+// @Security BearerAuth
+// @Param Authorization header string true "Bearer JWT token"
+// @Router /auth/sessions/{id} [delete]
+func (c *AuthController) RevokeSession(ctx *gin.Context) {
+	token, err := utils.ExtractTokenFromHeaders(ctx)
+	if err != nil {
+		ctx.JSON(int(err.StatusCode), err)
+		return
+	}
+	payload, err := utils.ExtractPayloadFromJWT(*token)
+	if err != nil {
+		ctx.JSON(int(err.StatusCode), err)
+		return
+	}
+	email := payload["email"].(string)
+
+	if err := c.as.RevokeSession(ctx, email, ctx.Param("id")); err != nil {
+		ctx.JSON(int(err.StatusCode), err)
+		return
+	}
+
+	ctx.JSON(200, gin.H{
+		"success": true,
+	})
+}
+
+// @Summary Завершить остальные сессии
+// @Description Завершает все сессии пользователя, кроме текущей.
+// @Tags auth
+// @Produce json
+// @Success 200
+// @Failure 401 {object} exceptions.Error_
+// @Failure 503 {object} exceptions.Error_
+// @Security BearerAuth
+// @Param Authorization header string true "Bearer JWT token"
+// @Router /auth/sessions [delete]
+func (c *AuthController) RevokeOtherSessions(ctx *gin.Context) {
+	token, err := utils.ExtractTokenFromHeaders(ctx)
+	if err != nil {
+		ctx.JSON(int(err.StatusCode), err)
+		return
+	}
+	payload, err := utils.ExtractPayloadFromJWT(*token)
+	if err != nil {
+		ctx.JSON(int(err.StatusCode), err)
+		return
+	}
+	email := payload["email"].(string)
+	sessionID := payload["sid"].(string)
+
+	if err := c.as.RevokeOtherSessions(ctx, email, sessionID); err != nil {
+		ctx.JSON(int(err.StatusCode), err)
+		return
+	}
+
+	ctx.JSON(200, gin.H{
+		"success": true,
+	})
+}
@@ -3,6 +3,7 @@ package controllers
 import (
 	"strconv"
 
+	"example.com/m/internal/api/v1/core/application/dto"
 	"example.com/m/internal/api/v1/core/application/services/booking_service"
 	"example.com/m/internal/api/v1/utils"
 	"github.com/gin-gonic/gin"
@@ -17,14 +18,17 @@ func NewBookingController(bs *booking_service.BookingService) *BookingController
 }
 
 // @Summary Забронировать книгу
-// @Description Забронировать книгу по ID
+// @Description Забронировать книгу по ID. Если книга уже забронирована, пользователь встаёт в очередь на неё. Число активных броней ограничено, после повторных неявок бронирование временно недоступно.
 // @Tags бронирования
 // @Accept json
 // @Produce json
 // @Param id path int true "ID книги"
-// @Success 201 {object} map[string]interface{} "success"
+// @Success 201 {object} dto.BookingDto
+// @Success 202 {object} dto.WaitlistEntryDto
 // @Failure 400 {object} exceptions.Error_
 // @Failure 401 {object} exceptions.Error_
+// @Failure 403 {object} exceptions.Error_
+// @Failure 409 {object} exceptions.Error_
 // @Failure 500 {object} exceptions.Error_
 // @Failure 503 {object} exceptions.Error_
 // @Security BearerAuth
@@ -52,24 +56,31 @@ func (c *BookingController) BookBook(ctx *gin.Context) {
 		return
 	}
 
-	exc = c.bs.BookBook(ctx, email, idParsed)
+	booking, entry, exc := c.bs.BookBook(ctx, email, idParsed)
 	if exc != nil {
 		ctx.JSON(int(exc.StatusCode), exc)
 		return
 	}
 
-	ctx.JSON(201, gin.H{"success": true})
+	if entry != nil {
+		ctx.JSON(202, &entry)
+		return
+	}
+	ctx.JSON(201, &booking)
 }
 
-// @Summary Delete a booking
-// @Description Delete a booking by ID
+// @Summary Cancel a booking
+// @Description Cancel the active booking of the post. Allowed for the booker and the owner of the post.
 // @Tags bookings
 // @Accept json
 // @Produce json
-// @Param id path int true "Booking ID"
+// @Param id path int true "Post ID"
 // @Success 200 {object} map[string]interface{} "success"
 // @Failure 400 {object} exceptions.Error_
 // @Failure 401 {object} exceptions.Error_
+// @Failure 403 {object} exceptions.Error_
+// @Failure 404 {object} exceptions.Error_
+// @Failure 409 {object} exceptions.Error_
 // @Failure 500 {object} exceptions.Error_
 // @Failure 503 {object} exceptions.Error_
 // @Security BearerAuth
@@ -107,21 +118,220 @@ func (c *BookingController) DeleteBooking(ctx *gin.Context) {
 	ctx.JSON(200, gin.H{"success": true})
 }
 
-// @Summary Mark a book as taken
-// @Description Mark a booking as taken by ID
+// @Summary Получить код передачи книги
+// @Description Забронировавший получает одноразовый код для подтверждённой брони и показывает его владельцу (цифрами или QR-кодом). Новый код заменяет предыдущий.
+// @Tags bookings
+// @Produce json
+// @Param id path int true "Post ID"
+// @Success 200 {object} dto.HandoverCodeDto
+// @Failure 400 {object} exceptions.Error_
+// @Failure 401 {object} exceptions.Error_
+// @Failure 403 {object} exceptions.Error_
+// @Failure 404 {object} exceptions.Error_
+// @Failure 409 {object} exceptions.Error_
+// @Failure 503 {object} exceptions.Error_
+// @Security BearerAuth
+// @Param Authorization header string true "Bearer JWT token"
+// @Router /posts/{id}/handover [post]
+func (c *BookingController) CreateHandoverCode(ctx *gin.Context) {
+	token, exc := utils.ExtractTokenFromHeaders(ctx)
+	if exc != nil {
+		ctx.JSON(int(exc.StatusCode), exc)
+		return
+	}
+
+	payload, exc := utils.ExtractPayloadFromJWT(*token)
+	if exc != nil {
+		ctx.JSON(int(exc.StatusCode), exc)
+		return
+	}
+
+	email := payload["email"].(string)
+
+	id := ctx.Param("id")
+	idParsed, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		ctx.JSON(400, gin.H{"error": "Invalid post ID"})
+		return
+	}
+
+	code, exc := c.bs.CreateHandoverCode(ctx, email, idParsed)
+	if exc != nil {
+		ctx.JSON(int(exc.StatusCode), exc)
+		return
+	}
+
+	ctx.JSON(200, code)
+}
+
+// @Summary Подтвердить передачу книги
+// @Description Владелец вводит или сканирует код забронировавшего. После этого бронь завершается, книга становится взятой и обмен сохраняется в истории.
 // @Tags bookings
 // @Accept json
 // @Produce json
-// @Param id path int true "Booking ID"
+// @Param id path int true "Post ID"
+// @Param data body dto.ConfirmHandoverDto true "Handover code"
 // @Success 200 {object} map[string]interface{} "success"
 // @Failure 400 {object} exceptions.Error_
 // @Failure 401 {object} exceptions.Error_
+// @Failure 403 {object} exceptions.Error_
+// @Failure 404 {object} exceptions.Error_
+// @Failure 409 {object} exceptions.Error_
+// @Failure 429 {object} exceptions.Error_
+// @Failure 503 {object} exceptions.Error_
+// @Security BearerAuth
+// @Param Authorization header string true "Bearer JWT token"
+// @Router /posts/{id}/handover/confirm [post]
+func (c *BookingController) ConfirmHandover(ctx *gin.Context) {
+	token, exc := utils.ExtractTokenFromHeaders(ctx)
+	if exc != nil {
+		ctx.JSON(int(exc.StatusCode), exc)
+		return
+	}
+
+	payload, exc := utils.ExtractPayloadFromJWT(*token)
+	if exc != nil {
+		ctx.JSON(int(exc.StatusCode), exc)
+		return
+	}
+
+	email := payload["email"].(string)
+
+	id := ctx.Param("id")
+	idParsed, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		ctx.JSON(400, gin.H{"error": "Invalid post ID"})
+		return
+	}
+
+	var data dto.ConfirmHandoverDto
+	if err := ctx.ShouldBindBodyWithJSON(&data); err != nil {
+		ctx.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	exc = c.bs.ConfirmHandover(ctx, email, idParsed, data.Code)
+	if exc != nil {
+		ctx.JSON(int(exc.StatusCode), exc)
+		return
+	}
+
+	ctx.JSON(200, gin.H{"success": true})
+}
+
+// @Summary Подтвердить бронь
+// @Description Владелец подтверждает ожидающую бронь своей книги. После подтверждения начинается срок, за который книгу нужно забрать.
+// @Tags bookings
+// @Accept json
+// @Produce json
+// @Param id path int true "Post ID"
+// @Success 200 {object} dto.BookingDto
+// @Failure 400 {object} exceptions.Error_
+// @Failure 401 {object} exceptions.Error_
+// @Failure 403 {object} exceptions.Error_
+// @Failure 404 {object} exceptions.Error_
+// @Failure 409 {object} exceptions.Error_
+// @Failure 500 {object} exceptions.Error_
+// @Failure 503 {object} exceptions.Error_
+// @Security BearerAuth
+// @Param Authorization header string true "Bearer JWT token"
+// @Router /posts/{id}/booking/accept [put]
+func (c *BookingController) AcceptBooking(ctx *gin.Context) {
+	token, exc := utils.ExtractTokenFromHeaders(ctx)
+	if exc != nil {
+		ctx.JSON(int(exc.StatusCode), exc)
+		return
+	}
+
+	payload, exc := utils.ExtractPayloadFromJWT(*token)
+	if exc != nil {
+		ctx.JSON(int(exc.StatusCode), exc)
+		return
+	}
+
+	email := payload["email"].(string)
+
+	id := ctx.Param("id")
+	idParsed, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		ctx.JSON(400, gin.H{"error": "Invalid post ID"})
+		return
+	}
+
+	booking, exc := c.bs.AcceptBooking(ctx, email, idParsed)
+	if exc != nil {
+		ctx.JSON(int(exc.StatusCode), exc)
+		return
+	}
+
+	ctx.JSON(200, &booking)
+}
+
+// @Summary Сообщить о неявке
+// @Description Владелец сообщает, что забронировавший не пришёл за книгой. Бронь снимается, книга переходит следующему в очереди, а у забронировавшего растёт счётчик неявок. После нескольких неявок бронирование для него временно недоступно. Сообщить о неявке можно после начала выбранного времени получения, а если оно не выбрано, после срока получения.
+// @Tags bookings
+// @Accept json
+// @Produce json
+// @Param id path int true "Post ID"
+// @Success 200 {object} dto.BookingDto
+// @Failure 400 {object} exceptions.Error_
+// @Failure 401 {object} exceptions.Error_
+// @Failure 403 {object} exceptions.Error_
+// @Failure 404 {object} exceptions.Error_
+// @Failure 409 {object} exceptions.Error_
+// @Failure 503 {object} exceptions.Error_
+// @Security BearerAuth
+// @Param Authorization header string true "Bearer JWT token"
+// @Router /posts/{id}/booking/no-show [put]
+func (c *BookingController) ReportNoShow(ctx *gin.Context) {
+	token, exc := utils.ExtractTokenFromHeaders(ctx)
+	if exc != nil {
+		ctx.JSON(int(exc.StatusCode), exc)
+		return
+	}
+
+	payload, exc := utils.ExtractPayloadFromJWT(*token)
+	if exc != nil {
+		ctx.JSON(int(exc.StatusCode), exc)
+		return
+	}
+
+	email := payload["email"].(string)
+
+	id := ctx.Param("id")
+	idParsed, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		ctx.JSON(400, gin.H{"error": "Invalid post ID"})
+		return
+	}
+
+	booking, exc := c.bs.ReportNoShow(ctx, email, idParsed)
+	if exc != nil {
+		ctx.JSON(int(exc.StatusCode), exc)
+		return
+	}
+
+	ctx.JSON(200, &booking)
+}
+
+// @Summary Отклонить бронь
+// @Description Владелец отклоняет ожидающую бронь своей книги, книга снова становится доступной.
+// @Tags bookings
+// @Accept json
+// @Produce json
+// @Param id path int true "Post ID"
+// @Success 200 {object} dto.BookingDto
+// @Failure 400 {object} exceptions.Error_
+// @Failure 401 {object} exceptions.Error_
+// @Failure 403 {object} exceptions.Error_
+// @Failure 404 {object} exceptions.Error_
+// @Failure 409 {object} exceptions.Error_
 // @Failure 500 {object} exceptions.Error_
 // @Failure 503 {object} exceptions.Error_
 // @Security BearerAuth
 // @Param Authorization header string true "Bearer JWT token"
-// @Router /posts/{id}/mark-taken [put]
-func (c *BookingController) MarkAsTaken(ctx *gin.Context) {
+// @Router /posts/{id}/booking/reject [put]
+func (c *BookingController) RejectBooking(ctx *gin.Context) {
 	token, exc := utils.ExtractTokenFromHeaders(ctx)
 	if exc != nil {
 		ctx.JSON(int(exc.StatusCode), exc)
@@ -143,7 +353,142 @@ func (c *BookingController) MarkAsTaken(ctx *gin.Context) {
 		return
 	}
 
-	exc = c.bs.MarkAsTaken(ctx, email, idParsed)
+	booking, exc := c.bs.RejectBooking(ctx, email, idParsed)
+	if exc != nil {
+		ctx.JSON(int(exc.StatusCode), exc)
+		return
+	}
+
+	ctx.JSON(200, &booking)
+}
+
+// @Summary Входящие запросы на бронь
+// @Description Возвращает ожидающие подтверждения брони книг текущего пользователя.
+// @Tags bookings
+// @Accept json
+// @Produce json
+// @Param limit query int false "Limit"
+// @Param cursor query string false "Cursor of the next page"
+// @Success 200 {object} dto.Page[dto.IncomingBookingDto]
+// @Failure 400 {object} exceptions.Error_
+// @Failure 401 {object} exceptions.Error_
+// @Failure 500 {object} exceptions.Error_
+// @Failure 503 {object} exceptions.Error_
+// @Security BearerAuth
+// @Param Authorization header string true "Bearer JWT token"
+// @Router /bookings/incoming [get]
+func (c *BookingController) GetIncomingBookings(ctx *gin.Context) {
+	token, exc := utils.ExtractTokenFromHeaders(ctx)
+	if exc != nil {
+		ctx.JSON(int(exc.StatusCode), exc)
+		return
+	}
+
+	payload, exc := utils.ExtractPayloadFromJWT(*token)
+	if exc != nil {
+		ctx.JSON(int(exc.StatusCode), exc)
+		return
+	}
+
+	email := payload["email"].(string)
+
+	page, exc := utils.ParsePageRequest(ctx, dto.CursorKeyTime)
+	if exc != nil {
+		ctx.JSON(int(exc.StatusCode), exc)
+		return
+	}
+
+	bookings, exc := c.bs.GetIncomingBookings(ctx, email, *page)
+	if exc != nil {
+		ctx.JSON(int(exc.StatusCode), exc)
+		return
+	}
+
+	ctx.JSON(200, &bookings)
+}
+
+// @Summary Позиция в очереди
+// @Description Возвращает позицию текущего пользователя в очереди на книгу.
+// @Tags bookings
+// @Produce json
+// @Param id path int true "Post ID"
+// @Success 200 {object} dto.WaitlistEntryDto
+// @Failure 400 {object} exceptions.Error_
+// @Failure 401 {object} exceptions.Error_
+// @Failure 404 {object} exceptions.Error_
+// @Failure 500 {object} exceptions.Error_
+// @Failure 503 {object} exceptions.Error_
+// @Security BearerAuth
+// @Param Authorization header string true "Bearer JWT token"
+// @Router /posts/{id}/waitlist [get]
+func (c *BookingController) GetWaitlistPosition(ctx *gin.Context) {
+	token, exc := utils.ExtractTokenFromHeaders(ctx)
+	if exc != nil {
+		ctx.JSON(int(exc.StatusCode), exc)
+		return
+	}
+
+	payload, exc := utils.ExtractPayloadFromJWT(*token)
+	if exc != nil {
+		ctx.JSON(int(exc.StatusCode), exc)
+		return
+	}
+
+	email := payload["email"].(string)
+
+	id := ctx.Param("id")
+	idParsed, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		ctx.JSON(400, gin.H{"error": "Invalid post ID"})
+		return
+	}
+
+	entry, exc := c.bs.GetWaitlistPosition(ctx, email, idParsed)
+	if exc != nil {
+		ctx.JSON(int(exc.StatusCode), exc)
+		return
+	}
+
+	ctx.JSON(200, &entry)
+}
+
+// @Summary Выйти из очереди
+// @Description Убирает текущего пользователя из очереди на книгу.
+// @Tags bookings
+// @Produce json
+// @Param id path int true "Post ID"
+// @Success 200 {object} map[string]interface{} "success"
+// @Failure 400 {object} exceptions.Error_
+// @Failure 401 {object} exceptions.Error_
+// @Failure 404 {object} exceptions.Error_
+// @Failure 500 {object} exceptions.Error_
+// @Failure 503 {object} exceptions.Error_
+// @Security BearerAuth
+// @Param Authorization header string true "Bearer JWT token"
+// @Router /posts/{id}/waitlist [delete]
+func (c *BookingController) LeaveWaitlist(ctx *gin.Context) {
+	token, exc := utils.ExtractTokenFromHeaders(ctx)
+	if exc != nil {
+		ctx.JSON(int(exc.StatusCode), exc)
+		return
+	}
+
+	payload, exc := utils.ExtractPayloadFromJWT(*token)
+	if exc != nil {
+		ctx.JSON(int(exc.StatusCode), exc)
+		return
+	}
+
+	email := payload["email"].(string)
+
+	id := ctx.Param("id")
+	idParsed, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		ctx.JSON(400, gin.H{"error": "Invalid post ID"})
+		return
+	}
+
+	exc = c.bs.LeaveWaitlist(ctx, email, idParsed)
 	if exc != nil {
 		ctx.JSON(int(exc.StatusCode), exc)
 		return
@@ -151,3 +496,138 @@ func (c *BookingController) MarkAsTaken(ctx *gin.Context) {
 
 	ctx.JSON(200, gin.H{"success": true})
 }
+
+// @Summary Мои очереди
+// @Description Возвращает все очереди, в которых стоит текущий пользователь, с позициями.
+// @Tags bookings
+// @Produce json
+// @Success 200 {array} dto.WaitlistEntryDto
+// @Failure 401 {object} exceptions.Error_
+// @Failure 500 {object} exceptions.Error_
+// @Failure 503 {object} exceptions.Error_
+// @Security BearerAuth
+// @Param Authorization header string true "Bearer JWT token"
+// @Router /bookings/waitlist [get]
+func (c *BookingController) GetMyWaitlist(ctx *gin.Context) {
+	token, exc := utils.ExtractTokenFromHeaders(ctx)
+	if exc != nil {
+		ctx.JSON(int(exc.StatusCode), exc)
+		return
+	}
+
+	payload, exc := utils.ExtractPayloadFromJWT(*token)
+	if exc != nil {
+		ctx.JSON(int(exc.StatusCode), exc)
+		return
+	}
+
+	email := payload["email"].(string)
+
+	entries, exc := c.bs.GetMyWaitlist(ctx, email)
+	if exc != nil {
+		ctx.JSON(int(exc.StatusCode), exc)
+		return
+	}
+
+	ctx.JSON(200, &entries)
+}
+
+// @Summary Выбрать слот получения книги
+// @Description Забронировавший выбирает слот в месте книги для подтверждённой брони. Слот должен закончиться до истечения брони; повторный вызов меняет слот.
+// @Tags bookings
+// @Accept json
+// @Produce json
+// @Param id path int true "Post ID"
+// @Param data body dto.ReservePickupSlotDto true "Pickup slot"
+// @Success 200 {object} dto.BookingDto
+// @Failure 400 {object} exceptions.Error_
+// @Failure 401 {object} exceptions.Error_
+// @Failure 403 {object} exceptions.Error_
+// @Failure 404 {object} exceptions.Error_
+// @Failure 409 {object} exceptions.Error_
+// @Failure 503 {object} exceptions.Error_
+// @Security BearerAuth
+// @Param Authorization header string true "Bearer JWT token"
+// @Router /posts/{id}/booking/slot [put]
+func (c *BookingController) ReservePickupSlot(ctx *gin.Context) {
+	token, exc := utils.ExtractTokenFromHeaders(ctx)
+	if exc != nil {
+		ctx.JSON(int(exc.StatusCode), exc)
+		return
+	}
+
+	payload, exc := utils.ExtractPayloadFromJWT(*token)
+	if exc != nil {
+		ctx.JSON(int(exc.StatusCode), exc)
+		return
+	}
+
+	email := payload["email"].(string)
+
+	id := ctx.Param("id")
+	idParsed, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		ctx.JSON(400, gin.H{"error": "Invalid post ID"})
+		return
+	}
+
+	var data dto.ReservePickupSlotDto
+	if err := ctx.ShouldBindBodyWithJSON(&data); err != nil {
+		ctx.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	booking, exc := c.bs.ReservePickupSlot(ctx, email, idParsed, data.SlotID)
+	if exc != nil {
+		ctx.JSON(int(exc.StatusCode), exc)
+		return
+	}
+
+	ctx.JSON(200, booking)
+}
+
+// @Summary Получение книги в календаре
+// @Description Возвращает событие iCalendar для выбранного слота получения книги. Доступно забронировавшему и владельцу.
+// @Tags bookings
+// @Produce text/calendar
+// @Param id path int true "Post ID"
+// @Success 200 {string} string "iCalendar"
+// @Failure 400 {object} exceptions.Error_
+// @Failure 401 {object} exceptions.Error_
+// @Failure 403 {object} exceptions.Error_
+// @Failure 404 {object} exceptions.Error_
+// @Failure 503 {object} exceptions.Error_
+// @Security BearerAuth
+// @Param Authorization header string true "Bearer JWT token"
+// @Router /posts/{id}/booking/calendar.ics [get]
+func (c *BookingController) GetPickupCalendar(ctx *gin.Context) {
+	token, exc := utils.ExtractTokenFromHeaders(ctx)
+	if exc != nil {
+		ctx.JSON(int(exc.StatusCode), exc)
+		return
+	}
+
+	payload, exc := utils.ExtractPayloadFromJWT(*token)
+	if exc != nil {
+		ctx.JSON(int(exc.StatusCode), exc)
+		return
+	}
+
+	email := payload["email"].(string)
+
+	id := ctx.Param("id")
+	idParsed, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		ctx.JSON(400, gin.H{"error": "Invalid post ID"})
+		return
+	}
+
+	ics, exc := c.bs.GetPickupCalendar(ctx, email, idParsed)
+	if exc != nil {
+		ctx.JSON(int(exc.StatusCode), exc)
+		return
+	}
+
+	ctx.Header("Content-Disposition", "attachment; filename=pickup.ics")
+	ctx.Data(200, "text/calendar; charset=utf-8", []byte(ics))
+}
@@ -53,9 +53,9 @@ func (c *ChatBotController) SendMessage(ctx *gin.Context) {
 }
 
 func (c *ChatBotController) GetChat(ctx *gin.Context) {
-	var query dto.ChatQueryDto
-	if err := ctx.ShouldBindQuery(&query); err != nil {
-		ctx.JSON(400, gin.H{"error": err.Error()})
+	page, err := utils.ParsePageRequest(ctx, dto.CursorKeyTime)
+	if err != nil {
+		ctx.JSON(int(err.StatusCode), err)
 		return
 	}
 
@@ -73,14 +73,14 @@ func (c *ChatBotController) GetChat(ctx *gin.Context) {
 
 	email := payload["email"].(string)
 
-	chat, err := c.chatBotService.GetChatByEmail(ctx.Request.Context(), email, query.Offset, query.Limit)
+	chat, err := c.chatBotService.GetChatByEmail(ctx.Request.Context(), email, *page)
 	if err != nil {
 		ctx.JSON(int(err.StatusCode), err)
 		return
 	}
 
-	chatMessagesDto := make([]dto.ChatMessageDto, 0, len(chat))
-	for _, el := range chat {
+	chatMessagesDto := make([]dto.ChatMessageDto, 0, len(chat.Items))
+	for _, el := range chat.Items {
 		if el.Writer == dto.USER {
 			chatMessagesDto = append(chatMessagesDto, dto.ChatMessageDto{
 				Role:      "user",
@@ -96,7 +96,9 @@ func (c *ChatBotController) GetChat(ctx *gin.Context) {
 		}
 	}
 
-	ctx.JSON(200, &dto.ChatDto{
-		Messages: chatMessagesDto,
+	ctx.JSON(200, &dto.Page[dto.ChatMessageDto]{
+		Items:      chatMessagesDto,
+		NextCursor: chat.NextCursor,
+		HasMore:    chat.HasMore,
 	})
 }
\ No newline at end of file
@@ -0,0 +1,131 @@
+package controllers
+
+import (
+	"example.com/m/internal/api/v1/core/application/dto"
+	"example.com/m/internal/api/v1/core/application/services/exchange_service"
+	"example.com/m/internal/api/v1/utils"
+	"github.com/gin-gonic/gin"
+)
+
+type ExchangeController struct {
+	es exchange_service.ExchangeService
+}
+
+func NewExchangeController(es *exchange_service.ExchangeService) *ExchangeController {
+	return &ExchangeController{es: *es}
+}
+
+// @Summary Отданные книги
+// @Description Возвращает историю книг, которые текущий пользователь отдал.
+// @Tags exchanges
+// @Produce json
+// @Param limit query int false "Limit"
+// @Param cursor query string false "Cursor of the next page"
+// @Success 200 {object} dto.Page[dto.ExchangeDto]
+// @Failure 401 {object} exceptions.Error_
+// @Failure 404 {object} exceptions.Error_
+// @Failure 503 {object} exceptions.Error_
+// @Security BearerAuth
+// @Param Authorization header string true "Bearer JWT token"
+// @Router /exchanges/given [get]
+func (c *ExchangeController) GetGivenExchanges(ctx *gin.Context) {
+	token, exc := utils.ExtractTokenFromHeaders(ctx)
+	if exc != nil {
+		ctx.JSON(int(exc.StatusCode), exc)
+		return
+	}
+
+	payload, exc := utils.ExtractPayloadFromJWT(*token)
+	if exc != nil {
+		ctx.JSON(int(exc.StatusCode), exc)
+		return
+	}
+
+	email := payload["email"].(string)
+
+	page, exc := utils.ParsePageRequest(ctx, dto.CursorKeyTime)
+	if exc != nil {
+		ctx.JSON(int(exc.StatusCode), exc)
+		return
+	}
+
+	exchanges, exc := c.es.GetGivenExchanges(ctx, email, *page)
+	if exc != nil {
+		ctx.JSON(int(exc.StatusCode), exc)
+		return
+	}
+
+	ctx.JSON(200, &exchanges)
+}
+
+// @Summary Полученные книги
+// @Description Возвращает историю книг, которые текущий пользователь получил.
+// @Tags exchanges
+// @Produce json
+// @Param limit query int false "Limit"
+// @Param cursor query string false "Cursor of the next page"
+// @Success 200 {object} dto.Page[dto.ExchangeDto]
+// @Failure 401 {object} exceptions.Error_
+// @Failure 404 {object} exceptions.Error_
+// @Failure 503 {object} exceptions.Error_
+// @Security BearerAuth
+// @Param Authorization header string true "Bearer JWT token"
+// @Router /exchanges/received [get]
+func (c *ExchangeController) GetReceivedExchanges(ctx *gin.Context) {
+	token, exc := utils.ExtractTokenFromHeaders(ctx)
+	if exc != nil {
+		ctx.JSON(int(exc.StatusCode), exc)
+		return
+	}
+
+	payload, exc := utils.ExtractPayloadFromJWT(*token)
+	if exc != nil {
+		ctx.JSON(int(exc.StatusCode), exc)
+		return
+	}
+
+	email := payload["email"].(string)
+
+	page, exc := utils.ParsePageRequest(ctx, dto.CursorKeyTime)
+	if exc != nil {
+		ctx.JSON(int(exc.StatusCode), exc)
+		return
+	}
+
+	exchanges, exc := c.es.GetReceivedExchanges(ctx, email, *page)
+	if exc != nil {
+		ctx.JSON(int(exc.StatusCode), exc)
+		return
+	}
+
+	ctx.JSON(200, &exchanges)
+}
+
+// @Summary Все обмены
+// @Description Возвращает историю всех обменов (доступно администраторам).
+// @Tags exchanges
+// @Produce json
+// @Param limit query int false "Limit"
+// @Param cursor query string false "Cursor of the next page"
+// @Success 200 {object} dto.Page[dto.ExchangeDto]
+// @Failure 401 {object} exceptions.Error_
+// @Failure 403 {object} exceptions.Error_
+// @Failure 503 {object} exceptions.Error_
+// @Security BearerAuth
+// @Param Authorization header string true "Bearer JWT token"
+// @Router /admin/exchanges [get]
+func (c *ExchangeController) GetAllExchanges(ctx *gin.Context) {
+	page, exc := utils.ParsePageRequest(ctx, dto.CursorKeyTime)
+	if exc != nil {
+		ctx.JSON(int(exc.StatusCode), exc)
+		return
+	}
+
+	exchanges, exc := c.es.GetAllExchanges(ctx, *page)
+	if exc != nil {
+		ctx.JSON(int(exc.StatusCode), exc)
+		return
+	}
+
+	ctx.JSON(200, &exchanges)
+}
@@ -0,0 +1,107 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"example.com/m/internal/api/v1/core/application/dto"
+	"example.com/m/internal/api/v1/core/application/services/loan_service"
+	"example.com/m/internal/api/v1/utils"
+	"github.com/gin-gonic/gin"
+)
+
+type LoanController struct {
+	ls *loan_service.LoanService
+}
+
+func NewLoanController(ls *loan_service.LoanService) *LoanController {
+	return &LoanController{ls: ls}
+}
+
+// @Summary Просроченные книги
+// @Description Возвращает книги, которые текущий пользователь дал или взял почитать и которые не вернули в срок.
+// @Tags loans
+// @Produce json
+// @Param limit query int false "Limit"
+// @Param cursor query string false "Cursor of the next page"
+// @Success 200 {object} dto.Page[dto.ExchangeDto]
+// @Failure 401 {object} exceptions.Error_
+// @Failure 404 {object} exceptions.Error_
+// @Failure 503 {object} exceptions.Error_
+// @Security BearerAuth
+// @Param Authorization header string true "Bearer JWT token"
+// @Router /loans/overdue [get]
+func (c *LoanController) GetOverdueLoans(ctx *gin.Context) {
+	token, exc := utils.ExtractTokenFromHeaders(ctx)
+	if exc != nil {
+		ctx.JSON(int(exc.StatusCode), exc)
+		return
+	}
+
+	payload, exc := utils.ExtractPayloadFromJWT(*token)
+	if exc != nil {
+		ctx.JSON(int(exc.StatusCode), exc)
+		return
+	}
+
+	email := payload["email"].(string)
+
+	page, exc := utils.ParsePageRequest(ctx, dto.CursorKeyTime)
+	if exc != nil {
+		ctx.JSON(int(exc.StatusCode), exc)
+		return
+	}
+
+	loans, exc := c.ls.GetOverdueLoans(ctx, email, *page)
+	if exc != nil {
+		ctx.JSON(int(exc.StatusCode), exc)
+		return
+	}
+
+	ctx.JSON(200, &loans)
+}
+
+// @Summary Подтвердить возврат книги
+// @Description Владелец подтверждает, что книгу, выданную почитать, вернули. Объявление снова становится доступным.
+// @Tags loans
+// @Produce json
+// @Param id path int true "Exchange ID"
+// @Success 200 {object} dto.ExchangeDto
+// @Failure 400 {object} exceptions.Error_
+// @Failure 401 {object} exceptions.Error_
+// @Failure 403 {object} exceptions.Error_
+// @Failure 404 {object} exceptions.Error_
+// @Failure 409 {object} exceptions.Error_
+// @Failure 503 {object} exceptions.Error_
+// @Security BearerAuth
+// @Param Authorization header string true "Bearer JWT token"
+// @Router /loans/{id}/return [post]
+func (c *LoanController) ConfirmReturn(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid exchange ID"})
+		return
+	}
+
+	token, exc := utils.ExtractTokenFromHeaders(ctx)
+	if exc != nil {
+		ctx.JSON(int(exc.StatusCode), exc)
+		return
+	}
+
+	payload, exc := utils.ExtractPayloadFromJWT(*token)
+	if exc != nil {
+		ctx.JSON(int(exc.StatusCode), exc)
+		return
+	}
+
+	email := payload["email"].(string)
+
+	loan, exc := c.ls.ConfirmReturn(ctx, email, id)
+	if exc != nil {
+		ctx.JSON(int(exc.StatusCode), exc)
+		return
+	}
+
+	ctx.JSON(200, loan)
+}
@@ -2,6 +2,7 @@ package controllers
 
 import (
 	"strconv"
+	"time"
 
 	"example.com/m/internal/api/v1/core/application/dto"
 	"example.com/m/internal/api/v1/core/application/services/place_service"
@@ -20,7 +21,7 @@ func NewPlaceController(s *place_service.PlaceService) *PlaceController {
 
 // CreatePlace создает новое место
 // @Summary Создать место
-// @Description Создает новое место (доступно только администраторам)
+// @Description Создает новое место (доступно администраторам)
 // @Tags places
 // @Accept json
 // @Produce json
@@ -80,7 +81,7 @@ func (c *PlaceController) GetPlaces(ctx *gin.Context) {
 
 // DeletePlace удаляет место
 // @Summary Удалить место
-// @Description Удаляет место по ID
+// @Description Удаляет место по ID (доступно администраторам и менеджерам этого места)
 // @Tags places
 // @Accept json
 // @Produce json
@@ -109,3 +110,183 @@ func (c *PlaceController) DeletePlace(ctx *gin.Context) {
 	}
 	ctx.JSON(204, nil)
 }
+
+// @Summary Часы работы места
+// @Description Возвращает часы работы места. weekday 0 — воскресенье, время в UTC.
+// @Tags places
+// @Produce json
+// @Param id path int true "ID места"
+// @Success 200 {array} dto.OpeningHoursDto
+// @Failure 400 {object} exceptions.Error_
+// @Failure 404 {object} exceptions.Error_
+// @Failure 503 {object} exceptions.Error_
+// @Router /places/{id}/hours [get]
+func (c *PlaceController) GetOpeningHours(ctx *gin.Context) {
+	id := ctx.Param("id")
+	idParsed, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		ctx.JSON(400, gin.H{"error": "Invalid place ID"})
+		return
+	}
+
+	hours, exc := c.ps.GetOpeningHours(ctx, idParsed)
+	if exc != nil {
+		ctx.JSON(int(exc.StatusCode), exc)
+		return
+	}
+	ctx.JSON(200, hours)
+}
+
+// @Summary Изменить часы работы места
+// @Description Заменяет часы работы места (доступно администраторам и менеджерам этого места). Пустой список удаляет их.
+// @Tags places
+// @Accept json
+// @Produce json
+// @Param id path int true "ID места"
+// @Param data body dto.SetOpeningHoursDto true "Часы работы"
+// @Success 200 {array} dto.OpeningHoursDto
+// @Failure 400 {object} exceptions.Error_
+// @Failure 401 {object} exceptions.Error_
+// @Failure 403 {object} exceptions.Error_
+// @Failure 404 {object} exceptions.Error_
+// @Failure 503 {object} exceptions.Error_
+// @Security BearerAuth
+// @Param Authorization header string true "Bearer JWT token"
+// @Router /places/{id}/hours [put]
+func (c *PlaceController) SetOpeningHours(ctx *gin.Context) {
+	id := ctx.Param("id")
+	idParsed, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		ctx.JSON(400, gin.H{"error": "Invalid place ID"})
+		return
+	}
+
+	var data dto.SetOpeningHoursDto
+	if err := ctx.ShouldBindBodyWithJSON(&data); err != nil {
+		ctx.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	hours, exc := c.ps.SetOpeningHours(ctx, idParsed, data.Hours)
+	if exc != nil {
+		ctx.JSON(int(exc.StatusCode), exc)
+		return
+	}
+	ctx.JSON(200, hours)
+}
+
+// @Summary Слоты получения книг
+// @Description Возвращает слоты места, начинающиеся в периоде [from, to). По умолчанию — неделя с текущего момента, не больше 31 дня.
+// @Tags places
+// @Produce json
+// @Param id path int true "ID места"
+// @Param from query string false "Начало периода, RFC 3339"
+// @Param to query string false "Конец периода, RFC 3339"
+// @Success 200 {array} dto.PickupSlotDto
+// @Failure 400 {object} exceptions.Error_
+// @Failure 404 {object} exceptions.Error_
+// @Failure 503 {object} exceptions.Error_
+// @Router /places/{id}/slots [get]
+func (c *PlaceController) GetPickupSlots(ctx *gin.Context) {
+	id := ctx.Param("id")
+	idParsed, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		ctx.JSON(400, gin.H{"error": "Invalid place ID"})
+		return
+	}
+
+	var from, to time.Time
+	if value := ctx.Query("from"); value != "" {
+		if from, err = time.Parse(time.RFC3339, value); err != nil {
+			ctx.JSON(400, gin.H{"error": "Invalid from"})
+			return
+		}
+	}
+	if value := ctx.Query("to"); value != "" {
+		if to, err = time.Parse(time.RFC3339, value); err != nil {
+			ctx.JSON(400, gin.H{"error": "Invalid to"})
+			return
+		}
+	}
+
+	slots, exc := c.ps.GetPickupSlots(ctx, idParsed, from, to)
+	if exc != nil {
+		ctx.JSON(int(exc.StatusCode), exc)
+		return
+	}
+	ctx.JSON(200, slots)
+}
+
+// @Summary Создать слот получения книг
+// @Description Создаёт слот в часы работы места, в который книги могут забрать не больше capacity человек (доступно администраторам и менеджерам этого места).
+// @Tags places
+// @Accept json
+// @Produce json
+// @Param id path int true "ID места"
+// @Param data body dto.CreatePickupSlotDto true "Слот"
+// @Success 201 {object} dto.PickupSlotDto
+// @Failure 400 {object} exceptions.Error_
+// @Failure 401 {object} exceptions.Error_
+// @Failure 403 {object} exceptions.Error_
+// @Failure 404 {object} exceptions.Error_
+// @Failure 503 {object} exceptions.Error_
+// @Security BearerAuth
+// @Param Authorization header string true "Bearer JWT token"
+// @Router /places/{id}/slots [post]
+func (c *PlaceController) CreatePickupSlot(ctx *gin.Context) {
+	id := ctx.Param("id")
+	idParsed, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		ctx.JSON(400, gin.H{"error": "Invalid place ID"})
+		return
+	}
+
+	var data dto.CreatePickupSlotDto
+	if err := ctx.ShouldBindBodyWithJSON(&data); err != nil {
+		ctx.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	slot, exc := c.ps.CreatePickupSlot(ctx, idParsed, &data)
+	if exc != nil {
+		ctx.JSON(int(exc.StatusCode), exc)
+		return
+	}
+	ctx.JSON(201, slot)
+}
+
+// @Summary Удалить слот получения книг
+// @Description Удаляет слот, если его не заняли активные брони (доступно администраторам и менеджерам этого места).
+// @Tags places
+// @Produce json
+// @Param id path int true "ID места"
+// @Param slot_id path int true "ID слота"
+// @Success 204 {object} nil
+// @Failure 400 {object} exceptions.Error_
+// @Failure 401 {object} exceptions.Error_
+// @Failure 403 {object} exceptions.Error_
+// @Failure 404 {object} exceptions.Error_
+// @Failure 409 {object} exceptions.Error_
+// @Failure 503 {object} exceptions.Error_
+// @Security BearerAuth
+// @Param Authorization header string true "Bearer JWT token"
+// @Router /places/{id}/slots/{slot_id} [delete]
+func (c *PlaceController) DeletePickupSlot(ctx *gin.Context) {
+	idParsed, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(400, gin.H{"error": "Invalid place ID"})
+		return
+	}
+	slotID, err := strconv.ParseInt(ctx.Param("slot_id"), 10, 64)
+	if err != nil {
+		ctx.JSON(400, gin.H{"error": "Invalid slot ID"})
+		return
+	}
+
+	exc := c.ps.DeletePickupSlot(ctx, idParsed, slotID)
+	if exc != nil {
+		ctx.JSON(int(exc.StatusCode), exc)
+		return
+	}
+	ctx.JSON(204, nil)
+}
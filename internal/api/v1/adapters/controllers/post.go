@@ -2,6 +2,7 @@ package controllers
 
 import (
 	"strconv"
+	"strings"
 
 	"example.com/m/internal/api/v1/adapters/repositories"
 	"example.com/m/internal/api/v1/core/application/dto"
@@ -120,8 +121,8 @@ func (c *PostController) GetPost(ctx *gin.Context) {
 // @Produce json
 // @Param status query string false "Post status" Enums(available, booked, taken, all) default(all)
 // @Param limit query int false "Limit"
-// @Param offset query int false "Offset"
-// @Success 200 {array} dto.PostToGetDto
+// @Param cursor query string false "Cursor of the next page"
+// @Success 200 {object} dto.Page[dto.PostDto]
 // @Failure 400 {object} exceptions.Error_
 // @Failure 401 {object} exceptions.Error_
 // @Failure 500 {object} exceptions.Error_
@@ -154,18 +155,15 @@ func (c *PostController) GetMyPosts(ctx *gin.Context) {
 		return
 	}
 
-	limit, _ := strconv.ParseUint(ctx.Query("limit"), 10, 64)
-	offset, _ := strconv.ParseUint(ctx.Query("offset"), 10, 64)
-
-	posts, exc := c.ps.GetMyPosts(ctx, email, status, uint(limit), uint(offset))
+	page, exc := utils.ParsePageRequest(ctx, dto.CursorKeyTime)
 	if exc != nil {
 		ctx.JSON(int(exc.StatusCode), exc)
 		return
 	}
 
-	if len(*posts) == 0 {
-		data := make([]string, 0)
-		ctx.JSON(200, data)
+	posts, exc := c.ps.GetMyPosts(ctx, email, status, *page)
+	if exc != nil {
+		ctx.JSON(int(exc.StatusCode), exc)
 		return
 	}
 	ctx.JSON(200, &posts)
@@ -260,17 +258,26 @@ func (c *PostController) DeleteFavorite(ctx *gin.Context) {
 }
 
 // @Summary Получить все доступные посты
-// @Description Получить все доступные посты. Есть возможность отфильтровать.
+// @Description Получить все доступные посты. Есть возможность отфильтровать и отсортировать. Вместе с первой страницей постов возвращается количество постов по значениям фильтров.
 // @Tags posts
 // @Accept json
 // @Produce json
-// @Param genre query string false "Post genre"
+// @Param genre query []string false "Post genres" collectionFormat(multi)
 // @Param condition query string false "Post condition"
 // @Param publicationYear query string false "Post publication year"
-// @Param placeId query string false "Post place id"
+// @Param yearFrom query int false "Minimal publication year"
+// @Param yearTo query int false "Maximal publication year"
+// @Param pagesFrom query int false "Minimal pages count"
+// @Param pagesTo query int false "Maximal pages count"
+// @Param placeId query []int false "Post place ids" collectionFormat(multi)
+// @Param publisher query string false "Post publisher"
+// @Param cover query string false "Post cover"
+// @Param city query string false "City of the post place"
+// @Param listingType query string false "Listing type" Enums(giveaway, loan)
+// @Param sort query string false "Sort order" Enums(newest, oldest, title, rating) default(newest)
 // @Param limit query int false "Limit"
-// @Param offset query int false "Offset"
-// @Success 200 {array} dto.PostDto
+// @Param cursor query string false "Cursor of the next page"
+// @Success 200 {object} dto.AvailablePostsDto
 // @Failure 400 {object} exceptions.Error_
 // @Failure 401 {object} exceptions.Error_
 // @Failure 500 {object} exceptions.Error_
@@ -293,44 +300,82 @@ func (c *PostController) GetAllAvailablePosts(ctx *gin.Context) {
 
 	email := payload["email"].(string)
 
-	limit, _ := strconv.ParseUint(ctx.Query("limit"), 10, 64)
-	offset, _ := strconv.ParseUint(ctx.Query("offset"), 10, 64)
+	page, exc := utils.ParsePageRequest(ctx, repositories.PostSortCursorKey(ctx.Query("sort")))
+	if exc != nil {
+		ctx.JSON(int(exc.StatusCode), exc)
+		return
+	}
 
-	placeIDStr := ctx.Query("placeId")
-	placeID, err := strconv.ParseInt(placeIDStr, 10, 64)
-	if err != nil {
-		placeID = 0 // or handle the error as needed
+	// both ?placeId=1&placeId=2 and ?placeId=1,2 are accepted
+	var placeIDs []int64
+	for _, placeIDStr := range splitQueryArray(ctx, "placeId") {
+		placeID, err := strconv.ParseInt(placeIDStr, 10, 64)
+		if err != nil {
+			ctx.JSON(400, gin.H{"error": "Invalid place ID"})
+			return
+		}
+		placeIDs = append(placeIDs, placeID)
+	}
+	// absent bounds are 0, which means no bound
+	bounds := map[string]int{"yearFrom": 0, "yearTo": 0, "pagesFrom": 0, "pagesTo": 0}
+	for key := range bounds {
+		value := ctx.Query(key)
+		if value == "" {
+			continue
+		}
+		bound, err := strconv.Atoi(value)
+		if err != nil {
+			ctx.JSON(400, gin.H{"error": "Invalid " + key})
+			return
+		}
+		bounds[key] = bound
 	}
 	options := repositories.PostFilterOptions{
-		Genre:           ctx.Query("genre"),
+		Genres:          splitQueryArray(ctx, "genre"),
 		Condition:       ctx.Query("condition"),
 		PublicationYear: ctx.Query("publicationYear"),
-		PlaceID:         placeID,
-	}
-
-	posts, exc := c.ps.GetAllAvailablePosts(ctx, email, options, uint(limit), uint(offset))
+		YearFrom:        bounds["yearFrom"],
+		YearTo:          bounds["yearTo"],
+		PagesFrom:       bounds["pagesFrom"],
+		PagesTo:         bounds["pagesTo"],
+		PlaceIDs:        placeIDs,
+		Publisher:       ctx.Query("publisher"),
+		Cover:           ctx.Query("cover"),
+		City:            ctx.Query("city"),
+		ListingType:     ctx.Query("listingType"),
+		Sort:            ctx.Query("sort"),
+	}
+
+	posts, exc := c.ps.GetAllAvailablePosts(ctx, email, options, *page)
 	if exc != nil {
 		ctx.JSON(int(exc.StatusCode), exc)
 		return
 	}
 
-	if len(*posts) == 0 {
-		data := make([]string, 0)
-		ctx.JSON(200, data)
-		return
-	}
-
 	ctx.JSON(200, &posts)
 }
 
+// splitQueryArray returns non-empty values of a repeated and/or comma separated query parameter.
+func splitQueryArray(ctx *gin.Context, key string) []string {
+	var values []string
+	for _, param := range ctx.QueryArray(key) {
+		for _, value := range strings.Split(param, ",") {
+			if value = strings.TrimSpace(value); value != "" {
+				values = append(values, value)
+			}
+		}
+	}
+	return values
+}
+
 // @Summary Получить все избранные посты
 // @Description Получить все избранные посты пользователя.
 // @Tags posts
 // @Accept json
 // @Produce json
 // @Param limit query int false "Limit"
-// @Param offset query int false "Offset"
-// @Success 200 {array} dto.PostDto
+// @Param cursor query string false "Cursor of the next page"
+// @Success 200 {object} dto.Page[dto.PostDto]
 // @Failure 400 {object} exceptions.Error_
 // @Failure 401 {object} exceptions.Error_
 // @Failure 500 {object} exceptions.Error_
@@ -353,33 +398,30 @@ func (c *PostController) GetAllFavourites(ctx *gin.Context) {
 
 	email := payload["email"].(string)
 
-	limit, _ := strconv.ParseUint(ctx.Query("limit"), 10, 64)
-	offset, _ := strconv.ParseUint(ctx.Query("offset"), 10, 64)
-
-	posts, exc := c.ps.GetAllFavourites(ctx, email, uint(limit), uint(offset))
+	page, exc := utils.ParsePageRequest(ctx, dto.CursorKeyTime)
 	if exc != nil {
 		ctx.JSON(int(exc.StatusCode), exc)
 		return
 	}
 
-	if len(*posts) == 0 {
-		data := make([]string, 0)
-		ctx.JSON(200, data)
+	posts, exc := c.ps.GetAllFavourites(ctx, email, *page)
+	if exc != nil {
+		ctx.JSON(int(exc.StatusCode), exc)
 		return
 	}
 
 	ctx.JSON(200, &posts)
 }
 
-// @Summary Поиск постов по названию или автору
-// @Description Поиск постов по названию или автору.
+// @Summary Поиск постов по названию, автору или жанру
+// @Description Полнотекстовый поиск доступных постов с учетом опечаток в авторе и названии. Результаты отсортированы по релевантности и содержат фрагмент с подсветкой совпадений.
 // @Tags posts
 // @Accept json
 // @Produce json
 // @Param query query string true "Search query"
 // @Param limit query int false "Limit"
-// @Param offset query int false "Offset"
-// @Success 200 {array} dto.PostDto
+// @Param cursor query string false "Cursor of the next page"
+// @Success 200 {object} dto.Page[dto.PostDto]
 // @Failure 400 {object} exceptions.Error_
 // @Failure 401 {object} exceptions.Error_
 // @Failure 500 {object} exceptions.Error_
@@ -403,18 +445,15 @@ func (r *PostController) SearchByTitleOrAuthorOrGenre(ctx *gin.Context) {
 	email := payload["email"].(string)
 
 	query := ctx.Query("query")
-	limit, _ := strconv.ParseUint(ctx.Query("limit"), 10, 64)
-	offset, _ := strconv.ParseUint(ctx.Query("offset"), 10, 64)
-
-	posts, exc := r.ps.SearchByTitleOrAuthorOrGenre(ctx, query, uint(limit), uint(offset), email)
+	page, exc := utils.ParsePageRequest(ctx, dto.CursorKeyNumber)
 	if exc != nil {
 		ctx.JSON(int(exc.StatusCode), exc)
 		return
 	}
 
-	if len(*posts) == 0 {
-		data := make([]string, 0)
-		ctx.JSON(200, data)
+	posts, exc := r.ps.SearchByTitleOrAuthorOrGenre(ctx, query, *page, email)
+	if exc != nil {
+		ctx.JSON(int(exc.StatusCode), exc)
 		return
 	}
 
@@ -427,8 +466,8 @@ func (r *PostController) SearchByTitleOrAuthorOrGenre(ctx *gin.Context) {
 // @Accept json
 // @Produce json
 // @Param limit query int false "Limit"
-// @Param offset query int false "Offset"
-// @Success 200 {array} dto.PostDto
+// @Param cursor query string false "Cursor of the next page"
+// @Success 200 {object} dto.Page[dto.PostDto]
 // @Failure 400 {object} exceptions.Error_
 // @Failure 401 {object} exceptions.Error_
 // @Failure 500 {object} exceptions.Error_
@@ -451,18 +490,15 @@ func (c *PostController) GetAllMyBooked(ctx *gin.Context) {
 
 	email := payload["email"].(string)
 
-	limit, _ := strconv.ParseUint(ctx.Query("limit"), 10, 64)
-	offset, _ := strconv.ParseUint(ctx.Query("offset"), 10, 64)
-
-	posts, exc := c.ps.GetAllMyBooked(ctx, email, uint(limit), uint(offset))
+	page, exc := utils.ParsePageRequest(ctx, dto.CursorKeyTime)
 	if exc != nil {
 		ctx.JSON(int(exc.StatusCode), exc)
 		return
 	}
 
-	if len(*posts) == 0 {
-		data := make([]string, 0)
-		ctx.JSON(200, data)
+	posts, exc := c.ps.GetAllMyBooked(ctx, email, *page)
+	if exc != nil {
+		ctx.JSON(int(exc.StatusCode), exc)
 		return
 	}
 
@@ -516,3 +552,265 @@ func (c *PostController) AddImage(ctx *gin.Context) {
 	go c.ps.AddImage(ctx, idParsed, header, file, email)
 	ctx.JSON(200, gin.H{"message": "ok"})
 }
+
+// @Summary Изменить объявление
+// @Description Изменяет поля объявления. Доступно только владельцу, статус объявления меняется только бронированиями.
+// @Tags posts
+// @Accept json
+// @Produce json
+// @Param id path int true "Post ID"
+// @Param post body dto.UpdatePostDto true "Post fields to update"
+// @Success 200 {object} dto.PostDto
+// @Failure 400 {object} exceptions.Error_
+// @Failure 401 {object} exceptions.Error_
+// @Failure 403 {object} exceptions.Error_
+// @Failure 404 {object} exceptions.Error_
+// @Failure 503 {object} exceptions.Error_
+// @Security BearerAuth
+// @Param Authorization header string true "Bearer JWT token"
+// @Router /posts/{id} [patch]
+func (c *PostController) UpdatePost(ctx *gin.Context) {
+	id := ctx.Param("id")
+
+	idParsed, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		ctx.JSON(400, gin.H{"error": "Invalid post ID"})
+		return
+	}
+
+	token, exc := utils.ExtractTokenFromHeaders(ctx)
+	if exc != nil {
+		ctx.JSON(int(exc.StatusCode), exc)
+		return
+	}
+
+	payload, exc := utils.ExtractPayloadFromJWT(*token)
+	if exc != nil {
+		ctx.JSON(int(exc.StatusCode), exc)
+		return
+	}
+
+	email := payload["email"].(string)
+
+	var post dto.UpdatePostDto
+	if err := ctx.ShouldBindBodyWithJSON(&post); err != nil {
+		ctx.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	updatedPost, exc := c.ps.UpdatePost(ctx, email, idParsed, &post)
+	if exc != nil {
+		ctx.JSON(int(exc.StatusCode), exc)
+		return
+	}
+
+	ctx.JSON(200, &updatedPost)
+}
+
+// @Summary Удалить объявление
+// @Description Удаляет объявление вместе с его изображениями. Доступно только владельцу, забронированное объявление удалить нельзя.
+// @Tags posts
+// @Produce json
+// @Param id path int true "Post ID"
+// @Success 200 {object} map[string]interface{} "success"
+// @Failure 400 {object} exceptions.Error_
+// @Failure 401 {object} exceptions.Error_
+// @Failure 403 {object} exceptions.Error_
+// @Failure 404 {object} exceptions.Error_
+// @Failure 409 {object} exceptions.Error_
+// @Failure 503 {object} exceptions.Error_
+// @Security BearerAuth
+// @Param Authorization header string true "Bearer JWT token"
+// @Router /posts/{id} [delete]
+func (c *PostController) DeletePost(ctx *gin.Context) {
+	id := ctx.Param("id")
+
+	idParsed, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		ctx.JSON(400, gin.H{"error": "Invalid post ID"})
+		return
+	}
+
+	token, exc := utils.ExtractTokenFromHeaders(ctx)
+	if exc != nil {
+		ctx.JSON(int(exc.StatusCode), exc)
+		return
+	}
+
+	payload, exc := utils.ExtractPayloadFromJWT(*token)
+	if exc != nil {
+		ctx.JSON(int(exc.StatusCode), exc)
+		return
+	}
+
+	email := payload["email"].(string)
+
+	exc = c.ps.DeletePost(ctx, email, idParsed)
+	if exc != nil {
+		ctx.JSON(int(exc.StatusCode), exc)
+		return
+	}
+
+	ctx.JSON(200, gin.H{"success": true})
+}
+
+// @Summary Изменить порядок изображений
+// @Description Задает новый порядок всех изображений объявления, первое изображение становится основным.
+// @Tags posts
+// @Accept json
+// @Produce json
+// @Param id path int true "Post ID"
+// @Param images body dto.ReorderImagesDto true "Images in the new order"
+// @Success 200 {object} dto.PostDto
+// @Failure 400 {object} exceptions.Error_
+// @Failure 401 {object} exceptions.Error_
+// @Failure 403 {object} exceptions.Error_
+// @Failure 404 {object} exceptions.Error_
+// @Failure 503 {object} exceptions.Error_
+// @Security BearerAuth
+// @Param Authorization header string true "Bearer JWT token"
+// @Router /posts/{id}/images [put]
+func (c *PostController) ReorderImages(ctx *gin.Context) {
+	id := ctx.Param("id")
+
+	idParsed, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		ctx.JSON(400, gin.H{"error": "Invalid post ID"})
+		return
+	}
+
+	token, exc := utils.ExtractTokenFromHeaders(ctx)
+	if exc != nil {
+		ctx.JSON(int(exc.StatusCode), exc)
+		return
+	}
+
+	payload, exc := utils.ExtractPayloadFromJWT(*token)
+	if exc != nil {
+		ctx.JSON(int(exc.StatusCode), exc)
+		return
+	}
+
+	email := payload["email"].(string)
+
+	var body dto.ReorderImagesDto
+	if err := ctx.ShouldBindBodyWithJSON(&body); err != nil {
+		ctx.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	post, exc := c.ps.ReorderImages(ctx, email, idParsed, body.Images)
+	if exc != nil {
+		ctx.JSON(int(exc.StatusCode), exc)
+		return
+	}
+
+	ctx.JSON(200, &post)
+}
+
+// @Summary Выбрать основное изображение
+// @Description Делает изображение основным, перемещая его на первое место.
+// @Tags posts
+// @Accept json
+// @Produce json
+// @Param id path int true "Post ID"
+// @Param image body dto.ImageDto true "Image URL"
+// @Success 200 {object} dto.PostDto
+// @Failure 400 {object} exceptions.Error_
+// @Failure 401 {object} exceptions.Error_
+// @Failure 403 {object} exceptions.Error_
+// @Failure 404 {object} exceptions.Error_
+// @Failure 503 {object} exceptions.Error_
+// @Security BearerAuth
+// @Param Authorization header string true "Bearer JWT token"
+// @Router /posts/{id}/images/primary [put]
+func (c *PostController) SetPrimaryImage(ctx *gin.Context) {
+	id := ctx.Param("id")
+
+	idParsed, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		ctx.JSON(400, gin.H{"error": "Invalid post ID"})
+		return
+	}
+
+	token, exc := utils.ExtractTokenFromHeaders(ctx)
+	if exc != nil {
+		ctx.JSON(int(exc.StatusCode), exc)
+		return
+	}
+
+	payload, exc := utils.ExtractPayloadFromJWT(*token)
+	if exc != nil {
+		ctx.JSON(int(exc.StatusCode), exc)
+		return
+	}
+
+	email := payload["email"].(string)
+
+	var body dto.ImageDto
+	if err := ctx.ShouldBindBodyWithJSON(&body); err != nil {
+		ctx.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	post, exc := c.ps.SetPrimaryImage(ctx, email, idParsed, body.Url)
+	if exc != nil {
+		ctx.JSON(int(exc.StatusCode), exc)
+		return
+	}
+
+	ctx.JSON(200, &post)
+}
+
+// @Summary Удалить изображение
+// @Description Удаляет изображение из объявления и из хранилища.
+// @Tags posts
+// @Produce json
+// @Param id path int true "Post ID"
+// @Param url query string true "Image URL"
+// @Success 200 {object} dto.PostDto
+// @Failure 400 {object} exceptions.Error_
+// @Failure 401 {object} exceptions.Error_
+// @Failure 403 {object} exceptions.Error_
+// @Failure 404 {object} exceptions.Error_
+// @Failure 500 {object} exceptions.Error_
+// @Security BearerAuth
+// @Param Authorization header string true "Bearer JWT token"
+// @Router /posts/{id}/images [delete]
+func (c *PostController) RemoveImage(ctx *gin.Context) {
+	id := ctx.Param("id")
+
+	idParsed, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		ctx.JSON(400, gin.H{"error": "Invalid post ID"})
+		return
+	}
+
+	imageURL := ctx.Query("url")
+	if imageURL == "" {
+		ctx.JSON(int(exceptions.ErrNotAllFields.StatusCode), exceptions.ErrNotAllFields)
+		return
+	}
+
+	token, exc := utils.ExtractTokenFromHeaders(ctx)
+	if exc != nil {
+		ctx.JSON(int(exc.StatusCode), exc)
+		return
+	}
+
+	payload, exc := utils.ExtractPayloadFromJWT(*token)
+	if exc != nil {
+		ctx.JSON(int(exc.StatusCode), exc)
+		return
+	}
+
+	email := payload["email"].(string)
+
+	post, exc := c.ps.RemoveImage(ctx, email, idParsed, imageURL)
+	if exc != nil {
+		ctx.JSON(int(exc.StatusCode), exc)
+		return
+	}
+
+	ctx.JSON(200, &post)
+}
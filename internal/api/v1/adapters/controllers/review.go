@@ -2,6 +2,7 @@ package controllers
 
 import (
 	"net/http"
+	"strconv"
 
 	"example.com/m/internal/api/v1/core/application/dto"
 	"example.com/m/internal/api/v1/core/application/services/review_service"
@@ -18,7 +19,7 @@ func NewReviewController(rs *review_service.ReviewService) *ReviewController {
 }
 
 // @Summary Создание нового отзыва (от пользователя к пользователю)
-// @Description Создает отзыв по email пользователя
+// @Description Создает отзыв на другого участника обмена. Каждый участник может оставить один отзыв на обмен.
 // @Tags reviews
 // @Accept json
 // @Produce json
@@ -26,6 +27,9 @@ func NewReviewController(rs *review_service.ReviewService) *ReviewController {
 // @Success 201 {object} exceptions.Error_
 // @Failure 400 {object} exceptions.Error_
 // @Failure 401 {object} exceptions.Error_
+// @Failure 403 {object} exceptions.Error_
+// @Failure 404 {object} exceptions.Error_
+// @Failure 409 {object} exceptions.Error_
 // @Failure 500 {object} exceptions.Error_
 // @Failure 503 {object} exceptions.Error_
 // @Security BearerAuth
@@ -69,7 +73,9 @@ func (rc *ReviewController) CreateReview(ctx *gin.Context) {
 // @Tags reviews
 // @Produce json
 // @Param username path string true "Username"
-// @Success 200 {array} dto.ReviewToGetDto
+// @Param limit query int false "Limit"
+// @Param cursor query string false "Cursor of the next page"
+// @Success 200 {object} dto.Page[dto.ReviewToGetDto]
 // @Failure 400 {object} exceptions.Error_
 // @Failure 401 {object} exceptions.Error_
 // @Failure 404 {object} exceptions.Error_
@@ -81,17 +87,166 @@ func (rc *ReviewController) CreateReview(ctx *gin.Context) {
 func (rc *ReviewController) GetReviewsForUser(ctx *gin.Context) {
 	targetUsername := ctx.Param("username")
 
-	reviews, exc := rc.rs.GetReviewsForUser(ctx.Request.Context(), targetUsername, 0, 0)
+	page, exc := utils.ParsePageRequest(ctx, dto.CursorKeyTime)
 	if exc != nil {
 		ctx.JSON(int(exc.StatusCode), exc)
 		return
 	}
 
-	if len(*reviews) == 0 {
-		data := make([]string, 0)
-		ctx.JSON(404, data)
+	reviews, exc := rc.rs.GetReviewsForUser(ctx.Request.Context(), targetUsername, *page)
+	if exc != nil {
+		ctx.JSON(int(exc.StatusCode), exc)
 		return
 	}
 
 	ctx.JSON(200, reviews)
 }
+
+// @Summary Изменение отзыва
+// @Description Изменяет оценку или комментарий отзыва. Доступно автору, пока не истекло время на редактирование.
+// @Tags reviews
+// @Accept json
+// @Produce json
+// @Param id path int true "Review ID"
+// @Param review body dto.ReviewToUpdateDto true "Review fields to update"
+// @Success 200 {object} map[string]interface{} "success"
+// @Failure 400 {object} exceptions.Error_
+// @Failure 401 {object} exceptions.Error_
+// @Failure 403 {object} exceptions.Error_
+// @Failure 404 {object} exceptions.Error_
+// @Failure 503 {object} exceptions.Error_
+// @Security BearerAuth
+// @Param Authorization header string true "Bearer JWT token"
+// @Router /reviews/{id} [patch]
+func (rc *ReviewController) UpdateReview(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid review ID"})
+		return
+	}
+
+	var review dto.ReviewToUpdateDto
+	if err := ctx.ShouldBindJSON(&review); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if exc := review.Validate(); exc != nil {
+		ctx.JSON(int(exc.StatusCode), exc)
+		return
+	}
+
+	token, exc := utils.ExtractTokenFromHeaders(ctx)
+	if exc != nil {
+		ctx.JSON(int(exc.StatusCode), exc)
+		return
+	}
+
+	payload, exc := utils.ExtractPayloadFromJWT(*token)
+	if exc != nil {
+		ctx.JSON(int(exc.StatusCode), exc)
+		return
+	}
+
+	email := payload["email"].(string)
+
+	if exc := rc.rs.UpdateReview(ctx.Request.Context(), email, id, &review); exc != nil {
+		ctx.JSON(int(exc.StatusCode), exc)
+		return
+	}
+
+	ctx.JSON(200, gin.H{"success": true})
+}
+
+// @Summary Удаление отзыва
+// @Description Удаляет отзыв. Доступно автору, пока не истекло время на редактирование.
+// @Tags reviews
+// @Produce json
+// @Param id path int true "Review ID"
+// @Success 200 {object} map[string]interface{} "success"
+// @Failure 400 {object} exceptions.Error_
+// @Failure 401 {object} exceptions.Error_
+// @Failure 403 {object} exceptions.Error_
+// @Failure 404 {object} exceptions.Error_
+// @Failure 503 {object} exceptions.Error_
+// @Security BearerAuth
+// @Param Authorization header string true "Bearer JWT token"
+// @Router /reviews/{id} [delete]
+func (rc *ReviewController) DeleteReview(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid review ID"})
+		return
+	}
+
+	token, exc := utils.ExtractTokenFromHeaders(ctx)
+	if exc != nil {
+		ctx.JSON(int(exc.StatusCode), exc)
+		return
+	}
+
+	payload, exc := utils.ExtractPayloadFromJWT(*token)
+	if exc != nil {
+		ctx.JSON(int(exc.StatusCode), exc)
+		return
+	}
+
+	email := payload["email"].(string)
+
+	if exc := rc.rs.DeleteReview(ctx.Request.Context(), email, id); exc != nil {
+		ctx.JSON(int(exc.StatusCode), exc)
+		return
+	}
+
+	ctx.JSON(200, gin.H{"success": true})
+}
+
+// @Summary Скрыть отзыв
+// @Description Скрывает отзыв из профиля пользователя и из его рейтинга (доступно модераторам и администраторам).
+// @Tags reviews
+// @Produce json
+// @Param id path int true "Review ID"
+// @Success 200 {object} map[string]interface{} "success"
+// @Failure 400 {object} exceptions.Error_
+// @Failure 401 {object} exceptions.Error_
+// @Failure 403 {object} exceptions.Error_
+// @Failure 404 {object} exceptions.Error_
+// @Failure 503 {object} exceptions.Error_
+// @Security BearerAuth
+// @Param Authorization header string true "Bearer JWT token"
+// @Router /admin/reviews/{id}/hide [put]
+func (rc *ReviewController) HideReview(ctx *gin.Context) {
+	rc.setReviewHidden(ctx, true)
+}
+
+// @Summary Показать отзыв
+// @Description Возвращает скрытый отзыв в профиль пользователя (доступно модераторам и администраторам).
+// @Tags reviews
+// @Produce json
+// @Param id path int true "Review ID"
+// @Success 200 {object} map[string]interface{} "success"
+// @Failure 400 {object} exceptions.Error_
+// @Failure 401 {object} exceptions.Error_
+// @Failure 403 {object} exceptions.Error_
+// @Failure 404 {object} exceptions.Error_
+// @Failure 503 {object} exceptions.Error_
+// @Security BearerAuth
+// @Param Authorization header string true "Bearer JWT token"
+// @Router /admin/reviews/{id}/hide [delete]
+func (rc *ReviewController) UnhideReview(ctx *gin.Context) {
+	rc.setReviewHidden(ctx, false)
+}
+
+func (rc *ReviewController) setReviewHidden(ctx *gin.Context, hidden bool) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid review ID"})
+		return
+	}
+
+	if exc := rc.rs.SetReviewHidden(ctx.Request.Context(), id, hidden); exc != nil {
+		ctx.JSON(int(exc.StatusCode), exc)
+		return
+	}
+
+	ctx.JSON(200, gin.H{"success": true})
+}
@@ -0,0 +1,168 @@
+package controllers
+
+import (
+	"strconv"
+
+	"example.com/m/internal/api/v1/core/application/dto"
+	"example.com/m/internal/api/v1/core/application/services/role_service"
+	"example.com/m/internal/api/v1/utils"
+	"github.com/gin-gonic/gin"
+)
+
+type RoleController struct {
+	rs role_service.RoleService
+}
+
+func NewRoleController(rs *role_service.RoleService) *RoleController {
+	return &RoleController{
+		rs: *rs,
+	}
+}
+
+// GetMyRoles возвращает роли текущего пользователя
+// @Summary Мои роли
+// @Description Возвращает роли текущего пользователя. Роль place_manager действует только для места place_id.
+// @Tags roles
+// @Produce json
+// @Success 200 {array} dto.RoleDto
+// @Failure 401 {object} exceptions.Error_
+// @Failure 503 {object} exceptions.Error_
+// @Security BearerAuth
+// @Param Authorization header string true "Bearer JWT token"
+// @Router /users/me/roles [get]
+func (c *RoleController) GetMyRoles(ctx *gin.Context) {
+	token, err := utils.ExtractTokenFromHeaders(ctx)
+	if err != nil {
+		ctx.JSON(int(err.StatusCode), err)
+		return
+	}
+	payload, err := utils.ExtractPayloadFromJWT(*token)
+	if err != nil {
+		ctx.JSON(int(err.StatusCode), err)
+		return
+	}
+	email := payload["email"].(string)
+
+	roles, err := c.rs.GetRoles(ctx, email)
+	if err != nil {
+		ctx.JSON(int(err.StatusCode), err)
+		return
+	}
+	ctx.JSON(200, roles)
+}
+
+// GetUserRoles возвращает роли пользователя
+// @Summary Роли пользователя
+// @Description Возвращает роли пользователя (доступно только администраторам)
+// @Tags roles
+// @Produce json
+// @Param username path string true "Username"
+// @Success 200 {array} dto.RoleDto
+// @Failure 401 {object} exceptions.Error_
+// @Failure 403 {object} exceptions.Error_
+// @Failure 404 {object} exceptions.Error_
+// @Failure 503 {object} exceptions.Error_
+// @Security BearerAuth
+// @Param Authorization header string true "Bearer JWT token"
+// @Router /admin/users/{username}/roles [get]
+func (c *RoleController) GetUserRoles(ctx *gin.Context) {
+	roles, err := c.rs.GetRolesByUsername(ctx, ctx.Param("username"))
+	if err != nil {
+		ctx.JSON(int(err.StatusCode), err)
+		return
+	}
+	ctx.JSON(200, roles)
+}
+
+// GrantRole выдает роль пользователю
+// @Summary Выдать роль
+// @Description Выдает роль пользователю (доступно только администраторам). Для place_manager нужно указать place_id, для остальных ролей place_id не указывается.
+// @Tags roles
+// @Accept json
+// @Produce json
+// @Param username path string true "Username"
+// @Param role body dto.GrantRoleDto true "Роль"
+// @Success 200 {array} dto.RoleDto
+// @Failure 400 {object} exceptions.Error_
+// @Failure 401 {object} exceptions.Error_
+// @Failure 403 {object} exceptions.Error_
+// @Failure 404 {object} exceptions.Error_
+// @Failure 503 {object} exceptions.Error_
+// @Security BearerAuth
+// @Param Authorization header string true "Bearer JWT token"
+// @Router /admin/users/{username}/roles [post]
+func (c *RoleController) GrantRole(ctx *gin.Context) {
+	var role dto.GrantRoleDto
+	if err := ctx.ShouldBindBodyWithJSON(&role); err != nil {
+		ctx.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	token, err := utils.ExtractTokenFromHeaders(ctx)
+	if err != nil {
+		ctx.JSON(int(err.StatusCode), err)
+		return
+	}
+	payload, err := utils.ExtractPayloadFromJWT(*token)
+	if err != nil {
+		ctx.JSON(int(err.StatusCode), err)
+		return
+	}
+	email := payload["email"].(string)
+
+	roles, err := c.rs.GrantRole(ctx, email, ctx.Param("username"), role)
+	if err != nil {
+		ctx.JSON(int(err.StatusCode), err)
+		return
+	}
+	ctx.JSON(200, roles)
+}
+
+// RevokeRole отзывает роль у пользователя
+// @Summary Отозвать роль
+// @Description Отзывает роль у пользователя (доступно только администраторам)
+// @Tags roles
+// @Produce json
+// @Param username path string true "Username"
+// @Param role path string true "Роль" Enums(moderator, place_manager, admin)
+// @Param place_id query int false "Место, для place_manager"
+// @Success 200
+// @Failure 400 {object} exceptions.Error_
+// @Failure 401 {object} exceptions.Error_
+// @Failure 403 {object} exceptions.Error_
+// @Failure 404 {object} exceptions.Error_
+// @Failure 503 {object} exceptions.Error_
+// @Security BearerAuth
+// @Param Authorization header string true "Bearer JWT token"
+// @Router /admin/users/{username}/roles/{role} [delete]
+func (c *RoleController) RevokeRole(ctx *gin.Context) {
+	var placeID *int64
+	if value := ctx.Query("place_id"); value != "" {
+		id, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			ctx.JSON(400, gin.H{"error": "Invalid place ID"})
+			return
+		}
+		placeID = &id
+	}
+
+	token, err := utils.ExtractTokenFromHeaders(ctx)
+	if err != nil {
+		ctx.JSON(int(err.StatusCode), err)
+		return
+	}
+	payload, err := utils.ExtractPayloadFromJWT(*token)
+	if err != nil {
+		ctx.JSON(int(err.StatusCode), err)
+		return
+	}
+	email := payload["email"].(string)
+
+	if err := c.rs.RevokeRole(ctx, email, ctx.Param("username"), ctx.Param("role"), placeID); err != nil {
+		ctx.JSON(int(err.StatusCode), err)
+		return
+	}
+	ctx.JSON(200, gin.H{
+		"success": true,
+	})
+}
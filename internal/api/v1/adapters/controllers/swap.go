@@ -0,0 +1,396 @@
+package controllers
+
+import (
+	"strconv"
+
+	"example.com/m/internal/api/v1/core/application/dto"
+	"example.com/m/internal/api/v1/core/application/services/swap_service"
+	"example.com/m/internal/api/v1/utils"
+	"github.com/gin-gonic/gin"
+)
+
+type SwapController struct {
+	ss *swap_service.SwapService
+}
+
+func NewSwapController(ss *swap_service.SwapService) *SwapController {
+	return &SwapController{ss: ss}
+}
+
+// @Summary Предложить обмен
+// @Description Предлагает одну или несколько своих доступных книг в обмен на книгу другого пользователя.
+// @Tags swaps
+// @Accept json
+// @Produce json
+// @Param id path int true "Post ID"
+// @Param data body dto.CreateSwapOfferDto true "Offered posts"
+// @Success 200 {object} dto.SwapOfferDto
+// @Failure 400 {object} exceptions.Error_
+// @Failure 401 {object} exceptions.Error_
+// @Failure 403 {object} exceptions.Error_
+// @Failure 404 {object} exceptions.Error_
+// @Failure 503 {object} exceptions.Error_
+// @Security BearerAuth
+// @Param Authorization header string true "Bearer JWT token"
+// @Router /posts/{id}/swaps [post]
+func (c *SwapController) ProposeSwap(ctx *gin.Context) {
+	token, exc := utils.ExtractTokenFromHeaders(ctx)
+	if exc != nil {
+		ctx.JSON(int(exc.StatusCode), exc)
+		return
+	}
+
+	payload, exc := utils.ExtractPayloadFromJWT(*token)
+	if exc != nil {
+		ctx.JSON(int(exc.StatusCode), exc)
+		return
+	}
+
+	email := payload["email"].(string)
+
+	id := ctx.Param("id")
+	idParsed, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		ctx.JSON(400, gin.H{"error": "Invalid post ID"})
+		return
+	}
+
+	var data dto.CreateSwapOfferDto
+	if err := ctx.ShouldBindBodyWithJSON(&data); err != nil {
+		ctx.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	offer, exc := c.ss.ProposeSwap(ctx, email, idParsed, data.OfferedPostIDs)
+	if exc != nil {
+		ctx.JSON(int(exc.StatusCode), exc)
+		return
+	}
+
+	ctx.JSON(200, offer)
+}
+
+// @Summary Предложение обмена
+// @Description Возвращает предложение обмена его автору или получателю.
+// @Tags swaps
+// @Produce json
+// @Param id path int true "Swap offer ID"
+// @Success 200 {object} dto.SwapOfferDto
+// @Failure 400 {object} exceptions.Error_
+// @Failure 401 {object} exceptions.Error_
+// @Failure 403 {object} exceptions.Error_
+// @Failure 404 {object} exceptions.Error_
+// @Failure 503 {object} exceptions.Error_
+// @Security BearerAuth
+// @Param Authorization header string true "Bearer JWT token"
+// @Router /swaps/{id} [get]
+func (c *SwapController) GetSwapOffer(ctx *gin.Context) {
+	token, exc := utils.ExtractTokenFromHeaders(ctx)
+	if exc != nil {
+		ctx.JSON(int(exc.StatusCode), exc)
+		return
+	}
+
+	payload, exc := utils.ExtractPayloadFromJWT(*token)
+	if exc != nil {
+		ctx.JSON(int(exc.StatusCode), exc)
+		return
+	}
+
+	email := payload["email"].(string)
+
+	id := ctx.Param("id")
+	idParsed, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		ctx.JSON(400, gin.H{"error": "Invalid swap offer ID"})
+		return
+	}
+
+	offer, exc := c.ss.GetSwapOffer(ctx, email, idParsed)
+	if exc != nil {
+		ctx.JSON(int(exc.StatusCode), exc)
+		return
+	}
+
+	ctx.JSON(200, offer)
+}
+
+// @Summary Отправленные предложения обмена
+// @Description Возвращает предложения обмена, сделанные текущим пользователем.
+// @Tags swaps
+// @Produce json
+// @Param limit query int false "Limit"
+// @Param cursor query string false "Cursor of the next page"
+// @Success 200 {object} dto.Page[dto.SwapOfferDto]
+// @Failure 400 {object} exceptions.Error_
+// @Failure 401 {object} exceptions.Error_
+// @Failure 404 {object} exceptions.Error_
+// @Failure 503 {object} exceptions.Error_
+// @Security BearerAuth
+// @Param Authorization header string true "Bearer JWT token"
+// @Router /swaps/sent [get]
+func (c *SwapController) GetSentOffers(ctx *gin.Context) {
+	token, exc := utils.ExtractTokenFromHeaders(ctx)
+	if exc != nil {
+		ctx.JSON(int(exc.StatusCode), exc)
+		return
+	}
+
+	payload, exc := utils.ExtractPayloadFromJWT(*token)
+	if exc != nil {
+		ctx.JSON(int(exc.StatusCode), exc)
+		return
+	}
+
+	email := payload["email"].(string)
+
+	page, exc := utils.ParsePageRequest(ctx, dto.CursorKeyTime)
+	if exc != nil {
+		ctx.JSON(int(exc.StatusCode), exc)
+		return
+	}
+
+	offers, exc := c.ss.GetSentOffers(ctx, email, *page)
+	if exc != nil {
+		ctx.JSON(int(exc.StatusCode), exc)
+		return
+	}
+
+	ctx.JSON(200, offers)
+}
+
+// @Summary Полученные предложения обмена
+// @Description Возвращает предложения обмена, полученные текущим пользователем.
+// @Tags swaps
+// @Produce json
+// @Param limit query int false "Limit"
+// @Param cursor query string false "Cursor of the next page"
+// @Success 200 {object} dto.Page[dto.SwapOfferDto]
+// @Failure 400 {object} exceptions.Error_
+// @Failure 401 {object} exceptions.Error_
+// @Failure 404 {object} exceptions.Error_
+// @Failure 503 {object} exceptions.Error_
+// @Security BearerAuth
+// @Param Authorization header string true "Bearer JWT token"
+// @Router /swaps/received [get]
+func (c *SwapController) GetReceivedOffers(ctx *gin.Context) {
+	token, exc := utils.ExtractTokenFromHeaders(ctx)
+	if exc != nil {
+		ctx.JSON(int(exc.StatusCode), exc)
+		return
+	}
+
+	payload, exc := utils.ExtractPayloadFromJWT(*token)
+	if exc != nil {
+		ctx.JSON(int(exc.StatusCode), exc)
+		return
+	}
+
+	email := payload["email"].(string)
+
+	page, exc := utils.ParsePageRequest(ctx, dto.CursorKeyTime)
+	if exc != nil {
+		ctx.JSON(int(exc.StatusCode), exc)
+		return
+	}
+
+	offers, exc := c.ss.GetReceivedOffers(ctx, email, *page)
+	if exc != nil {
+		ctx.JSON(int(exc.StatusCode), exc)
+		return
+	}
+
+	ctx.JSON(200, offers)
+}
+
+// @Summary Принять обмен
+// @Description Получатель принимает предложение. Все книги обмена сразу бронируются за другой стороной, передача каждой подтверждается кодом. Другие предложения с этими книгами отменяются.
+// @Tags swaps
+// @Produce json
+// @Param id path int true "Swap offer ID"
+// @Success 200 {object} dto.SwapOfferDto
+// @Failure 400 {object} exceptions.Error_
+// @Failure 401 {object} exceptions.Error_
+// @Failure 403 {object} exceptions.Error_
+// @Failure 404 {object} exceptions.Error_
+// @Failure 409 {object} exceptions.Error_
+// @Failure 503 {object} exceptions.Error_
+// @Security BearerAuth
+// @Param Authorization header string true "Bearer JWT token"
+// @Router /swaps/{id}/accept [put]
+func (c *SwapController) AcceptSwap(ctx *gin.Context) {
+	token, exc := utils.ExtractTokenFromHeaders(ctx)
+	if exc != nil {
+		ctx.JSON(int(exc.StatusCode), exc)
+		return
+	}
+
+	payload, exc := utils.ExtractPayloadFromJWT(*token)
+	if exc != nil {
+		ctx.JSON(int(exc.StatusCode), exc)
+		return
+	}
+
+	email := payload["email"].(string)
+
+	id := ctx.Param("id")
+	idParsed, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		ctx.JSON(400, gin.H{"error": "Invalid swap offer ID"})
+		return
+	}
+
+	offer, exc := c.ss.AcceptSwap(ctx, email, idParsed)
+	if exc != nil {
+		ctx.JSON(int(exc.StatusCode), exc)
+		return
+	}
+
+	ctx.JSON(200, offer)
+}
+
+// @Summary Отклонить обмен
+// @Description Получатель отклоняет предложение обмена.
+// @Tags swaps
+// @Produce json
+// @Param id path int true "Swap offer ID"
+// @Success 200 {object} dto.SwapOfferDto
+// @Failure 400 {object} exceptions.Error_
+// @Failure 401 {object} exceptions.Error_
+// @Failure 403 {object} exceptions.Error_
+// @Failure 404 {object} exceptions.Error_
+// @Failure 409 {object} exceptions.Error_
+// @Failure 503 {object} exceptions.Error_
+// @Security BearerAuth
+// @Param Authorization header string true "Bearer JWT token"
+// @Router /swaps/{id}/reject [put]
+func (c *SwapController) RejectSwap(ctx *gin.Context) {
+	token, exc := utils.ExtractTokenFromHeaders(ctx)
+	if exc != nil {
+		ctx.JSON(int(exc.StatusCode), exc)
+		return
+	}
+
+	payload, exc := utils.ExtractPayloadFromJWT(*token)
+	if exc != nil {
+		ctx.JSON(int(exc.StatusCode), exc)
+		return
+	}
+
+	email := payload["email"].(string)
+
+	id := ctx.Param("id")
+	idParsed, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		ctx.JSON(400, gin.H{"error": "Invalid swap offer ID"})
+		return
+	}
+
+	offer, exc := c.ss.RejectSwap(ctx, email, idParsed)
+	if exc != nil {
+		ctx.JSON(int(exc.StatusCode), exc)
+		return
+	}
+
+	ctx.JSON(200, offer)
+}
+
+// @Summary Отозвать предложение обмена
+// @Description Автор отзывает своё ожидающее предложение обмена.
+// @Tags swaps
+// @Produce json
+// @Param id path int true "Swap offer ID"
+// @Success 200 {object} dto.SwapOfferDto
+// @Failure 400 {object} exceptions.Error_
+// @Failure 401 {object} exceptions.Error_
+// @Failure 403 {object} exceptions.Error_
+// @Failure 404 {object} exceptions.Error_
+// @Failure 409 {object} exceptions.Error_
+// @Failure 503 {object} exceptions.Error_
+// @Security BearerAuth
+// @Param Authorization header string true "Bearer JWT token"
+// @Router /swaps/{id} [delete]
+func (c *SwapController) WithdrawSwap(ctx *gin.Context) {
+	token, exc := utils.ExtractTokenFromHeaders(ctx)
+	if exc != nil {
+		ctx.JSON(int(exc.StatusCode), exc)
+		return
+	}
+
+	payload, exc := utils.ExtractPayloadFromJWT(*token)
+	if exc != nil {
+		ctx.JSON(int(exc.StatusCode), exc)
+		return
+	}
+
+	email := payload["email"].(string)
+
+	id := ctx.Param("id")
+	idParsed, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		ctx.JSON(400, gin.H{"error": "Invalid swap offer ID"})
+		return
+	}
+
+	offer, exc := c.ss.WithdrawSwap(ctx, email, idParsed)
+	if exc != nil {
+		ctx.JSON(int(exc.StatusCode), exc)
+		return
+	}
+
+	ctx.JSON(200, offer)
+}
+
+// @Summary Встречное предложение
+// @Description Получатель отвечает на предложение другими книгами: своими (offered_post_ids) и книгами автора предложения (requested_post_ids). Исходное предложение становится встречным, новое уходит автору.
+// @Tags swaps
+// @Accept json
+// @Produce json
+// @Param id path int true "Swap offer ID"
+// @Param data body dto.CounterSwapOfferDto true "Counter offer"
+// @Success 200 {object} dto.SwapOfferDto
+// @Failure 400 {object} exceptions.Error_
+// @Failure 401 {object} exceptions.Error_
+// @Failure 403 {object} exceptions.Error_
+// @Failure 404 {object} exceptions.Error_
+// @Failure 409 {object} exceptions.Error_
+// @Failure 503 {object} exceptions.Error_
+// @Security BearerAuth
+// @Param Authorization header string true "Bearer JWT token"
+// @Router /swaps/{id}/counter [post]
+func (c *SwapController) CounterSwap(ctx *gin.Context) {
+	token, exc := utils.ExtractTokenFromHeaders(ctx)
+	if exc != nil {
+		ctx.JSON(int(exc.StatusCode), exc)
+		return
+	}
+
+	payload, exc := utils.ExtractPayloadFromJWT(*token)
+	if exc != nil {
+		ctx.JSON(int(exc.StatusCode), exc)
+		return
+	}
+
+	email := payload["email"].(string)
+
+	id := ctx.Param("id")
+	idParsed, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		ctx.JSON(400, gin.H{"error": "Invalid swap offer ID"})
+		return
+	}
+
+	var data dto.CounterSwapOfferDto
+	if err := ctx.ShouldBindBodyWithJSON(&data); err != nil {
+		ctx.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	offer, exc := c.ss.CounterSwap(ctx, email, idParsed, &data)
+	if exc != nil {
+		ctx.JSON(int(exc.StatusCode), exc)
+		return
+	}
+
+	ctx.JSON(200, offer)
+}
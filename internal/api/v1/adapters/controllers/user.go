@@ -2,6 +2,7 @@ package controllers
 
 import (
 	"example.com/m/internal/api/v1/core/application/dto"
+	"example.com/m/internal/api/v1/core/application/exceptions"
 	"example.com/m/internal/api/v1/core/application/services/user_service"
 	"example.com/m/internal/api/v1/utils"
 	"github.com/gin-gonic/gin"
@@ -87,7 +88,7 @@ func (c *UserController) CreateUser(ctx *gin.Context) {
 
 // @Summary Получить профиль пользователя (по нику)
 // @Schemes
-// @Description Returns user profile by username (requires JWT in "Bearer" header)
+// @Description Returns user profile by username (requires JWT in "Bearer" header). Email and telegram username are returned only if the user's visibility settings allow it, email is never shown publicly.
 // @Tags user
 // @Produce json
 // @Param username path string true "Username"
@@ -101,7 +102,21 @@ func (c *UserController) CreateUser(ctx *gin.Context) {
 func (c *UserController) GetUserByUsername(ctx *gin.Context) {
 	username := ctx.Param("username")
 
-	user, err := c.us.GetUserByUsernameWithRating(ctx, username)
+	token, err := utils.ExtractTokenFromHeaders(ctx)
+	if err != nil {
+		ctx.JSON(int(err.StatusCode), err)
+		return
+	}
+
+	payload, err := utils.ExtractPayloadFromJWT(*token)
+	if err != nil {
+		ctx.JSON(int(err.StatusCode), err)
+		return
+	}
+
+	email := payload["email"].(string)
+
+	user, err := c.us.GetUserProfileForViewer(ctx, email, username)
 	if err != nil {
 		ctx.JSON(int(err.StatusCode), err)
 		return
@@ -152,12 +167,14 @@ func (c *UserController) GetUserProfile(ctx *gin.Context) {
 
 // @Summary Обновить профиль.
 // @Schemes
-// @Description Updates user profile and returns it (requires JWT in "Bearer" header)
+// @Description Updates user profile and returns it (requires JWT in "Bearer" header). preferred_place_ids replaces the preferred pickup places.
 // @Tags user
 // @Produce json
 // @Param user body dto.UpdateUserDto true "User data"
 // @Param Authorization header string true "Bearer JWT token"
-// @Success 200 {object} dto.GetUserDto
+// @Success 200 {object} dto.UserWithRatingDto
+// @Failure 400 {object} exceptions.Error_
+// @Failure 404 {object} exceptions.Error_
 // @Failure 500 {object} exceptions.Error_
 // @Failure 503 {object} exceptions.Error_
 // @Failure 401 {object} exceptions.Error_
@@ -190,7 +207,167 @@ func (c *UserController) UpdateUserProfile(ctx *gin.Context) {
 		return
 	}
 
-	userToReturn := utils.ExcludeUserCredentials(updatedUser)
+	// the profile includes the preferred places which aren't in the user row
+	user, err := c.us.GetUserByUsernameWithRating(ctx, updatedUser.Username)
+	if err != nil {
+		ctx.JSON(int(err.StatusCode), err)
+		return
+	}
+
+	ctx.JSON(200, &user)
+}
+
+// @Summary Загрузить аватар
+// @Description Обрезает изображение до квадрата, уменьшает его и делает аватаром пользователя. Предыдущий аватар удаляется из хранилища.
+// @Tags user
+// @Accept multipart/form-data
+// @Produce json
+// @Param avatar formData file true "Изображение JPEG или PNG, не больше 10 МБ"
+// @Param Authorization header string true "Bearer JWT token"
+// @Success 200 {object} dto.GetUserDto
+// @Failure 400 {object} exceptions.Error_
+// @Failure 401 {object} exceptions.Error_
+// @Failure 413 {object} exceptions.Error_
+// @Failure 415 {object} exceptions.Error_
+// @Failure 503 {object} exceptions.Error_
+// @Security BearerAuth
+// @Router /users/me/avatar [post]
+func (c *UserController) UploadAvatar(ctx *gin.Context) {
+	token, err := utils.ExtractTokenFromHeaders(ctx)
+	if err != nil {
+		ctx.JSON(int(err.StatusCode), err)
+		return
+	}
+
+	payload, err := utils.ExtractPayloadFromJWT(*token)
+	if err != nil {
+		ctx.JSON(int(err.StatusCode), err)
+		return
+	}
+
+	email := payload["email"].(string)
+
+	file, header, formErr := ctx.Request.FormFile("avatar")
+	if formErr != nil {
+		ctx.JSON(int(exceptions.ErrNotAllFields.StatusCode), exceptions.ErrNotAllFields)
+		return
+	}
+	defer file.Close()
+
+	user, err := c.us.SetAvatar(ctx, email, header, file)
+	if err != nil {
+		ctx.JSON(int(err.StatusCode), err)
+		return
+	}
+
+	userToReturn := utils.ExcludeUserCredentials(user)
 
 	ctx.JSON(200, &userToReturn)
 }
+
+// @Summary Удалить аватар
+// @Tags user
+// @Produce json
+// @Param Authorization header string true "Bearer JWT token"
+// @Success 200
+// @Failure 401 {object} exceptions.Error_
+// @Failure 503 {object} exceptions.Error_
+// @Security BearerAuth
+// @Router /users/me/avatar [delete]
+func (c *UserController) DeleteAvatar(ctx *gin.Context) {
+	token, err := utils.ExtractTokenFromHeaders(ctx)
+	if err != nil {
+		ctx.JSON(int(err.StatusCode), err)
+		return
+	}
+
+	payload, err := utils.ExtractPayloadFromJWT(*token)
+	if err != nil {
+		ctx.JSON(int(err.StatusCode), err)
+		return
+	}
+
+	email := payload["email"].(string)
+	if err := c.us.DeleteAvatar(ctx, email); err != nil {
+		ctx.JSON(int(err.StatusCode), err)
+		return
+	}
+
+	ctx.JSON(200, gin.H{"success": true})
+}
+
+// @Summary Подтверждение почты кодом
+// @Description Подтверждает почту пользователя одноразовым кодом из письма
+// @Tags user
+// @Accept json
+// @Produce json
+// @Param data body dto.VerifyEmailDto true "Email and code"
+// @Success 200
+// @Failure 400 {object} exceptions.Error_
+// @Failure 404 {object} exceptions.Error_
+// @Failure 429 {object} exceptions.Error_
+// @Failure 503 {object} exceptions.Error_
+// @Router /users/verify [post]
+func (c *UserController) VerifyEmail(ctx *gin.Context) {
+	var data dto.VerifyEmailDto
+	if err := ctx.ShouldBindBodyWithJSON(&data); err != nil {
+		ctx.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := c.us.VerifyEmailByCode(ctx, data.Email, data.Code); err != nil {
+		ctx.JSON(int(err.StatusCode), err)
+		return
+	}
+
+	ctx.JSON(200, gin.H{"success": true})
+}
+
+// @Summary Подтверждение почты по ссылке
+// @Description Подтверждает почту пользователя по подписанной ссылке из письма
+// @Tags user
+// @Produce json
+// @Param email query string true "Email"
+// @Param expires query string true "Link expiration time"
+// @Param signature query string true "Link signature"
+// @Success 200
+// @Failure 400 {object} exceptions.Error_
+// @Failure 404 {object} exceptions.Error_
+// @Failure 503 {object} exceptions.Error_
+// @Router /users/verify [get]
+func (c *UserController) VerifyEmailByLink(ctx *gin.Context) {
+	err := c.us.VerifyEmailByLink(ctx, ctx.Query("email"), ctx.Query("expires"), ctx.Query("signature"))
+	if err != nil {
+		ctx.JSON(int(err.StatusCode), err)
+		return
+	}
+
+	ctx.JSON(200, gin.H{"success": true})
+}
+
+// @Summary Повторная отправка письма
+// @Description Отправляет новый код подтверждения почты. Не чаще раза в минуту.
+// @Tags user
+// @Accept json
+// @Produce json
+// @Param data body dto.ResendVerificationDto true "Email"
+// @Success 200
+// @Failure 400 {object} exceptions.Error_
+// @Failure 404 {object} exceptions.Error_
+// @Failure 429 {object} exceptions.Error_
+// @Failure 503 {object} exceptions.Error_
+// @Router /users/verify/resend [post]
+func (c *UserController) ResendVerification(ctx *gin.Context) {
+	var data dto.ResendVerificationDto
+	if err := ctx.ShouldBindBodyWithJSON(&data); err != nil {
+		ctx.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := c.us.SendVerification(ctx, data.Email); err != nil {
+		ctx.JSON(int(err.StatusCode), err)
+		return
+	}
+
+	ctx.JSON(200, gin.H{"success": true})
+}
@@ -0,0 +1,55 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"path"
+
+	object_storage "example.com/m/internal/api/v1/infrastructure/s3"
+	"example.com/m/internal/config"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// avatars are kept in the bucket of post images, so that their URLs are
+// served the same way
+const avatarBucket = "posts"
+
+type AvatarRepository struct {
+	logger *zap.Logger
+	s3     *object_storage.ClientS3
+}
+
+func NewAvatarRepository(logger *zap.Logger, s3 *object_storage.ClientS3) *AvatarRepository {
+	return &AvatarRepository{
+		logger: logger,
+		s3:     s3,
+	}
+}
+
+// Upload stores the JPEG image and returns its URL.
+func (r *AvatarRepository) Upload(ctx context.Context, data []byte) (string, error) {
+	fileName := fmt.Sprintf("avatar-%s.jpg", uuid.NewString())
+	if err := r.s3.UploadBytes(ctx, avatarBucket, fileName, data, "image/jpeg"); err != nil {
+		r.logger.Error(
+			"Avatar Repository Error",
+			zap.String("method", "Upload"),
+			zap.String("error", err.Error()),
+		)
+		return "", err
+	}
+	return fmt.Sprintf("%s/%s", config.Config.S3Endpoint, fileName), nil
+}
+
+// Delete removes the avatar uploaded by Upload from the storage.
+func (r *AvatarRepository) Delete(ctx context.Context, avatarURL string) error {
+	if err := r.s3.DeleteFile(ctx, avatarBucket, path.Base(avatarURL)); err != nil {
+		r.logger.Error(
+			"Avatar Repository Error",
+			zap.String("method", "Delete"),
+			zap.String("error", err.Error()),
+		)
+		return err
+	}
+	return nil
+}
@@ -10,8 +10,17 @@ import (
 	"go.uber.org/zap"
 )
 
+var bookingColumns = []interface{}{
+	"id", "user_email", "post_id", "created_at", "status", "expires_at", "updated_at", "swap_id", "slot_id",
+	goqu.From("users").Select("username").
+		Where(goqu.I("users.email").Eq(goqu.I("bookings.user_email"))).
+		As("booker_username"),
+}
+
+var activeBookingStatuses = []string{dto.BookingStatusPending, dto.BookingStatusConfirmed}
+
 type BookingRepository struct {
-	db     *sql.DB
+	db     DBTX
 	logger *zap.Logger
 }
 
@@ -22,26 +31,37 @@ func NewBookingRepository(db *sql.DB, logger *zap.Logger) *BookingRepository {
 	}
 }
 
-func (r *BookingRepository) Create(ctx context.Context, b *dto.BookingToCreateDto) error {
-	query, _, _ := goqu.Insert("bookings").Rows(b).ToSQL()
-	_, err := r.db.Exec(query)
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanBooking(row rowScanner, b *dto.BookingDto) error {
+	return row.Scan(&b.ID, &b.UserEmail, &b.PostID, &b.CreatedAt, &b.Status, &b.ExpiresAt, &b.UpdatedAt, &b.SwapID, &b.SlotID, &b.BookerUsername)
+}
+
+func (r *BookingRepository) Create(ctx context.Context, b *dto.BookingToCreateDto) (*int64, error) {
+	var id int64
+	query, _, _ := goqu.Insert("bookings").Rows(b).Returning("id").ToSQL()
+	err := r.db.QueryRow(query).Scan(&id)
 	if err != nil {
 		r.logger.Error(
 			"Booking Repository Error",
 			zap.String("method", "Create"),
 			zap.String("error", err.Error()),
 		)
-		return err
+		return nil, err
 	}
-	return nil
+	return &id, nil
 }
 
+// GetByPostID returns the pending or confirmed booking of the post, if any.
 func (r *BookingRepository) GetByPostID(ctx context.Context, postID int64) (*dto.BookingDto, error) {
 	var booking dto.BookingDto
-	query, _, _ := goqu.From("bookings").Where(goqu.Ex{
+	query, _, _ := goqu.From("bookings").Select(bookingColumns...).Where(goqu.Ex{
 		"post_id": postID,
+		"status":  activeBookingStatuses,
 	}).ToSQL()
-	err := r.db.QueryRow(query).Scan(&booking.ID, &booking.UserEmail, &booking.PostID, &booking.CreatedAt)
+	err := scanBooking(r.db.QueryRow(query), &booking)
 	if errors.Is(err, sql.ErrNoRows) {
 		return nil, nil
 	}
@@ -58,10 +78,10 @@ func (r *BookingRepository) GetByPostID(ctx context.Context, postID int64) (*dto
 
 func (r *BookingRepository) Get(ctx context.Context, id int64) (*dto.BookingDto, error) {
 	var booking dto.BookingDto
-	query, _, _ := goqu.From("bookings").Where(goqu.Ex{
+	query, _, _ := goqu.From("bookings").Select(bookingColumns...).Where(goqu.Ex{
 		"id": id,
 	}).ToSQL()
-	err := r.db.QueryRow(query).Scan(&booking.ID, &booking.UserEmail, &booking.PostID, &booking.CreatedAt)
+	err := scanBooking(r.db.QueryRow(query), &booking)
 	if errors.Is(err, sql.ErrNoRows) {
 		return nil, nil
 	}
@@ -76,6 +96,173 @@ func (r *BookingRepository) Get(ctx context.Context, id int64) (*dto.BookingDto,
 	return &booking, nil
 }
 
+func (r *BookingRepository) Update(ctx context.Context, id int64, b *dto.UpdateBookingDto) error {
+	query, _, _ := goqu.Update("bookings").Set(*b).Where(goqu.Ex{
+		"id": id,
+	}).ToSQL()
+	_, err := r.db.Exec(query)
+	if err != nil {
+		r.logger.Error(
+			"Booking Repository Error",
+			zap.String("method", "Update"),
+			zap.String("error", err.Error()),
+		)
+		return err
+	}
+	return nil
+}
+
+// GetExpired returns active bookings whose deadline is earlier than now.
+func (r *BookingRepository) GetExpired(ctx context.Context, now string) (*[]dto.BookingDto, error) {
+	bookings := []dto.BookingDto{}
+	query, _, _ := goqu.From("bookings").Select(bookingColumns...).Where(
+		goqu.Ex{"status": activeBookingStatuses},
+		goqu.C("expires_at").Lt(now),
+	).Order(goqu.C("expires_at").Asc()).ToSQL()
+
+	rows, err := r.db.Query(query)
+	if err != nil {
+		r.logger.Error(
+			"Booking Repository Error",
+			zap.String("method", "GetExpired"),
+			zap.String("error", err.Error()),
+		)
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var booking dto.BookingDto
+		if err := scanBooking(rows, &booking); err != nil {
+			r.logger.Error(
+				"Booking Repository Error",
+				zap.String("method", "GetExpired"),
+				zap.String("error", err.Error()),
+			)
+			return nil, err
+		}
+		bookings = append(bookings, booking)
+	}
+
+	if err := rows.Err(); err != nil {
+		r.logger.Error(
+			"Booking Repository Error",
+			zap.String("method", "GetExpired"),
+			zap.String("error", err.Error()),
+		)
+		return nil, err
+	}
+
+	return &bookings, nil
+}
+
+// CountActiveByUser returns the number of pending or confirmed bookings of the
+// user.
+func (r *BookingRepository) CountActiveByUser(ctx context.Context, userEmail string) (int, error) {
+	var count int
+	query, _, _ := goqu.From("bookings").Select(goqu.COUNT("*")).Where(goqu.Ex{
+		"user_email": userEmail,
+		"status":     activeBookingStatuses,
+	}).ToSQL()
+	if err := r.db.QueryRowContext(ctx, query).Scan(&count); err != nil {
+		r.logger.Error(
+			"Booking Repository Error",
+			zap.String("method", "CountActiveByUser"),
+			zap.String("error", err.Error()),
+		)
+		return 0, err
+	}
+	return count, nil
+}
+
+// GetActiveBySwap returns the pending or confirmed bookings made by the swap.
+func (r *BookingRepository) GetActiveBySwap(ctx context.Context, swapID int64) ([]dto.BookingDto, error) {
+	bookings := []dto.BookingDto{}
+	query, _, _ := goqu.From("bookings").Select(bookingColumns...).Where(goqu.Ex{
+		"swap_id": swapID,
+		"status":  activeBookingStatuses,
+	}).Order(goqu.C("post_id").Asc()).ToSQL()
+
+	rows, err := r.db.Query(query)
+	if err != nil {
+		r.logger.Error(
+			"Booking Repository Error",
+			zap.String("method", "GetActiveBySwap"),
+			zap.String("error", err.Error()),
+		)
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var booking dto.BookingDto
+		if err := scanBooking(rows, &booking); err != nil {
+			r.logger.Error(
+				"Booking Repository Error",
+				zap.String("method", "GetActiveBySwap"),
+				zap.String("error", err.Error()),
+			)
+			return nil, err
+		}
+		bookings = append(bookings, booking)
+	}
+
+	if err := rows.Err(); err != nil {
+		r.logger.Error(
+			"Booking Repository Error",
+			zap.String("method", "GetActiveBySwap"),
+			zap.String("error", err.Error()),
+		)
+		return nil, err
+	}
+
+	return bookings, nil
+}
+
+// GetActiveByUser returns the pending or confirmed bookings made by the user.
+func (r *BookingRepository) GetActiveByUser(ctx context.Context, userEmail string) ([]dto.BookingDto, error) {
+	bookings := []dto.BookingDto{}
+	query, _, _ := goqu.From("bookings").Select(bookingColumns...).Where(goqu.Ex{
+		"user_email": userEmail,
+		"status":     activeBookingStatuses,
+	}).Order(goqu.C("post_id").Asc()).ToSQL()
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		r.logger.Error(
+			"Booking Repository Error",
+			zap.String("method", "GetActiveByUser"),
+			zap.String("error", err.Error()),
+		)
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var booking dto.BookingDto
+		if err := scanBooking(rows, &booking); err != nil {
+			r.logger.Error(
+				"Booking Repository Error",
+				zap.String("method", "GetActiveByUser"),
+				zap.String("error", err.Error()),
+			)
+			return nil, err
+		}
+		bookings = append(bookings, booking)
+	}
+
+	if err := rows.Err(); err != nil {
+		r.logger.Error(
+			"Booking Repository Error",
+			zap.String("method", "GetActiveByUser"),
+			zap.String("error", err.Error()),
+		)
+		return nil, err
+	}
+
+	return bookings, nil
+}
+
 func (r *BookingRepository) Delete(ctx context.Context, id int64) error {
 	query, _, _ := goqu.Delete("bookings").Where(goqu.Ex{
 		"id": id,
@@ -95,16 +282,195 @@ func (r *BookingRepository) Delete(ctx context.Context, id int64) error {
 	return nil
 }
 
-func (r *BookingRepository) RemoveAll(ctx context.Context, postID int64) error {
-	query, _, _ := goqu.Delete("bookings").ToSQL()
-	_, err := r.db.Exec(query)
+// GetIncoming returns bookings of the owner's posts with the given status.
+func (r *BookingRepository) GetIncoming(ctx context.Context, ownerEmail string, status string, page dto.PageRequest) (*dto.Page[dto.IncomingBookingDto], error) {
+	var bookings []dto.IncomingBookingDto
+	query, _, err := paginate(goqu.
+		Select(
+			"bookings.id", "bookings.user_email", "bookings.post_id", "bookings.created_at",
+			"bookings.status", "bookings.expires_at", "bookings.updated_at", "bookings.swap_id", "bookings.slot_id",
+			goqu.I("posts.title").As("post_title"),
+			goqu.I("users.username").As("booker_username"),
+		).
+		From("bookings").
+		Join(
+			goqu.T("posts"),
+			goqu.On(goqu.I("bookings.post_id").Eq(goqu.I("posts.id"))),
+		).
+		Join(
+			goqu.T("users"),
+			goqu.On(goqu.I("bookings.user_email").Eq(goqu.I("users.email"))),
+		).
+		Where(goqu.Ex{
+			"posts.user_email": ownerEmail,
+			"bookings.status":  status,
+		}), goqu.I("bookings.created_at"), goqu.I("bookings.id"), page, true).
+		ToSQL()
+	if err != nil {
+		r.logger.Error(
+			"Booking Repository Error",
+			zap.String("method", "GetIncoming"),
+			zap.String("error", err.Error()),
+		)
+		return nil, err
+	}
+
+	rows, err := r.db.Query(query)
+	if err != nil {
+		r.logger.Error(
+			"Booking Repository Error",
+			zap.String("method", "GetIncoming"),
+			zap.String("error", err.Error()),
+		)
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var booking dto.IncomingBookingDto
+		if err := rows.Scan(
+			&booking.ID, &booking.UserEmail, &booking.PostID, &booking.CreatedAt,
+			&booking.Status, &booking.ExpiresAt, &booking.UpdatedAt, &booking.SwapID, &booking.SlotID,
+			&booking.PostTitle, &booking.BookerUsername); err != nil {
+			r.logger.Error(
+				"Booking Repository Error",
+				zap.String("method", "GetIncoming"),
+				zap.String("error", err.Error()),
+			)
+			return nil, err
+		}
+		bookings = append(bookings, booking)
+	}
+
+	if err := rows.Err(); err != nil {
+		r.logger.Error(
+			"Booking Repository Error",
+			zap.String("method", "GetIncoming"),
+			zap.String("error", err.Error()),
+		)
+		return nil, err
+	}
+
+	return dto.NewPage(bookings, page.Limit, func(b *dto.IncomingBookingDto) dto.Cursor {
+		return dto.Cursor{Key: b.CreatedAt, ID: b.ID}
+	}), nil
+}
+
+// SetSlot reserves the pickup slot for the booking. A reminder is sent again
+// for the new slot.
+func (r *BookingRepository) SetSlot(ctx context.Context, id int64, slotID int64, updatedAt string) error {
+	query, _, _ := goqu.Update("bookings").
+		Set(goqu.Record{"slot_id": slotID, "slot_reminder_sent_at": nil, "updated_at": updatedAt}).
+		Where(goqu.C("id").Eq(id)).
+		ToSQL()
+	if _, err := r.db.ExecContext(ctx, query); err != nil {
+		r.logger.Error(
+			"Booking Repository Error",
+			zap.String("method", "SetSlot"),
+			zap.String("error", err.Error()),
+		)
+		return err
+	}
+	return nil
+}
+
+func pickupSelect() *goqu.SelectDataset {
+	return goqu.
+		Select(
+			"bookings.id", "pickup_slots.id", "bookings.user_email", "posts.user_email", "posts.title",
+			"places.name", "places.address", "places.city", "pickup_slots.starts_at", "pickup_slots.ends_at",
+		).
+		From("bookings").
+		Join(goqu.T("pickup_slots"), goqu.On(goqu.I("bookings.slot_id").Eq(goqu.I("pickup_slots.id")))).
+		Join(goqu.T("posts"), goqu.On(goqu.I("bookings.post_id").Eq(goqu.I("posts.id")))).
+		Join(goqu.T("places"), goqu.On(goqu.I("pickup_slots.place_id").Eq(goqu.I("places.id"))))
+}
+
+func scanPickup(row rowScanner, p *dto.PickupDto) error {
+	return row.Scan(
+		&p.BookingID, &p.SlotID, &p.BookerEmail, &p.OwnerEmail, &p.PostTitle,
+		&p.PlaceName, &p.PlaceAddress, &p.PlaceCity, &p.StartsAt, &p.EndsAt,
+	)
+}
+
+// GetPickup returns the appointment of the booking, or nil when no slot is
+// reserved.
+func (r *BookingRepository) GetPickup(ctx context.Context, bookingID int64) (*dto.PickupDto, error) {
+	var pickup dto.PickupDto
+	query, _, _ := pickupSelect().Where(goqu.Ex{"bookings.id": bookingID}).ToSQL()
+	err := scanPickup(r.db.QueryRowContext(ctx, query), &pickup)
 	if errors.Is(err, sql.ErrNoRows) {
-		return nil
+		return nil, nil
+	}
+	if err != nil {
+		r.logger.Error(
+			"Booking Repository Error",
+			zap.String("method", "GetPickup"),
+			zap.String("error", err.Error()),
+		)
+		return nil, err
 	}
+	return &pickup, nil
+}
+
+// GetPickupsToRemind returns up to limit appointments of confirmed bookings
+// starting after now and not later than before, whose reminder wasn't sent.
+func (r *BookingRepository) GetPickupsToRemind(ctx context.Context, now string, before string, limit uint) ([]dto.PickupDto, error) {
+	pickups := []dto.PickupDto{}
+	query, _, _ := pickupSelect().
+		Where(
+			goqu.Ex{"bookings.status": dto.BookingStatusConfirmed, "bookings.slot_reminder_sent_at": nil},
+			goqu.I("pickup_slots.starts_at").Gt(now),
+			goqu.I("pickup_slots.starts_at").Lte(before),
+		).
+		Order(goqu.I("pickup_slots.starts_at").Asc()).
+		Limit(limit).
+		ToSQL()
+
+	rows, err := r.db.QueryContext(ctx, query)
 	if err != nil {
 		r.logger.Error(
 			"Booking Repository Error",
-			zap.String("method", "RemoveAll"),
+			zap.String("method", "GetPickupsToRemind"),
+			zap.String("error", err.Error()),
+		)
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var pickup dto.PickupDto
+		if err := scanPickup(rows, &pickup); err != nil {
+			r.logger.Error(
+				"Booking Repository Error",
+				zap.String("method", "GetPickupsToRemind"),
+				zap.String("error", err.Error()),
+			)
+			return nil, err
+		}
+		pickups = append(pickups, pickup)
+	}
+
+	if err := rows.Err(); err != nil {
+		r.logger.Error(
+			"Booking Repository Error",
+			zap.String("method", "GetPickupsToRemind"),
+			zap.String("error", err.Error()),
+		)
+		return nil, err
+	}
+	return pickups, nil
+}
+
+func (r *BookingRepository) SetSlotReminderSent(ctx context.Context, id int64, at string) error {
+	query, _, _ := goqu.Update("bookings").
+		Set(goqu.Record{"slot_reminder_sent_at": at}).
+		Where(goqu.C("id").Eq(id)).
+		ToSQL()
+	if _, err := r.db.ExecContext(ctx, query); err != nil {
+		r.logger.Error(
+			"Booking Repository Error",
+			zap.String("method", "SetSlotReminderSent"),
 			zap.String("error", err.Error()),
 		)
 		return err
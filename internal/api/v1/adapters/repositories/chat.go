@@ -26,12 +26,14 @@ func dbMessageToChatMessage(messages ...dto.DbMessageDto) []dto.ChatMessage {
 	for _, el := range messages {
 		if el.Role == "user" {
 			msgs = append(msgs, dto.ChatMessage{
+				ID:        el.ID,
 				Message:   el.Text,
 				Writer:    dto.USER,
 				CreatedAt: el.CreatedAt,
 			})
 		} else {
 			msgs = append(msgs, dto.ChatMessage{
+				ID:        el.ID,
 				Message:   el.Text,
 				Writer:    dto.BOT,
 				CreatedAt: el.CreatedAt,
@@ -60,14 +62,12 @@ func chatMessageToDto(message *dto.ChatMessage, email string) *dto.DbMessageDto
 	}
 }
 
-func (r *ChatRepository) GetChatByUserEmail(ctx context.Context, email string, offset int, limit int) ([]dto.ChatMessage, error) {
-	query, _, _ := goqu.From("chat_messages").
+func (r *ChatRepository) GetChatByUserEmail(ctx context.Context, email string, page dto.PageRequest) (*dto.Page[dto.ChatMessage], error) {
+	query, _, _ := paginate(goqu.From("chat_messages").
+		Select("id", "email", "text", "role", "created_at").
 		Where(goqu.Ex{
 			"email": email,
-		}).
-		Order(goqu.C("created_at").Desc()).
-		Offset(uint(offset)).
-		Limit(uint(limit)).
+		}), goqu.C("created_at"), goqu.C("id"), page, true).
 		ToSQL()
 
 	r.logger.Debug(
@@ -90,7 +90,7 @@ func (r *ChatRepository) GetChatByUserEmail(ctx context.Context, email string, o
 	var messages []dto.DbMessageDto
 	for rows.Next() {
 		var msg dto.DbMessageDto
-		if err := rows.Scan(&msg.Email, &msg.Text, &msg.Role, &msg.CreatedAt); err != nil {
+		if err := rows.Scan(&msg.ID, &msg.Email, &msg.Text, &msg.Role, &msg.CreatedAt); err != nil {
 			r.logger.Error(
 				"Chat Repository Error",
 				zap.String("method", "GetChatByUserEmail"),
@@ -120,7 +120,9 @@ func (r *ChatRepository) GetChatByUserEmail(ctx context.Context, email string, o
 		zap.Any("Convert slice msgs", messages),
 	)
 
-	return dbMessageToChatMessage(messages...), nil
+	return dto.NewPage(dbMessageToChatMessage(messages...), page.Limit, func(m *dto.ChatMessage) dto.Cursor {
+		return dto.Cursor{Key: m.CreatedAt, ID: m.ID}
+	}), nil
 }
 
 func (r *ChatRepository) AddNewMessageToChatByEmail(ctx context.Context, email string, message *dto.ChatMessage) error {
@@ -0,0 +1,135 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"example.com/m/internal/api/v1/core/application/dto"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+const (
+	CodePurposeVerification  = "verify"
+	CodePurposePasswordReset = "reset"
+	// handover codes are stored under the booking id instead of an email
+	CodePurposeHandover = "handover"
+)
+
+// CodeRepository stores one-time codes sent by email under
+// "code:<purpose>:<email>".
+type CodeRepository struct {
+	rdb    *redis.Client
+	logger *zap.Logger
+}
+
+func NewCodeRepository(rdb *redis.Client, logger *zap.Logger) *CodeRepository {
+	return &CodeRepository{
+		rdb:    rdb,
+		logger: logger,
+	}
+}
+
+func codeKey(purpose string, email string) string {
+	return "code:" + purpose + ":" + email
+}
+
+// Set replaces the previous code of the email.
+func (r *CodeRepository) Set(ctx *context.Context, purpose string, email string, hash string, ttl time.Duration) error {
+	key := codeKey(purpose, email)
+	_, err := r.rdb.TxPipelined(*ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(*ctx, key)
+		pipe.HSet(*ctx, key, &dto.OneTimeCodeDto{Hash: hash})
+		pipe.Expire(*ctx, key, ttl)
+		return nil
+	})
+	if err != nil {
+		r.logger.Error(
+			"Code Repository Error",
+			zap.String("method", "Set"),
+			zap.String("error", err.Error()),
+		)
+		return err
+	}
+	return nil
+}
+
+func (r *CodeRepository) Get(ctx *context.Context, purpose string, email string) (*dto.OneTimeCodeDto, error) {
+	cmd := r.rdb.HGetAll(*ctx, codeKey(purpose, email))
+	if err := cmd.Err(); err != nil {
+		r.logger.Error(
+			"Code Repository Error",
+			zap.String("method", "Get"),
+			zap.String("error", err.Error()),
+		)
+		return nil, err
+	}
+	if len(cmd.Val()) == 0 {
+		return nil, nil
+	}
+
+	var code dto.OneTimeCodeDto
+	if err := cmd.Scan(&code); err != nil {
+		r.logger.Error(
+			"Code Repository Error",
+			zap.String("method", "Get"),
+			zap.String("error", err.Error()),
+		)
+		return nil, err
+	}
+	return &code, nil
+}
+
+// IncrAttempts counts a check of the code and returns the number of checks.
+// Nothing is counted when the code has already expired.
+func (r *CodeRepository) IncrAttempts(ctx *context.Context, purpose string, email string) (int64, error) {
+	key := codeKey(purpose, email)
+	attempts, err := incrIfExistsScript.Run(*ctx, r.rdb, []string{key}, "attempts").Int64()
+	if err != nil {
+		r.logger.Error(
+			"Code Repository Error",
+			zap.String("method", "IncrAttempts"),
+			zap.String("error", err.Error()),
+		)
+		return 0, err
+	}
+	return attempts, nil
+}
+
+var incrIfExistsScript = redis.NewScript(`
+if redis.call("EXISTS", KEYS[1]) == 1 then
+	return redis.call("HINCRBY", KEYS[1], ARGV[1], 1)
+end
+return 0
+`)
+
+// Delete removes the code and reports whether it still existed, so that of two
+// concurrent requests with the same code only one can use it.
+func (r *CodeRepository) Delete(ctx *context.Context, purpose string, email string) (bool, error) {
+	deleted, err := r.rdb.Del(*ctx, codeKey(purpose, email)).Result()
+	if err != nil {
+		r.logger.Error(
+			"Code Repository Error",
+			zap.String("method", "Delete"),
+			zap.String("error", err.Error()),
+		)
+		return false, err
+	}
+	return deleted == 1, nil
+}
+
+// Throttle returns false if it was already called for the purpose and email
+// during the last interval.
+func (r *CodeRepository) Throttle(ctx *context.Context, purpose string, email string, interval time.Duration) (bool, error) {
+	ok, err := r.rdb.SetNX(*ctx, codeKey(purpose, email)+":throttle", 1, interval).Result()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		r.logger.Error(
+			"Code Repository Error",
+			zap.String("method", "Throttle"),
+			zap.String("error", err.Error()),
+		)
+		return false, err
+	}
+	return ok, nil
+}
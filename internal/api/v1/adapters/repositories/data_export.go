@@ -0,0 +1,173 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+
+	"example.com/m/internal/api/v1/core/application/dto"
+	"github.com/doug-martin/goqu/v9"
+	"go.uber.org/zap"
+)
+
+// exportSections are the tables included in the personal data export. Each
+// section becomes a JSON array of rows in the file of the same name.
+var exportSections = []struct {
+	name  string
+	query func(email string) *goqu.SelectDataset
+}{
+	{"posts", func(email string) *goqu.SelectDataset {
+		return goqu.From("posts").Where(goqu.Ex{"user_email": email})
+	}},
+	{"preferred_places", func(email string) *goqu.SelectDataset {
+		return goqu.From("user_preferred_places").
+			Select(goqu.I("places.*")).
+			Join(goqu.T("places"), goqu.On(goqu.I("user_preferred_places.place_id").Eq(goqu.I("places.id")))).
+			Where(goqu.Ex{"user_preferred_places.user_email": email})
+	}},
+	{"bookings", func(email string) *goqu.SelectDataset {
+		return goqu.From("bookings").
+			Select(goqu.I("bookings.*"), goqu.I("posts.title").As("post_title")).
+			LeftJoin(goqu.T("posts"), goqu.On(goqu.I("bookings.post_id").Eq(goqu.I("posts.id")))).
+			Where(goqu.Ex{"bookings.user_email": email})
+	}},
+	{"waitlist", func(email string) *goqu.SelectDataset {
+		return goqu.From("booking_waitlist").
+			Select(goqu.I("booking_waitlist.*"), goqu.I("posts.title").As("post_title")).
+			LeftJoin(goqu.T("posts"), goqu.On(goqu.I("booking_waitlist.post_id").Eq(goqu.I("posts.id")))).
+			Where(goqu.Ex{"booking_waitlist.user_email": email})
+	}},
+	{"favorites", func(email string) *goqu.SelectDataset {
+		return goqu.From("favorites").
+			Select(goqu.I("favorites.*"), goqu.I("posts.title").As("post_title")).
+			LeftJoin(goqu.T("posts"), goqu.On(goqu.I("favorites.post_id").Eq(goqu.I("posts.id")))).
+			Where(goqu.Ex{"favorites.user_email": email})
+	}},
+	{"exchanges", func(email string) *goqu.SelectDataset {
+		return goqu.From("exchanges").
+			Select(
+				"id", "post_id", "post_title", "place_id", "booking_id",
+				exportUsername("exchanges.owner_email", "owner_username"),
+				exportUsername("exchanges.recipient_email", "recipient_username"),
+				"booked_at", "taken_at", "due_at", "returned_at",
+			).
+			Where(goqu.Or(
+				goqu.Ex{"owner_email": email},
+				goqu.Ex{"recipient_email": email},
+			))
+	}},
+	{"swap_offers", func(email string) *goqu.SelectDataset {
+		return goqu.From("swap_offers").
+			Select(
+				"id",
+				exportUsername("swap_offers.proposer_email", "proposer_username"),
+				exportUsername("swap_offers.recipient_email", "recipient_username"),
+				"parent_id", "status", "created_at", "updated_at",
+			).
+			Where(goqu.Or(
+				goqu.Ex{"proposer_email": email},
+				goqu.Ex{"recipient_email": email},
+			))
+	}},
+	{"no_shows", func(email string) *goqu.SelectDataset {
+		return goqu.From("no_show_reports").
+			Select("id", "booking_id", exportUsername("no_show_reports.reporter_email", "reporter_username"), "created_at").
+			Where(goqu.Ex{"user_email": email})
+	}},
+	{"reviews_written", func(email string) *goqu.SelectDataset {
+		return goqu.From("reviews").
+			Select(
+				"id", exportUsername("reviews.target_user_email", "target_username"),
+				"rating", "comment", "created_at", "exchange_id", "updated_at", "is_hidden",
+			).
+			Where(goqu.Ex{"reviewer_user_email": email})
+	}},
+	{"reviews_received", func(email string) *goqu.SelectDataset {
+		return goqu.From("reviews").
+			Select(
+				"id", exportUsername("reviews.reviewer_user_email", "reviewer_username"),
+				"rating", "comment", "created_at", "exchange_id", "updated_at", "is_hidden",
+			).
+			Where(goqu.Ex{"target_user_email": email})
+	}},
+	{"chat_messages", func(email string) *goqu.SelectDataset {
+		return goqu.From("chat_messages").Where(goqu.Ex{"email": email})
+	}},
+}
+
+// exportUsername selects the username of the user with the email in the
+// column, so that the export doesn't include other users' emails.
+func exportUsername(emailColumn string, as string) *goqu.SelectDataset {
+	return goqu.From("users").Select("username").
+		Where(goqu.I("users.email").Eq(goqu.I(emailColumn))).
+		As(as)
+}
+
+type DataExportRepository struct {
+	db     *sql.DB
+	logger *zap.Logger
+}
+
+func NewDataExportRepository(db *sql.DB, logger *zap.Logger) *DataExportRepository {
+	return &DataExportRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// Export returns the profile and every section as JSON built by Postgres.
+func (r *DataExportRepository) Export(ctx context.Context, email string) ([]dto.ExportFileDto, error) {
+	profile := goqu.From("users").
+		Select("email", "username", "created_at", "updated_at", "telegram_username", "is_verified", "deletion_scheduled_at",
+			"avatar_url", "bio", "faculty", "email_visibility", "telegram_visibility",
+			"no_show_count", "booking_suspended_until").
+		Where(goqu.Ex{"email": email})
+	query, _, err := goqu.From(profile.As("t")).Select(goqu.L("row_to_json(t)")).ToSQL()
+	if err != nil {
+		r.logger.Error(
+			"Data Export Repository Error",
+			zap.String("method", "Export"),
+			zap.String("error", err.Error()),
+		)
+		return nil, err
+	}
+
+	var data []byte
+	if err := r.db.QueryRow(query).Scan(&data); err != nil {
+		r.logger.Error(
+			"Data Export Repository Error",
+			zap.String("method", "Export"),
+			zap.String("error", err.Error()),
+		)
+		return nil, err
+	}
+	files := []dto.ExportFileDto{{Name: "profile.json", Data: data}}
+
+	for _, section := range exportSections {
+		query, _, err := goqu.
+			From(section.query(email).As("t")).
+			Select(goqu.L("COALESCE(json_agg(t ORDER BY t.id), '[]')")).
+			ToSQL()
+		if err != nil {
+			r.logger.Error(
+				"Data Export Repository Error",
+				zap.String("method", "Export"),
+				zap.String("section", section.name),
+				zap.String("error", err.Error()),
+			)
+			return nil, err
+		}
+
+		var data []byte
+		if err := r.db.QueryRow(query).Scan(&data); err != nil {
+			r.logger.Error(
+				"Data Export Repository Error",
+				zap.String("method", "Export"),
+				zap.String("section", section.name),
+				zap.String("error", err.Error()),
+			)
+			return nil, err
+		}
+		files = append(files, dto.ExportFileDto{Name: section.name + ".json", Data: data})
+	}
+	return files, nil
+}
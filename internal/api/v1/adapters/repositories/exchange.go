@@ -0,0 +1,237 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+
+	"example.com/m/internal/api/v1/core/application/dto"
+	"github.com/doug-martin/goqu/v9"
+	"github.com/doug-martin/goqu/v9/exp"
+	"go.uber.org/zap"
+)
+
+type ExchangeRepository struct {
+	db     DBTX
+	logger *zap.Logger
+}
+
+func NewExchangeRepository(db *sql.DB, logger *zap.Logger) *ExchangeRepository {
+	return &ExchangeRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+func (r *ExchangeRepository) Create(ctx context.Context, e *dto.ExchangeToCreateDto) (*int64, error) {
+	var id int64
+	query, _, _ := goqu.Insert("exchanges").Rows(*e).Returning("id").ToSQL()
+	err := r.db.QueryRow(query).Scan(&id)
+	if err != nil {
+		r.logger.Error(
+			"Exchange Repository Error",
+			zap.String("method", "Create"),
+			zap.String("error", err.Error()),
+		)
+		return nil, err
+	}
+	return &id, nil
+}
+
+// GetGiven returns exchanges in which the user gave a book away.
+func (r *ExchangeRepository) GetGiven(ctx context.Context, ownerEmail string, page dto.PageRequest) (*dto.Page[dto.ExchangeDto], error) {
+	return r.list(ctx, "GetGiven", goqu.Ex{"exchanges.owner_email": ownerEmail}, page)
+}
+
+// GetReceived returns exchanges in which the user received a book.
+func (r *ExchangeRepository) GetReceived(ctx context.Context, recipientEmail string, page dto.PageRequest) (*dto.Page[dto.ExchangeDto], error) {
+	return r.list(ctx, "GetReceived", goqu.Ex{"exchanges.recipient_email": recipientEmail}, page)
+}
+
+func (r *ExchangeRepository) GetAll(ctx context.Context, page dto.PageRequest) (*dto.Page[dto.ExchangeDto], error) {
+	return r.list(ctx, "GetAll", goqu.Ex{}, page)
+}
+
+// GetOverdueLoans returns loans not returned by now in which the user is the
+// lender or the borrower.
+func (r *ExchangeRepository) GetOverdueLoans(ctx context.Context, email string, now string, page dto.PageRequest) (*dto.Page[dto.ExchangeDto], error) {
+	return r.list(ctx, "GetOverdueLoans", goqu.And(
+		goqu.Or(
+			goqu.Ex{"exchanges.owner_email": email},
+			goqu.Ex{"exchanges.recipient_email": email},
+		),
+		goqu.I("exchanges.due_at").Lt(now),
+		goqu.Ex{"exchanges.returned_at": nil},
+	), page)
+}
+
+// GetLoansDueSoon returns up to limit loans which are due between now and
+// before and whose borrower hasn't been reminded yet.
+func (r *ExchangeRepository) GetLoansDueSoon(ctx context.Context, now string, before string, limit uint) ([]dto.ExchangeDto, error) {
+	loans, err := r.list(ctx, "GetLoansDueSoon", goqu.And(
+		goqu.I("exchanges.due_at").Gte(now),
+		goqu.I("exchanges.due_at").Lte(before),
+		goqu.Ex{"exchanges.returned_at": nil, "exchanges.due_reminder_sent_at": nil},
+	), dto.PageRequest{Limit: limit})
+	if err != nil {
+		return nil, err
+	}
+	return loans.Items, nil
+}
+
+// GetOverdueLoansToRemind returns up to limit overdue loans whose last overdue
+// reminder was sent before remindedBefore or never.
+func (r *ExchangeRepository) GetOverdueLoansToRemind(ctx context.Context, now string, remindedBefore string, limit uint) ([]dto.ExchangeDto, error) {
+	loans, err := r.list(ctx, "GetOverdueLoansToRemind", goqu.And(
+		goqu.I("exchanges.due_at").Lt(now),
+		goqu.Ex{"exchanges.returned_at": nil},
+		goqu.Or(
+			goqu.Ex{"exchanges.overdue_reminder_sent_at": nil},
+			goqu.I("exchanges.overdue_reminder_sent_at").Lt(remindedBefore),
+		),
+	), dto.PageRequest{Limit: limit})
+	if err != nil {
+		return nil, err
+	}
+	return loans.Items, nil
+}
+
+// SetReminderSent records when a reminder of the kind was sent, kind is
+// "due" or "overdue".
+func (r *ExchangeRepository) SetReminderSent(ctx context.Context, id int64, kind string, at string) error {
+	query, _, _ := goqu.Update("exchanges").
+		Set(goqu.Record{kind + "_reminder_sent_at": at}).
+		Where(goqu.C("id").Eq(id)).
+		ToSQL()
+	if _, err := r.db.Exec(query); err != nil {
+		r.logger.Error(
+			"Exchange Repository Error",
+			zap.String("method", "SetReminderSent"),
+			zap.String("error", err.Error()),
+		)
+		return err
+	}
+	return nil
+}
+
+// MarkReturned records the return of the loan. It returns false when the
+// exchange is not a loan or has already been returned.
+func (r *ExchangeRepository) MarkReturned(ctx context.Context, id int64, returnedAt string) (bool, error) {
+	query, _, _ := goqu.Update("exchanges").
+		Set(goqu.Record{"returned_at": returnedAt}).
+		Where(
+			goqu.C("id").Eq(id),
+			goqu.C("due_at").IsNotNull(),
+			goqu.C("returned_at").IsNull(),
+		).
+		ToSQL()
+	result, err := r.db.Exec(query)
+	if err != nil {
+		r.logger.Error(
+			"Exchange Repository Error",
+			zap.String("method", "MarkReturned"),
+			zap.String("error", err.Error()),
+		)
+		return false, err
+	}
+	updated, err := result.RowsAffected()
+	if err != nil {
+		r.logger.Error(
+			"Exchange Repository Error",
+			zap.String("method", "MarkReturned"),
+			zap.String("error", err.Error()),
+		)
+		return false, err
+	}
+	return updated == 1, nil
+}
+
+func (r *ExchangeRepository) list(ctx context.Context, method string, where exp.Expression, page dto.PageRequest) (*dto.Page[dto.ExchangeDto], error) {
+	var exchanges []dto.ExchangeDto
+	query, _, err := paginate(goqu.
+		Select(
+			"exchanges.id", "exchanges.post_id", "exchanges.post_title", "exchanges.place_id",
+			goqu.L("COALESCE(places.name, '')").As("place_name"),
+			// the owner or the recipient is empty if the account was deleted
+			"exchanges.booking_id", goqu.COALESCE(goqu.I("exchanges.owner_email"), ""),
+			goqu.COALESCE(goqu.I("owners.username"), "").As("owner_username"),
+			goqu.COALESCE(goqu.I("exchanges.recipient_email"), ""),
+			goqu.COALESCE(goqu.I("recipients.username"), "").As("recipient_username"),
+			"exchanges.booked_at", "exchanges.taken_at", "exchanges.due_at", "exchanges.returned_at",
+			"exchanges.overdue_reminder_sent_at",
+		).
+		From("exchanges").
+		LeftJoin(
+			goqu.T("places"),
+			goqu.On(goqu.I("exchanges.place_id").Eq(goqu.I("places.id"))),
+		).
+		LeftJoin(
+			goqu.T("users").As("owners"),
+			goqu.On(goqu.I("exchanges.owner_email").Eq(goqu.I("owners.email"))),
+		).
+		LeftJoin(
+			goqu.T("users").As("recipients"),
+			goqu.On(goqu.I("exchanges.recipient_email").Eq(goqu.I("recipients.email"))),
+		).
+		Where(where), goqu.I("exchanges.taken_at"), goqu.I("exchanges.id"), page, true).
+		ToSQL()
+	if err != nil {
+		r.logger.Error(
+			"Exchange Repository Error",
+			zap.String("method", method),
+			zap.String("error", err.Error()),
+		)
+		return nil, err
+	}
+
+	rows, err := r.db.Query(query)
+	if err != nil {
+		r.logger.Error(
+			"Exchange Repository Error",
+			zap.String("method", method),
+			zap.String("error", err.Error()),
+		)
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var e dto.ExchangeDto
+		if err := rows.Scan(
+			&e.ID, &e.PostID, &e.PostTitle, &e.PlaceID, &e.PlaceName,
+			&e.BookingID, &e.OwnerEmail, &e.OwnerUsername,
+			&e.RecipientEmail, &e.RecipientUsername,
+			&e.BookedAt, &e.TakenAt, &e.DueAt, &e.ReturnedAt, &e.OverdueReminderSentAt); err != nil {
+			r.logger.Error(
+				"Exchange Repository Error",
+				zap.String("method", method),
+				zap.String("error", err.Error()),
+			)
+			return nil, err
+		}
+		exchanges = append(exchanges, e)
+	}
+
+	if err := rows.Err(); err != nil {
+		r.logger.Error(
+			"Exchange Repository Error",
+			zap.String("method", method),
+			zap.String("error", err.Error()),
+		)
+		return nil, err
+	}
+
+	return dto.NewPage(exchanges, page.Limit, func(e *dto.ExchangeDto) dto.Cursor {
+		return dto.Cursor{Key: e.TakenAt, ID: e.ID}
+	}), nil
+}
+
+func (r *ExchangeRepository) Get(ctx context.Context, id int64) (*dto.ExchangeDto, error) {
+	exchanges, err := r.list(ctx, "Get", goqu.Ex{"exchanges.id": id}, dto.PageRequest{Limit: 1})
+	if err != nil {
+		return nil, err
+	}
+	if len(exchanges.Items) == 0 {
+		return nil, nil
+	}
+	return &exchanges.Items[0], nil
+}
@@ -0,0 +1,78 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"example.com/m/internal/api/v1/core/application/dto"
+	"github.com/doug-martin/goqu/v9"
+	"go.uber.org/zap"
+)
+
+// IdentityRepository links accounts of external identity providers to users.
+type IdentityRepository struct {
+	db     *sql.DB
+	logger *zap.Logger
+}
+
+func NewIdentityRepository(db *sql.DB, logger *zap.Logger) *IdentityRepository {
+	return &IdentityRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// GetUserEmail returns the email of the user linked to the identity or nil.
+func (r *IdentityRepository) GetUserEmail(ctx context.Context, issuer string, subject string) (*string, error) {
+	query, _, err := goqu.From("user_identities").Select("user_email").Where(goqu.Ex{
+		"issuer":  issuer,
+		"subject": subject,
+	}).ToSQL()
+	if err != nil {
+		r.logger.Error(
+			"Identity Repository Error",
+			zap.String("method", "GetUserEmail"),
+			zap.String("error", err.Error()),
+		)
+		return nil, err
+	}
+
+	var email string
+	err = r.db.QueryRow(query).Scan(&email)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		r.logger.Error(
+			"Identity Repository Error",
+			zap.String("method", "GetUserEmail"),
+			zap.String("error", err.Error()),
+		)
+		return nil, err
+	}
+	return &email, nil
+}
+
+// Create links the identity. Linking an already linked identity is a no-op.
+func (r *IdentityRepository) Create(ctx context.Context, i *dto.UserIdentityDto) error {
+	query, _, err := goqu.Insert("user_identities").Rows(*i).OnConflict(goqu.DoNothing()).ToSQL()
+	if err != nil {
+		r.logger.Error(
+			"Identity Repository Error",
+			zap.String("method", "Create"),
+			zap.String("error", err.Error()),
+		)
+		return err
+	}
+
+	if _, err := r.db.Exec(query); err != nil {
+		r.logger.Error(
+			"Identity Repository Error",
+			zap.String("method", "Create"),
+			zap.String("error", err.Error()),
+		)
+		return err
+	}
+	return nil
+}
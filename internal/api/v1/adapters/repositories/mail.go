@@ -0,0 +1,33 @@
+package repositories
+
+import (
+	"context"
+
+	"example.com/m/internal/api/v1/infrastructure/mail"
+	"go.uber.org/zap"
+)
+
+type MailRepository struct {
+	mailer mail.Mailer
+	logger *zap.Logger
+}
+
+func NewMailRepository(mailer mail.Mailer, logger *zap.Logger) *MailRepository {
+	return &MailRepository{
+		mailer: mailer,
+		logger: logger,
+	}
+}
+
+func (r *MailRepository) Send(ctx context.Context, to string, subject string, body string) error {
+	err := r.mailer.Send(ctx, to, subject, body)
+	if err != nil {
+		r.logger.Error(
+			"Mail Repository Error",
+			zap.String("method", "Send"),
+			zap.String("error", err.Error()),
+		)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,152 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"example.com/m/internal/api/v1/core/application/dto"
+	"github.com/doug-martin/goqu/v9"
+	"go.uber.org/zap"
+)
+
+// NoShowRepository keeps no-show reports and the booking restrictions of
+// users.
+type NoShowRepository struct {
+	db     DBTX
+	logger *zap.Logger
+}
+
+func NewNoShowRepository(db *sql.DB, logger *zap.Logger) *NoShowRepository {
+	return &NoShowRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// Create stores the report and increments the no-show counter of the user.
+// It should be called in a transaction.
+func (r *NoShowRepository) Create(ctx context.Context, report *dto.NoShowReportToCreateDto) error {
+	query, _, err := goqu.Insert("no_show_reports").Rows(report).ToSQL()
+	if err != nil {
+		r.logger.Error(
+			"No Show Repository Error",
+			zap.String("method", "Create"),
+			zap.String("error", err.Error()),
+		)
+		return err
+	}
+	if _, err := r.db.ExecContext(ctx, query); err != nil {
+		r.logger.Error(
+			"No Show Repository Error",
+			zap.String("method", "Create"),
+			zap.String("error", err.Error()),
+		)
+		return err
+	}
+
+	query, _, err = goqu.Update("users").
+		Set(goqu.Record{"no_show_count": goqu.L("no_show_count + 1")}).
+		Where(goqu.C("email").Eq(report.UserEmail)).
+		ToSQL()
+	if err != nil {
+		r.logger.Error(
+			"No Show Repository Error",
+			zap.String("method", "Create"),
+			zap.String("error", err.Error()),
+		)
+		return err
+	}
+	if _, err := r.db.ExecContext(ctx, query); err != nil {
+		r.logger.Error(
+			"No Show Repository Error",
+			zap.String("method", "Create"),
+			zap.String("error", err.Error()),
+		)
+		return err
+	}
+	return nil
+}
+
+// CountSince returns the number of no-shows of the user reported after since.
+func (r *NoShowRepository) CountSince(ctx context.Context, userEmail string, since string) (int, error) {
+	var count int
+	query, _, err := goqu.From("no_show_reports").
+		Select(goqu.COUNT("*")).
+		Where(goqu.C("user_email").Eq(userEmail), goqu.C("created_at").Gt(since)).
+		ToSQL()
+	if err != nil {
+		r.logger.Error(
+			"No Show Repository Error",
+			zap.String("method", "CountSince"),
+			zap.String("error", err.Error()),
+		)
+		return 0, err
+	}
+	if err := r.db.QueryRowContext(ctx, query).Scan(&count); err != nil {
+		r.logger.Error(
+			"No Show Repository Error",
+			zap.String("method", "CountSince"),
+			zap.String("error", err.Error()),
+		)
+		return 0, err
+	}
+	return count, nil
+}
+
+// GetBookingRestrictions returns the time until which the user can't book
+// after no-shows and the time the account is scheduled to be deleted at. Both
+// are nil when not set or when the user doesn't exist.
+func (r *NoShowRepository) GetBookingRestrictions(ctx context.Context, userEmail string) (*string, *string, error) {
+	var suspendedUntil, deletionScheduledAt *string
+	query, _, err := goqu.From("users").
+		Select("booking_suspended_until", "deletion_scheduled_at").
+		Where(goqu.C("email").Eq(userEmail)).
+		ToSQL()
+	if err != nil {
+		r.logger.Error(
+			"No Show Repository Error",
+			zap.String("method", "GetBookingRestrictions"),
+			zap.String("error", err.Error()),
+		)
+		return nil, nil, err
+	}
+	err = r.db.QueryRowContext(ctx, query).Scan(&suspendedUntil, &deletionScheduledAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil, nil
+	}
+	if err != nil {
+		r.logger.Error(
+			"No Show Repository Error",
+			zap.String("method", "GetBookingRestrictions"),
+			zap.String("error", err.Error()),
+		)
+		return nil, nil, err
+	}
+	return suspendedUntil, deletionScheduledAt, nil
+}
+
+// SuspendBooking forbids the user to book until the given time.
+func (r *NoShowRepository) SuspendBooking(ctx context.Context, userEmail string, until string) error {
+	query, _, err := goqu.Update("users").
+		Set(goqu.Record{"booking_suspended_until": until}).
+		Where(goqu.C("email").Eq(userEmail)).
+		ToSQL()
+	if err != nil {
+		r.logger.Error(
+			"No Show Repository Error",
+			zap.String("method", "SuspendBooking"),
+			zap.String("error", err.Error()),
+		)
+		return err
+	}
+	if _, err := r.db.ExecContext(ctx, query); err != nil {
+		r.logger.Error(
+			"No Show Repository Error",
+			zap.String("method", "SuspendBooking"),
+			zap.String("error", err.Error()),
+		)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"example.com/m/internal/api/v1/core/application/dto"
+	"example.com/m/internal/api/v1/infrastructure/oidc"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+// OIDCRepository talks to the identity provider and keeps logins in progress
+// under "oidc:<state>".
+type OIDCRepository struct {
+	provider *oidc.Provider
+	rdb      *redis.Client
+	logger   *zap.Logger
+}
+
+func NewOIDCRepository(provider *oidc.Provider, rdb *redis.Client, logger *zap.Logger) *OIDCRepository {
+	return &OIDCRepository{
+		provider: provider,
+		rdb:      rdb,
+		logger:   logger,
+	}
+}
+
+func oidcLoginKey(state string) string {
+	return "oidc:" + state
+}
+
+func (r *OIDCRepository) Enabled() bool {
+	return r.provider != nil
+}
+
+func (r *OIDCRepository) Issuer() string {
+	return r.provider.Issuer()
+}
+
+func (r *OIDCRepository) AuthCodeURL(ctx context.Context, state string, nonce string, challenge string) (string, error) {
+	url, err := r.provider.AuthCodeURL(ctx, state, nonce, challenge)
+	if err != nil {
+		r.logger.Error(
+			"OIDC Repository Error",
+			zap.String("method", "AuthCodeURL"),
+			zap.String("error", err.Error()),
+		)
+		return "", err
+	}
+	return url, nil
+}
+
+func (r *OIDCRepository) Exchange(ctx context.Context, code string, verifier string, nonce string) (*dto.OIDCClaimsDto, error) {
+	claims, err := r.provider.Exchange(ctx, code, verifier, nonce)
+	if err != nil {
+		r.logger.Error(
+			"OIDC Repository Error",
+			zap.String("method", "Exchange"),
+			zap.String("error", err.Error()),
+		)
+		return nil, err
+	}
+	return &dto.OIDCClaimsDto{
+		Issuer:            r.provider.Issuer(),
+		Subject:           claims.Subject,
+		Email:             claims.Email,
+		EmailVerified:     claims.EmailVerified,
+		PreferredUsername: claims.PreferredUsername,
+	}, nil
+}
+
+func (r *OIDCRepository) SaveLogin(ctx *context.Context, state string, l *dto.OIDCLoginDto, ttl time.Duration) error {
+	key := oidcLoginKey(state)
+	_, err := r.rdb.TxPipelined(*ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(*ctx, key, l)
+		pipe.Expire(*ctx, key, ttl)
+		return nil
+	})
+	if err != nil {
+		r.logger.Error(
+			"OIDC Repository Error",
+			zap.String("method", "SaveLogin"),
+			zap.String("error", err.Error()),
+		)
+		return err
+	}
+	return nil
+}
+
+// TakeLogin returns the login and removes it, so that a state can be used once.
+func (r *OIDCRepository) TakeLogin(ctx *context.Context, state string) (*dto.OIDCLoginDto, error) {
+	key := oidcLoginKey(state)
+	var get *redis.MapStringStringCmd
+	var del *redis.IntCmd
+	_, err := r.rdb.TxPipelined(*ctx, func(pipe redis.Pipeliner) error {
+		get = pipe.HGetAll(*ctx, key)
+		del = pipe.Del(*ctx, key)
+		return nil
+	})
+	if err != nil {
+		r.logger.Error(
+			"OIDC Repository Error",
+			zap.String("method", "TakeLogin"),
+			zap.String("error", err.Error()),
+		)
+		return nil, err
+	}
+	if del.Val() == 0 {
+		return nil, nil
+	}
+
+	var l dto.OIDCLoginDto
+	if err := get.Scan(&l); err != nil {
+		r.logger.Error(
+			"OIDC Repository Error",
+			zap.String("method", "TakeLogin"),
+			zap.String("error", err.Error()),
+		)
+		return nil, err
+	}
+	return &l, nil
+}
@@ -0,0 +1,25 @@
+package repositories
+
+import (
+	"example.com/m/internal/api/v1/core/application/dto"
+	"github.com/doug-martin/goqu/v9"
+	"github.com/doug-martin/goqu/v9/exp"
+)
+
+// paginate orders the query by key and id and leaves only the items after the
+// cursor. One item more than the limit is fetched, dto.NewPage uses it to tell
+// whether there is a next page.
+func paginate(query *goqu.SelectDataset, key, id exp.Orderable, page dto.PageRequest, desc bool) *goqu.SelectDataset {
+	if desc {
+		if page.Cursor != nil {
+			query = query.Where(goqu.L("(?, ?) < (?, ?)", key, id, page.Cursor.Key, page.Cursor.ID))
+		}
+		query = query.Order(key.Desc(), id.Desc())
+	} else {
+		if page.Cursor != nil {
+			query = query.Where(goqu.L("(?, ?) > (?, ?)", key, id, page.Cursor.Key, page.Cursor.ID))
+		}
+		query = query.Order(key.Asc(), id.Asc())
+	}
+	return query.Limit(page.Limit + 1)
+}
@@ -0,0 +1,170 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"example.com/m/internal/api/v1/core/application/dto"
+	"github.com/doug-martin/goqu/v9"
+	"github.com/doug-martin/goqu/v9/exp"
+	"go.uber.org/zap"
+)
+
+type PickupSlotRepository struct {
+	db     DBTX
+	logger *zap.Logger
+}
+
+func NewPickupSlotRepository(db *sql.DB, logger *zap.Logger) *PickupSlotRepository {
+	return &PickupSlotRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// reservedCount counts active bookings of the slot, except the given booking.
+func reservedCount(slotID exp.Comparable, exceptBookingID int64) *goqu.SelectDataset {
+	return goqu.From("bookings").
+		Select(goqu.COUNT("*")).
+		Where(
+			goqu.I("bookings.slot_id").Eq(slotID),
+			goqu.I("bookings.status").In(activeBookingStatuses),
+			goqu.I("bookings.id").Neq(exceptBookingID),
+		)
+}
+
+func slotSelect() *goqu.SelectDataset {
+	return goqu.From("pickup_slots").Select(
+		"pickup_slots.id", "pickup_slots.place_id", "pickup_slots.starts_at", "pickup_slots.ends_at",
+		"pickup_slots.capacity", reservedCount(goqu.I("pickup_slots.id"), 0).As("reserved"),
+	)
+}
+
+func scanSlot(row rowScanner, s *dto.PickupSlotDto) error {
+	return row.Scan(&s.ID, &s.PlaceID, &s.StartsAt, &s.EndsAt, &s.Capacity, &s.Reserved)
+}
+
+func (r *PickupSlotRepository) Create(ctx context.Context, s *dto.PickupSlotToCreateDto) (*int64, error) {
+	var id int64
+	query, _, _ := goqu.Insert("pickup_slots").Rows(s).Returning("id").ToSQL()
+	if err := r.db.QueryRowContext(ctx, query).Scan(&id); err != nil {
+		r.logger.Error(
+			"Pickup Slot Repository Error",
+			zap.String("method", "Create"),
+			zap.String("error", err.Error()),
+		)
+		return nil, err
+	}
+	return &id, nil
+}
+
+func (r *PickupSlotRepository) Get(ctx context.Context, id int64) (*dto.PickupSlotDto, error) {
+	return r.get(ctx, "Get", id, false)
+}
+
+// GetForUpdate returns the slot and locks it until the end of the
+// transaction, so that its capacity is checked by one booking at a time.
+func (r *PickupSlotRepository) GetForUpdate(ctx context.Context, id int64) (*dto.PickupSlotDto, error) {
+	return r.get(ctx, "GetForUpdate", id, true)
+}
+
+func (r *PickupSlotRepository) get(ctx context.Context, method string, id int64, forUpdate bool) (*dto.PickupSlotDto, error) {
+	query := slotSelect().Where(goqu.Ex{"pickup_slots.id": id})
+	if forUpdate {
+		query = query.ForUpdate(exp.Wait)
+	}
+	sqlQuery, _, _ := query.ToSQL()
+
+	var slot dto.PickupSlotDto
+	err := scanSlot(r.db.QueryRowContext(ctx, sqlQuery), &slot)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		r.logger.Error(
+			"Pickup Slot Repository Error",
+			zap.String("method", method),
+			zap.String("error", err.Error()),
+		)
+		return nil, err
+	}
+	return &slot, nil
+}
+
+// CountReserved returns the number of active bookings of the slot other than
+// the given booking.
+func (r *PickupSlotRepository) CountReserved(ctx context.Context, slotID int64, exceptBookingID int64) (int, error) {
+	var count int
+	query, _, _ := reservedCount(goqu.V(slotID), exceptBookingID).ToSQL()
+	if err := r.db.QueryRowContext(ctx, query).Scan(&count); err != nil {
+		r.logger.Error(
+			"Pickup Slot Repository Error",
+			zap.String("method", "CountReserved"),
+			zap.String("error", err.Error()),
+		)
+		return 0, err
+	}
+	return count, nil
+}
+
+// GetByPlace returns slots of the place starting within [from, to), earliest
+// first.
+func (r *PickupSlotRepository) GetByPlace(ctx context.Context, placeID int64, from string, to string) ([]dto.PickupSlotDto, error) {
+	slots := []dto.PickupSlotDto{}
+	query, _, _ := slotSelect().
+		Where(
+			goqu.I("pickup_slots.place_id").Eq(placeID),
+			goqu.I("pickup_slots.starts_at").Gte(from),
+			goqu.I("pickup_slots.starts_at").Lt(to),
+		).
+		Order(goqu.I("pickup_slots.starts_at").Asc(), goqu.I("pickup_slots.id").Asc()).
+		ToSQL()
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		r.logger.Error(
+			"Pickup Slot Repository Error",
+			zap.String("method", "GetByPlace"),
+			zap.String("error", err.Error()),
+		)
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var slot dto.PickupSlotDto
+		if err := scanSlot(rows, &slot); err != nil {
+			r.logger.Error(
+				"Pickup Slot Repository Error",
+				zap.String("method", "GetByPlace"),
+				zap.String("error", err.Error()),
+			)
+			return nil, err
+		}
+		slots = append(slots, slot)
+	}
+
+	if err := rows.Err(); err != nil {
+		r.logger.Error(
+			"Pickup Slot Repository Error",
+			zap.String("method", "GetByPlace"),
+			zap.String("error", err.Error()),
+		)
+		return nil, err
+	}
+	return slots, nil
+}
+
+func (r *PickupSlotRepository) Delete(ctx context.Context, id int64) error {
+	query, _, _ := goqu.From("pickup_slots").Where(goqu.C("id").Eq(id)).Delete().ToSQL()
+	if _, err := r.db.ExecContext(ctx, query); err != nil {
+		r.logger.Error(
+			"Pickup Slot Repository Error",
+			zap.String("method", "Delete"),
+			zap.String("error", err.Error()),
+		)
+		return err
+	}
+	return nil
+}
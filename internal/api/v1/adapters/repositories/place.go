@@ -113,3 +113,101 @@ func (r *PlaceRepository) Create(ctx context.Context, p *dto.CreatePlaceDto) (*d
 	x := p.ToPlaceDto(id)
 	return &x, nil
 }
+
+func (r *PlaceRepository) GetOpeningHours(ctx context.Context, placeID int64) ([]dto.OpeningHoursDto, error) {
+	query, _, _ := goqu.From("place_opening_hours").
+		Select("weekday", "opens_at", "closes_at").
+		Where(goqu.Ex{"place_id": placeID}).
+		Order(goqu.C("weekday").Asc(), goqu.C("opens_at").Asc()).
+		ToSQL()
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		r.logger.Error(
+			"Place Repository Error",
+			zap.String("method", "GetOpeningHours"),
+			zap.String("error", err.Error()),
+		)
+		return nil, err
+	}
+	defer rows.Close()
+
+	hours := []dto.OpeningHoursDto{}
+	for rows.Next() {
+		var h dto.OpeningHoursDto
+		if err := rows.Scan(&h.Weekday, &h.OpensAt, &h.ClosesAt); err != nil {
+			r.logger.Error(
+				"Place Repository Error",
+				zap.String("method", "GetOpeningHours"),
+				zap.String("error", err.Error()),
+			)
+			return nil, err
+		}
+		hours = append(hours, h)
+	}
+
+	if err := rows.Err(); err != nil {
+		r.logger.Error(
+			"Place Repository Error",
+			zap.String("method", "GetOpeningHours"),
+			zap.String("error", err.Error()),
+		)
+		return nil, err
+	}
+	return hours, nil
+}
+
+// SetOpeningHours replaces the opening hours of the place.
+func (r *PlaceRepository) SetOpeningHours(ctx context.Context, placeID int64, hours []dto.OpeningHoursDto) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		r.logger.Error(
+			"Place Repository Error",
+			zap.String("method", "SetOpeningHours"),
+			zap.String("error", err.Error()),
+		)
+		return err
+	}
+	defer tx.Rollback()
+
+	query, _, _ := goqu.Delete("place_opening_hours").Where(goqu.Ex{"place_id": placeID}).ToSQL()
+	if _, err := tx.Exec(query); err != nil {
+		r.logger.Error(
+			"Place Repository Error",
+			zap.String("method", "SetOpeningHours"),
+			zap.String("error", err.Error()),
+		)
+		return err
+	}
+
+	if len(hours) != 0 {
+		rows := make([]interface{}, 0, len(hours))
+		for _, h := range hours {
+			rows = append(rows, goqu.Record{
+				"place_id":  placeID,
+				"weekday":   h.Weekday,
+				"opens_at":  h.OpensAt,
+				"closes_at": h.ClosesAt,
+			})
+		}
+		query, _, _ = goqu.Insert("place_opening_hours").Rows(rows...).ToSQL()
+		if _, err := tx.Exec(query); err != nil {
+			r.logger.Error(
+				"Place Repository Error",
+				zap.String("method", "SetOpeningHours"),
+				zap.String("error", err.Error()),
+			)
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		r.logger.Error(
+			"Place Repository Error",
+			zap.String("method", "SetOpeningHours"),
+			zap.String("error", err.Error()),
+		)
+		return err
+	}
+	return nil
+}
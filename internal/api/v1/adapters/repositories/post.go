@@ -7,18 +7,30 @@ import (
 	"errors"
 	"fmt"
 	"mime/multipart"
+	"path"
+	"strconv"
+	"strings"
 
 	"example.com/m/internal/api/v1/core/application/dto"
 	object_storage "example.com/m/internal/api/v1/infrastructure/s3"
 	"example.com/m/internal/config"
 	"github.com/doug-martin/goqu/v9"
+	"github.com/doug-martin/goqu/v9/exp"
 	"github.com/google/uuid"
 	"github.com/lib/pq"
 	"go.uber.org/zap"
 )
 
+// hideOwnerEmail removes the owner's email from a post shown to another user,
+// the owner is identified by OwnerUsername instead.
+func hideOwnerEmail(post *dto.PostDto, viewerEmail string) {
+	if post.UserEmail != viewerEmail {
+		post.UserEmail = ""
+	}
+}
+
 type PostRepository struct {
-	db     *sql.DB
+	db     DBTX
 	logger *zap.Logger
 	s3     *object_storage.ClientS3
 }
@@ -36,13 +48,13 @@ func (r *PostRepository) Create(ctx context.Context, p *dto.PostDtoWithoutId) (*
 
 	// Manually construct the query to handle the images field
 	query := `
-        INSERT INTO posts (images, user_email, place_id, title, description, genre, author, publication_year, publisher, condition, status, created_at, cover, pages_count)
-        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+        INSERT INTO posts (images, user_email, place_id, title, description, genre, author, publication_year, publisher, condition, status, created_at, cover, pages_count, listing_type, loan_period_days)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)
         RETURNING id
     `
 
 	// Execute the query with the images field converted to a PostgreSQL array
-	err := r.db.QueryRowContext(ctx, query, pq.Array(p.Images), p.UserEmail, p.PlaceID, p.Title, p.Description, p.Genre, p.Author, p.PublicationYear, p.Publisher, p.Condition, p.Status, p.CreatedAt, &p.Cover, &p.PagesCount).Scan(&id)
+	err := r.db.QueryRowContext(ctx, query, pq.Array(p.Images), p.UserEmail, p.PlaceID, p.Title, p.Description, p.Genre, p.Author, p.PublicationYear, p.Publisher, p.Condition, p.Status, p.CreatedAt, &p.Cover, &p.PagesCount, p.ListingType, p.LoanPeriodDays).Scan(&id)
 	if err != nil {
 		r.logger.Error(
 			"Post Repository Error",
@@ -84,7 +96,7 @@ func (r *PostRepository) Get(ctx context.Context, id int64, userEmail string) (*
 		Scan(&post.ID, &images, &post.UserEmail,
 			&post.PlaceID, &post.Title, &post.Description, &post.Genre,
 			&post.Author, &post.PublicationYear, &post.Publisher, &post.Condition,
-			&post.Status, &post.CreatedAt, &post.Cover, &post.PagesCount, &post.Summary, &post.Quote, &post.PlaceName, &post.PlaceAddress, &post.OwnerUsername, &post.IsFavorite)
+			&post.Status, &post.CreatedAt, &post.Cover, &post.PagesCount, &post.Summary, &post.Quote, &post.ListingType, &post.LoanPeriodDays, &post.PlaceName, &post.PlaceAddress, &post.OwnerUsername, &post.IsFavorite)
 	if errors.Is(err, sql.ErrNoRows) {
 		return nil, nil
 	}
@@ -101,6 +113,33 @@ func (r *PostRepository) Get(ctx context.Context, id int64, userEmail string) (*
 	} else {
 		post.Images = images
 	}
+	hideOwnerEmail(&post, userEmail)
+	return &post, nil
+}
+
+// GetForUpdate locks the post row until the end of the transaction and returns
+// the fields needed to decide on a booking. Must be called inside UnitOfWork.Do.
+func (r *PostRepository) GetForUpdate(ctx context.Context, id int64) (*dto.PostDto, error) {
+	var post dto.PostDto
+	query, _, _ := goqu.
+		Select("id", "user_email", "place_id", "title", "status", "listing_type", "loan_period_days").
+		From("posts").
+		Where(goqu.C("id").Eq(id)).
+		ForUpdate(exp.Wait).
+		ToSQL()
+	err := r.db.QueryRowContext(ctx, query).
+		Scan(&post.ID, &post.UserEmail, &post.PlaceID, &post.Title, &post.Status, &post.ListingType, &post.LoanPeriodDays)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		r.logger.Error(
+			"Post Repository Error",
+			zap.String("method", "GetForUpdate"),
+			zap.String("error", err.Error()),
+		)
+		return nil, err
+	}
 	return &post, nil
 }
 
@@ -140,39 +179,135 @@ func (r *PostRepository) Delete(ctx context.Context, id int64) error {
 	return nil
 }
 
+const (
+	PostSortNewest = "newest"
+	PostSortOldest = "oldest"
+	PostSortTitle  = "title"
+	// by the average rating of the post owner
+	PostSortRating = "rating"
+)
+
+// PostSortCursorKey returns the type of the cursor key of available posts in
+// the sort order.
+func PostSortCursorKey(sort string) dto.CursorKey {
+	switch sort {
+	case PostSortTitle:
+		return dto.CursorKeyText
+	case PostSortRating:
+		return dto.CursorKeyNumber
+	default:
+		return dto.CursorKeyTime
+	}
+}
+
 type PostFilterOptions struct {
-	Genre           string
+	Genres          []string
 	Condition       string
 	PublicationYear string
-	PlaceID         int64
+	YearFrom        int
+	YearTo          int
+	PagesFrom       int
+	PagesTo         int
+	PlaceIDs        []int64
+	Publisher       string
+	Cover           string
+	City            string
+	ListingType     string
+	Sort            string
+}
+
+// the average is a double precision number, so that it is the same after a
+// round trip through the cursor
+const ownerRatingQuery = "(SELECT COALESCE(AVG(reviews.rating), 0)::float8 FROM reviews " +
+	"WHERE reviews.target_user_email = posts.user_email AND reviews.is_hidden = false AND reviews.rating > 0)"
+
+// escapeLike escapes the wildcards of a LIKE pattern, so that the value only
+// matches itself.
+func escapeLike(value string) string {
+	return likeEscaper.Replace(value)
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// filterAvailable applies the filters to a dataset selecting available posts
+// of other users.
+func filterAvailable(query *goqu.SelectDataset, userEmail string, options PostFilterOptions) *goqu.SelectDataset {
+	query = query.
+		Where(goqu.I("posts.status").Eq("available")).
+		Where(goqu.I("posts.user_email").Neq(userEmail))
+	if len(options.Genres) != 0 {
+		query = query.Where(goqu.I("posts.genre").In(options.Genres))
+	}
+	if options.Condition != "" {
+		query = query.Where(goqu.I("posts.condition").Eq(options.Condition))
+	}
+	if options.PublicationYear != "" {
+		query = query.Where(goqu.I("posts.publication_year").Eq(options.PublicationYear))
+	}
+	if options.YearFrom != 0 {
+		query = query.Where(goqu.I("posts.publication_year").Gte(options.YearFrom))
+	}
+	if options.YearTo != 0 {
+		query = query.Where(goqu.I("posts.publication_year").Lte(options.YearTo))
+	}
+	if options.PagesFrom != 0 {
+		query = query.Where(goqu.I("posts.pages_count").Gte(options.PagesFrom))
+	}
+	if options.PagesTo != 0 {
+		query = query.Where(goqu.I("posts.pages_count").Lte(options.PagesTo))
+	}
+	if len(options.PlaceIDs) != 0 {
+		query = query.Where(goqu.I("posts.place_id").In(options.PlaceIDs))
+	}
+	if options.Publisher != "" {
+		query = query.Where(goqu.I("posts.publisher").ILike(escapeLike(options.Publisher)))
+	}
+	if options.Cover != "" {
+		query = query.Where(goqu.I("posts.cover").Eq(options.Cover))
+	}
+	if options.ListingType != "" {
+		query = query.Where(goqu.I("posts.listing_type").Eq(options.ListingType))
+	}
+	if options.City != "" {
+		query = query.Where(goqu.I("posts.place_id").In(
+			goqu.From("places").Select("id").Where(goqu.C("city").Eq(options.City)),
+		))
+	}
+	return query
+}
+
+func postCreatedAtCursor(p *dto.PostDto) dto.Cursor {
+	return dto.Cursor{Key: p.CreatedAt, ID: p.ID}
 }
 
 // TODO таргетинг
-func (r *PostRepository) GetAllAvailable(ctx context.Context, userEmail string, options PostFilterOptions, limit, offset uint) (*[]dto.PostDto, error) {
+func (r *PostRepository) GetAllAvailable(ctx context.Context, userEmail string, options PostFilterOptions, page dto.PageRequest) (*dto.Page[dto.PostDto], error) {
 	var posts []dto.PostDto
-	query := goqu.
+	query := filterAvailable(goqu.
 		Select(
 			"posts.*",
 			goqu.L("(SELECT COUNT(*) > 0 FROM favorites WHERE favorites.post_id = posts.id AND favorites.user_email = ?) AS is_favourite", userEmail),
+			goqu.L(ownerRatingQuery).As("owner_rating"),
 		).
-		From("posts").
-		Where(goqu.C("status").Eq("available")).
-		Where(goqu.I("posts.user_email").Neq(userEmail)).
-		GroupBy("posts.id")
-	if options.Genre != "" {
-		query = query.Where(goqu.C("genre").Eq(options.Genre))
-	}
-	if options.Condition != "" {
-		query = query.Where(goqu.C("condition").Eq(options.Condition))
-	}
-	if options.PublicationYear != "" {
-		query = query.Where(goqu.C("publication_year").Eq(options.PublicationYear))
-	}
-	if options.PlaceID != 0 {
-		query = query.Where(goqu.C("place_id").Eq(options.PlaceID))
+		From("posts"), userEmail, options)
+	var cursorOf func(*dto.PostDto) dto.Cursor
+	switch options.Sort {
+	case PostSortOldest:
+		query = paginate(query, goqu.I("posts.created_at"), goqu.I("posts.id"), page, false)
+		cursorOf = postCreatedAtCursor
+	case PostSortTitle:
+		query = paginate(query, goqu.L("coalesce(posts.title, '')"), goqu.I("posts.id"), page, false)
+		cursorOf = func(p *dto.PostDto) dto.Cursor { return dto.Cursor{Key: p.Title, ID: p.ID} }
+	case PostSortRating:
+		query = paginate(query, goqu.L(ownerRatingQuery), goqu.I("posts.id"), page, true)
+		cursorOf = func(p *dto.PostDto) dto.Cursor {
+			return dto.Cursor{Key: strconv.FormatFloat(p.OwnerRating, 'g', -1, 64), ID: p.ID}
+		}
+	default:
+		query = paginate(query, goqu.I("posts.created_at"), goqu.I("posts.id"), page, true)
+		cursorOf = postCreatedAtCursor
 	}
-	query = query.Order(goqu.I("posts.created_at").Desc())
-	sql, args, err := query.Limit(limit).Offset(offset).ToSQL()
+	sql, args, err := query.ToSQL()
 	if err != nil {
 		r.logger.Error(
 			"Post Repository Error",
@@ -200,7 +335,8 @@ func (r *PostRepository) GetAllAvailable(ctx context.Context, userEmail string,
 			&post.ID, &images, &post.UserEmail,
 			&post.PlaceID, &post.Title, &post.Description, &post.Genre,
 			&post.Author, &post.PublicationYear, &post.Publisher,
-			&post.Condition, &post.Status, &post.CreatedAt, &post.Cover, &post.PagesCount, &post.Summary, &post.Quote, &post.IsFavorite); err != nil {
+			&post.Condition, &post.Status, &post.CreatedAt, &post.Cover, &post.PagesCount, &post.Summary, &post.Quote, &post.ListingType, &post.LoanPeriodDays, &post.IsFavorite,
+			&post.OwnerRating); err != nil {
 			return nil, err
 		}
 		if images == nil {
@@ -208,35 +344,196 @@ func (r *PostRepository) GetAllAvailable(ctx context.Context, userEmail string,
 		} else {
 			post.Images = images
 		}
+		hideOwnerEmail(&post, userEmail)
 		posts = append(posts, post)
 	}
 
-	return &posts, nil
+	return dto.NewPage(posts, page.Limit, cursorOf), nil
 }
 
-func (r *PostRepository) SearchByTitleOrAuthorOrGenre(ctx context.Context, titleOrAuthorOrGenre string, limit, offset uint, userEmail string) (*[]dto.PostDto, error) {
+// GetAvailableFacets counts available posts by genre, condition, cover, place
+// and city. Counts of each facet ignore the filter on that facet, so that the
+// other values of it can still be chosen.
+func (r *PostRepository) GetAvailableFacets(ctx context.Context, userEmail string, options PostFilterOptions) (*dto.PostFacetsDto, error) {
+	var facets dto.PostFacetsDto
+	var err error
+
+	withoutGenres := options
+	withoutGenres.Genres = nil
+	if facets.Genres, err = r.countFacet(ctx, userEmail, withoutGenres, goqu.I("posts.genre"), goqu.I("posts.genre")); err != nil {
+		return nil, err
+	}
+
+	withoutCondition := options
+	withoutCondition.Condition = ""
+	if facets.Conditions, err = r.countFacet(ctx, userEmail, withoutCondition, goqu.I("posts.condition"), goqu.I("posts.condition")); err != nil {
+		return nil, err
+	}
+
+	withoutCover := options
+	withoutCover.Cover = ""
+	if facets.Covers, err = r.countFacet(ctx, userEmail, withoutCover, goqu.I("posts.cover"), goqu.I("posts.cover")); err != nil {
+		return nil, err
+	}
+
+	withoutPlaces := options
+	withoutPlaces.PlaceIDs = nil
+	if facets.Places, err = r.countFacet(ctx, userEmail, withoutPlaces, goqu.L("posts.place_id::text"), goqu.I("places.name")); err != nil {
+		return nil, err
+	}
+
+	withoutCity := options
+	withoutCity.City = ""
+	if facets.Cities, err = r.countFacet(ctx, userEmail, withoutCity, goqu.I("places.city"), goqu.I("places.city")); err != nil {
+		return nil, err
+	}
+
+	return &facets, nil
+}
+
+func (r *PostRepository) countFacet(
+	ctx context.Context, userEmail string, options PostFilterOptions, value exp.Expression, label exp.Expression,
+) ([]dto.FacetValueDto, error) {
+	values := []dto.FacetValueDto{}
+	query, _, err := filterAvailable(goqu.
+		Select(
+			goqu.L("COALESCE(?, '')", value),
+			goqu.L("COALESCE(?, '')", label),
+			goqu.COUNT("*").As("count"),
+		).
+		From("posts").
+		LeftJoin(
+			goqu.T("places"),
+			goqu.On(goqu.I("posts.place_id").Eq(goqu.I("places.id"))),
+		), userEmail, options).
+		GroupBy(goqu.L("1"), goqu.L("2")).
+		Order(goqu.C("count").Desc(), goqu.L("1").Asc()).
+		ToSQL()
+	if err != nil {
+		r.logger.Error(
+			"Post Repository Error",
+			zap.String("method", "GetAvailableFacets"),
+			zap.String("error", err.Error()),
+		)
+		return nil, err
+	}
+
+	rows, err := r.db.Query(query)
+	if err != nil {
+		r.logger.Error(
+			"Post Repository Error",
+			zap.String("method", "GetAvailableFacets"),
+			zap.String("error", err.Error()),
+		)
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var facet dto.FacetValueDto
+		if err := rows.Scan(&facet.Value, &facet.Label, &facet.Count); err != nil {
+			r.logger.Error(
+				"Post Repository Error",
+				zap.String("method", "GetAvailableFacets"),
+				zap.String("error", err.Error()),
+			)
+			return nil, err
+		}
+		if facet.Value == "" {
+			continue
+		}
+		values = append(values, facet)
+	}
+
+	if err := rows.Err(); err != nil {
+		r.logger.Error(
+			"Post Repository Error",
+			zap.String("method", "GetAvailableFacets"),
+			zap.String("error", err.Error()),
+		)
+		return nil, err
+	}
+
+	return values, nil
+}
+
+const (
+	// postSearchDocument is the text indexed for full-text search, see the
+	// posts_search_*_idx indexes. The fuzzy match of the author and title
+	// uses the <% operator so that the posts_search_*_trgm_idx indexes apply.
+	postSearchDocument = "coalesce(posts.title, '') || ' ' || coalesce(posts.author, '') || ' ' || " +
+		"coalesce(posts.description, '') || ' ' || coalesce(posts.summary, '')"
+	// minimal word similarity for a misspelled author or title to match, the
+	// default threshold of <% is 0.6
+	postSearchSimilarity = 0.4
+	postSearchHeadline   = "StartSel=<b>, StopSel=</b>, MaxWords=25, MinWords=10, MaxFragments=2"
+)
+
+// SearchByTitleOrAuthorOrGenre finds available posts by full-text search in
+// russian and english and by fuzzy match of the author and title. Results are
+// ordered by relevance and have a highlighted snippet.
+func (r *PostRepository) SearchByTitleOrAuthorOrGenre(ctx context.Context, titleOrAuthorOrGenre string, page dto.PageRequest, userEmail string) (*dto.Page[dto.PostDto], error) {
 	var posts []dto.PostDto
-	query, _, _ := goqu.
+	q := titleOrAuthorOrGenre
+	relevance := goqu.L(
+		"(ts_rank(to_tsvector('russian', "+postSearchDocument+"), plainto_tsquery('russian', ?)) + "+
+			"ts_rank(to_tsvector('english', "+postSearchDocument+"), plainto_tsquery('english', ?)) + "+
+			"word_similarity(?, coalesce(posts.author, '')) + word_similarity(?, coalesce(posts.title, '')))",
+		q, q, q, q,
+	)
+	query, _, _ := paginate(goqu.
 		Select(
 			"posts.*",
 			goqu.L("(SELECT COUNT(*) > 0 FROM favorites WHERE favorites.post_id = posts.id AND favorites.user_email = ?) AS is_favourite", userEmail),
+			relevance.As("relevance"),
+			goqu.L(
+				"ts_headline('russian', concat_ws(' ', posts.title, posts.author, posts.description), plainto_tsquery('russian', ?), ?)",
+				q, postSearchHeadline,
+			).As("snippet"),
 		).
-		From("posts").Where(
-		goqu.Or(
-			goqu.C("title").ILike("%"+titleOrAuthorOrGenre+"%"),
-			goqu.C("author").ILike("%"+titleOrAuthorOrGenre+"%"),
-			goqu.C("genre").ILike("%"+titleOrAuthorOrGenre+"%"),
-		),
-	).
-		Where(goqu.And(
+		From("posts").
+		Where(
+			goqu.L(
+				"(to_tsvector('russian', "+postSearchDocument+") @@ plainto_tsquery('russian', ?) OR "+
+					"to_tsvector('english', "+postSearchDocument+") @@ plainto_tsquery('english', ?) OR "+
+					"? <% coalesce(posts.author, '') OR "+
+					"? <% coalesce(posts.title, '') OR "+
+					"posts.genre ILIKE ?)",
+				q, q, q, q, "%"+escapeLike(q)+"%",
+			),
 			goqu.C("status").Eq("available"),
 			goqu.I("posts.user_email").Neq(userEmail),
-		)).
-		GroupBy("posts.id").
-		Order(goqu.I("posts.created_at").Desc()).
-		Limit(limit).Offset(offset).ToSQL()
+		), relevance, goqu.I("posts.id"), page, true).
+		ToSQL()
 
-	rows, err := r.db.Query(query)
+	// the threshold of <% is a setting, SET LOCAL keeps it to a transaction of
+	// this search, so it doesn't depend on the pooled connection
+	db := r.db
+	if beginner, ok := r.db.(interface {
+		BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error)
+	}); ok {
+		tx, err := beginner.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+		if err != nil {
+			r.logger.Error(
+				"Post Repository Error",
+				zap.String("method", "SearchByTitleOrAuthor"),
+				zap.String("error", err.Error()),
+			)
+			return nil, err
+		}
+		defer tx.Rollback()
+		db = tx
+	}
+	if _, err := db.ExecContext(ctx, fmt.Sprintf("SET LOCAL pg_trgm.word_similarity_threshold = %g", postSearchSimilarity)); err != nil {
+		r.logger.Error(
+			"Post Repository Error",
+			zap.String("method", "SearchByTitleOrAuthor"),
+			zap.String("error", err.Error()),
+		)
+		return nil, err
+	}
+
+	rows, err := db.QueryContext(ctx, query)
 	if err != nil {
 		r.logger.Error(
 			"Post Repository Error",
@@ -255,7 +552,8 @@ func (r *PostRepository) SearchByTitleOrAuthorOrGenre(ctx context.Context, title
 			&post.PlaceID, &post.Title, &post.Description, &post.Genre,
 			&post.Author, &post.PublicationYear, &post.Publisher,
 			&post.Condition, &post.Status, &post.CreatedAt,
-			&post.Cover, &post.PagesCount, &post.Summary, &post.Quote, &post.IsFavorite); err != nil {
+			&post.Cover, &post.PagesCount, &post.Summary, &post.Quote, &post.ListingType, &post.LoanPeriodDays, &post.IsFavorite,
+			&post.Relevance, &post.Snippet); err != nil {
 			return nil, err
 		}
 		if images == nil {
@@ -263,53 +561,29 @@ func (r *PostRepository) SearchByTitleOrAuthorOrGenre(ctx context.Context, title
 		} else {
 			post.Images = images
 		}
+		hideOwnerEmail(&post, userEmail)
 		posts = append(posts, post)
 	}
-	return &posts, nil
+	return dto.NewPage(posts, page.Limit, func(p *dto.PostDto) dto.Cursor {
+		// relevance is a real number, its shortest form parses back to the same value
+		return dto.Cursor{Key: strconv.FormatFloat(p.Relevance, 'g', -1, 32), ID: p.ID}
+	}), nil
 }
 
-func (r *PostRepository) GetAllMyPosted(ctx context.Context, userEmail string, status string, limit, offset uint) (*[]dto.PostDto, error) {
+func (r *PostRepository) GetAllMyPosted(ctx context.Context, userEmail string, status string, page dto.PageRequest) (*dto.Page[dto.PostDto], error) {
 	var posts []dto.PostDto
-	// query, _, _ := goqu.
-	// 	Select(
-	// 		"posts.*",
-	// 		goqu.L("(SELECT COUNT(*) > 0 FROM favorites WHERE favorites.post_id = posts.id AND favorites.user_email = ?) AS is_favourite", userEmail),
-	// 	).
-	// 	From("posts").Where(goqu.Ex{
-	// 	"posts.user_email": userEmail,
-	// 	"status":           status,
-	// }).
-	// 	GroupBy("posts.id").
-	// 	Order(goqu.I("posts.created_at").Desc()).
-	// 	Limit(uint(limit)).Offset(uint(offset)).ToSQL()
-
-	var query string
+	where := goqu.Ex{"posts.user_email": userEmail}
 	if status != "all" {
-		query, _, _ = goqu.
-			Select(
-				"posts.*",
-				goqu.L("(SELECT COUNT(*) > 0 FROM favorites WHERE favorites.post_id = posts.id AND favorites.user_email = ?) AS is_favourite", userEmail),
-			).
-			From("posts").Where(goqu.Ex{
-			"posts.user_email": userEmail,
-			"status":           status,
-		}).
-			GroupBy("posts.id").
-			Order(goqu.I("posts.created_at").Desc()).
-			Limit(uint(limit)).Offset(uint(offset)).ToSQL()
-	} else {
-		query, _, _ = goqu.
-			Select(
-				"posts.*",
-				goqu.L("(SELECT COUNT(*) > 0 FROM favorites WHERE favorites.post_id = posts.id AND favorites.user_email = ?) AS is_favourite", userEmail),
-			).
-			From("posts").Where(goqu.Ex{
-			"posts.user_email": userEmail,
-		}).
-			GroupBy("posts.id").
-			Order(goqu.I("posts.created_at").Desc()).
-			Limit(uint(limit)).Offset(uint(offset)).ToSQL()
+		where["status"] = status
 	}
+	query, _, _ := paginate(goqu.
+		Select(
+			"posts.*",
+			goqu.L("(SELECT COUNT(*) > 0 FROM favorites WHERE favorites.post_id = posts.id AND favorites.user_email = ?) AS is_favourite", userEmail),
+		).
+		From("posts").
+		Where(where), goqu.I("posts.created_at"), goqu.I("posts.id"), page, true).
+		ToSQL()
 
 	rows, err := r.db.Query(query)
 	if err != nil {
@@ -330,7 +604,7 @@ func (r *PostRepository) GetAllMyPosted(ctx context.Context, userEmail string, s
 			&post.PlaceID, &post.Title, &post.Description, &post.Genre,
 			&post.Author, &post.PublicationYear, &post.Publisher,
 			&post.Condition, &post.Status, &post.CreatedAt,
-			&post.Cover, &post.PagesCount, &post.Summary, &post.Quote, &post.IsFavorite); err != nil {
+			&post.Cover, &post.PagesCount, &post.Summary, &post.Quote, &post.ListingType, &post.LoanPeriodDays, &post.IsFavorite); err != nil {
 			r.logger.Error(
 				"Post Repository Error",
 				zap.String("method", "GetAllMyPosted"),
@@ -343,6 +617,7 @@ func (r *PostRepository) GetAllMyPosted(ctx context.Context, userEmail string, s
 		} else {
 			post.Images = images
 		}
+		hideOwnerEmail(&post, userEmail)
 		posts = append(posts, post)
 	}
 
@@ -355,7 +630,7 @@ func (r *PostRepository) GetAllMyPosted(ctx context.Context, userEmail string, s
 		return nil, err
 	}
 
-	return &posts, nil
+	return dto.NewPage(posts, page.Limit, postCreatedAtCursor), nil
 }
 
 func (r *PostRepository) AddFavorite(ctx context.Context, postID int64, userEmail string) error {
@@ -401,9 +676,9 @@ func (r *PostRepository) DeleteFavorite(ctx context.Context, postID int64, userE
 	return nil
 }
 
-func (r *PostRepository) GetAllMyBooked(ctx context.Context, userEmail string, limit, offset uint) (*[]dto.PostDto, error) {
+func (r *PostRepository) GetAllMyBooked(ctx context.Context, userEmail string, page dto.PageRequest) (*dto.Page[dto.PostDto], error) {
 	var posts []dto.PostDto
-	query, _, err := goqu.
+	query, _, err := paginate(goqu.
 		Select(
 			"posts.*",
 			goqu.L("(SELECT COUNT(*) > 0 FROM favorites WHERE favorites.post_id = posts.id AND favorites.user_email = ?) AS is_favourite", userEmail),
@@ -415,10 +690,11 @@ func (r *PostRepository) GetAllMyBooked(ctx context.Context, userEmail string, l
 				goqu.I("posts.id").Eq(goqu.I("bookings.post_id")),
 			),
 		).
-		Where(goqu.Ex{"bookings.user_email": userEmail}).
-		GroupBy("posts.id").
-		Limit(limit).
-		Offset(offset).
+		Where(goqu.Ex{
+			"bookings.user_email": userEmail,
+			"bookings.status":     activeBookingStatuses,
+		}).
+		GroupBy("posts.id"), goqu.I("posts.created_at"), goqu.I("posts.id"), page, true).
 		ToSQL()
 	if err != nil {
 		r.logger.Error(
@@ -447,7 +723,7 @@ func (r *PostRepository) GetAllMyBooked(ctx context.Context, userEmail string, l
 			&post.ID, &images, &post.UserEmail, &post.PlaceID,
 			&post.Title, &post.Description, &post.Genre, &post.Author, &post.PublicationYear,
 			&post.Publisher, &post.Condition, &post.Status, &post.CreatedAt,
-			&post.Cover, &post.PagesCount, &post.Summary, &post.Quote, &post.IsFavorite); err != nil {
+			&post.Cover, &post.PagesCount, &post.Summary, &post.Quote, &post.ListingType, &post.LoanPeriodDays, &post.IsFavorite); err != nil {
 			r.logger.Error(
 				"Post Repository Error",
 				zap.String("method", "GetAllMyBooked"),
@@ -460,6 +736,7 @@ func (r *PostRepository) GetAllMyBooked(ctx context.Context, userEmail string, l
 		} else {
 			post.Images = images
 		}
+		hideOwnerEmail(&post, userEmail)
 		posts = append(posts, post)
 	}
 
@@ -472,17 +749,17 @@ func (r *PostRepository) GetAllMyBooked(ctx context.Context, userEmail string, l
 		return nil, err
 	}
 
-	return &posts, nil
+	return dto.NewPage(posts, page.Limit, postCreatedAtCursor), nil
 }
 
 func (r *PostRepository) GetAllMyTaken(ctx context.Context, userEmail string) {
 
 }
 
-func (r *PostRepository) GetAllFavourite(ctx context.Context, userEmail string, limit, offset uint) (*[]dto.PostDto, error) {
+func (r *PostRepository) GetAllFavourite(ctx context.Context, userEmail string, page dto.PageRequest) (*dto.Page[dto.PostDto], error) {
 	var posts []dto.PostDto
 	isFavoriteQuery := "(SELECT COUNT(id) > 0 FROM favorites WHERE favorites.post_id = posts.id AND favorites.user_email = ?)"
-	query, _, err := goqu.
+	query, _, err := paginate(goqu.
 		Select(
 			"posts.*",
 			goqu.L(isFavoriteQuery+" AS is_favourite", userEmail),
@@ -491,9 +768,7 @@ func (r *PostRepository) GetAllFavourite(ctx context.Context, userEmail string,
 			goqu.L(isFavoriteQuery, userEmail).Eq(true),
 		).
 		Where(goqu.I("posts.status").Eq("available")).
-		From("posts").
-		Limit(limit).
-		Offset(offset).
+		From("posts"), goqu.I("posts.created_at"), goqu.I("posts.id"), page, true).
 		ToSQL()
 	if err != nil {
 		r.logger.Error(
@@ -522,7 +797,7 @@ func (r *PostRepository) GetAllFavourite(ctx context.Context, userEmail string,
 			&post.ID, &images, &post.UserEmail, &post.PlaceID,
 			&post.Title, &post.Description, &post.Genre, &post.Author, &post.PublicationYear,
 			&post.Publisher, &post.Condition, &post.Status, &post.CreatedAt,
-			&post.Cover, &post.PagesCount, &post.Summary, &post.Quote, &post.IsFavorite); err != nil {
+			&post.Cover, &post.PagesCount, &post.Summary, &post.Quote, &post.ListingType, &post.LoanPeriodDays, &post.IsFavorite); err != nil {
 			r.logger.Error(
 				"Post Repository Error",
 				zap.String("method", "GetFavoritePosts"),
@@ -535,6 +810,7 @@ func (r *PostRepository) GetAllFavourite(ctx context.Context, userEmail string,
 		} else {
 			post.Images = images
 		}
+		hideOwnerEmail(&post, userEmail)
 		posts = append(posts, post)
 	}
 
@@ -547,7 +823,7 @@ func (r *PostRepository) GetAllFavourite(ctx context.Context, userEmail string,
 		return nil, err
 	}
 
-	return &posts, nil
+	return dto.NewPage(posts, page.Limit, postCreatedAtCursor), nil
 }
 
 func (r *PostRepository) AddImage(
@@ -594,6 +870,157 @@ func (r *PostRepository) UpdateImageURL(ctx context.Context, postID int64, image
 	return nil
 }
 
+// SetImages replaces the images of the post, the first image is the primary one.
+func (r *PostRepository) SetImages(ctx context.Context, postID int64, images []string) error {
+	query, _, err := goqu.
+		Update("posts").
+		Set(goqu.Record{"images": pq.StringArray(images)}).
+		Where(goqu.Ex{"id": postID}).
+		ToSQL()
+
+	if err != nil {
+		r.logger.Error(
+			"Post Repository Error",
+			zap.String("method", "SetImages"),
+			zap.String("error", err.Error()),
+		)
+		return err
+	}
+	_, err = r.db.Exec(query)
+	if err != nil {
+		r.logger.Error(
+			"Post Repository Error",
+			zap.String("method", "SetImages"),
+			zap.String("error", err.Error()),
+		)
+		return err
+	}
+	return nil
+}
+
+// DeleteImage removes the image uploaded by AddImage from the storage.
+func (r *PostRepository) DeleteImage(ctx context.Context, imageURL string) error {
+	err := r.s3.DeleteFile(ctx, "posts", path.Base(imageURL))
+	if err != nil {
+		r.logger.Error(
+			"Post Repository Error",
+			zap.String("method", "DeleteImage"),
+			zap.String("error", err.Error()),
+		)
+		return err
+	}
+	return nil
+}
+
+// GetImagesByUser returns images of all posts of the user.
+func (r *PostRepository) GetImagesByUser(ctx context.Context, userEmail string) ([]string, error) {
+	query, _, err := goqu.From("posts").
+		Select(goqu.L("unnest(images)")).
+		Where(goqu.Ex{"user_email": userEmail}).
+		ToSQL()
+	if err != nil {
+		r.logger.Error(
+			"Post Repository Error",
+			zap.String("method", "GetImagesByUser"),
+			zap.String("error", err.Error()),
+		)
+		return nil, err
+	}
+
+	rows, err := r.db.Query(query)
+	if err != nil {
+		r.logger.Error(
+			"Post Repository Error",
+			zap.String("method", "GetImagesByUser"),
+			zap.String("error", err.Error()),
+		)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var images []string
+	for rows.Next() {
+		var image string
+		if err := rows.Scan(&image); err != nil {
+			r.logger.Error(
+				"Post Repository Error",
+				zap.String("method", "GetImagesByUser"),
+				zap.String("error", err.Error()),
+			)
+			return nil, err
+		}
+		images = append(images, image)
+	}
+
+	if err := rows.Err(); err != nil {
+		r.logger.Error(
+			"Post Repository Error",
+			zap.String("method", "GetImagesByUser"),
+			zap.String("error", err.Error()),
+		)
+		return nil, err
+	}
+	return images, nil
+}
+
+// GetIDsByUser returns ids of all posts of the user in ascending order.
+func (r *PostRepository) GetIDsByUser(ctx context.Context, userEmail string) ([]int64, error) {
+	query, _, _ := goqu.From("posts").
+		Select("id").
+		Where(goqu.Ex{"user_email": userEmail}).
+		Order(goqu.C("id").Asc()).
+		ToSQL()
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		r.logger.Error(
+			"Post Repository Error",
+			zap.String("method", "GetIDsByUser"),
+			zap.String("error", err.Error()),
+		)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			r.logger.Error(
+				"Post Repository Error",
+				zap.String("method", "GetIDsByUser"),
+				zap.String("error", err.Error()),
+			)
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	if err := rows.Err(); err != nil {
+		r.logger.Error(
+			"Post Repository Error",
+			zap.String("method", "GetIDsByUser"),
+			zap.String("error", err.Error()),
+		)
+		return nil, err
+	}
+	return ids, nil
+}
+
+// DownloadImage returns the content of the image uploaded by AddImage.
+func (r *PostRepository) DownloadImage(ctx context.Context, imageURL string) ([]byte, error) {
+	data, err := r.s3.DownloadFile(ctx, "posts", path.Base(imageURL))
+	if err != nil {
+		r.logger.Error(
+			"Post Repository Error",
+			zap.String("method", "DownloadImage"),
+			zap.String("error", err.Error()),
+		)
+		return nil, err
+	}
+	return data, nil
+}
+
 func (r *PostRepository) SetSummary(ctx context.Context, postID int64, summary string) error {
 	query, _, err := goqu.
 		Update("posts").
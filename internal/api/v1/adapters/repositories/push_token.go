@@ -22,7 +22,7 @@ func NewPushTokenRepository(rdb *redis.Client, logger *zap.Logger) *PushTokenRep
 }
 
 func (r *PushTokenRepository) Set(ctx *context.Context, email string, token string) error {
-	err := r.rdb.Set(*ctx, email+"_push", token, config.Config.JWTExpiration).Err()
+	err := r.rdb.Set(*ctx, email+"_push", token, config.Config.RefreshTokenExpiration).Err()
 	r.logger.Info(
 		"Psuh Token Repository",
 		zap.String("method", "Set"),
@@ -3,6 +3,7 @@ package repositories
 import (
 	"context"
 	"database/sql"
+	"errors"
 
 	"example.com/m/internal/api/v1/core/application/dto"
 	"github.com/doug-martin/goqu/v9"
@@ -41,25 +42,129 @@ func (r *ReviewRepository) Create(ctx context.Context, review *dto.ReviewWithout
 	return nil
 }
 
-func (r *ReviewRepository) GetReviewsForUser(ctx context.Context, targetUserEmail string, limit, offset uint) (*[]dto.ReviewToGetDto, error) {
+func (r *ReviewRepository) Get(ctx context.Context, id int64) (*dto.ReviewDto, error) {
+	var review dto.ReviewDto
+	query, _, _ := goqu.
+		Select(
+			"id", goqu.COALESCE(goqu.I("target_user_email"), ""),
+			goqu.COALESCE(goqu.I("reviewer_user_email"), ""), "rating", "comment",
+			"created_at", "exchange_id", "updated_at", "is_hidden",
+		).
+		From("reviews").
+		Where(goqu.Ex{"id": id}).
+		ToSQL()
+	err := r.db.QueryRow(query).Scan(
+		&review.ID, &review.TargetUserEmail, &review.ReviewerUserEmail, &review.Rating, &review.Comment,
+		&review.CreatedAt, &review.ExchangeID, &review.UpdatedAt, &review.IsHidden)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		r.logger.Error(
+			"Review Repository Error",
+			zap.String("method", "Get"),
+			zap.String("error", err.Error()),
+		)
+		return nil, err
+	}
+	return &review, nil
+}
+
+// ExistsForExchange reports whether the reviewer has already reviewed the exchange.
+func (r *ReviewRepository) ExistsForExchange(ctx context.Context, exchangeID int64, reviewerEmail string) (bool, error) {
+	var exists bool
+	query, _, _ := goqu.
+		Select(goqu.L("COUNT(*) > 0")).
+		From("reviews").
+		Where(goqu.Ex{
+			"exchange_id":         exchangeID,
+			"reviewer_user_email": reviewerEmail,
+		}).
+		ToSQL()
+	err := r.db.QueryRow(query).Scan(&exists)
+	if err != nil {
+		r.logger.Error(
+			"Review Repository Error",
+			zap.String("method", "ExistsForExchange"),
+			zap.String("error", err.Error()),
+		)
+		return false, err
+	}
+	return exists, nil
+}
+
+func (r *ReviewRepository) Update(ctx context.Context, id int64, review *dto.UpdateReviewDto) error {
+	query, _, _ := goqu.Update("reviews").Set(*review).Where(goqu.Ex{"id": id}).ToSQL()
+	_, err := r.db.Exec(query)
+	if err != nil {
+		r.logger.Error(
+			"Review Repository Error",
+			zap.String("method", "Update"),
+			zap.String("error", err.Error()),
+		)
+		return err
+	}
+	return nil
+}
+
+func (r *ReviewRepository) SetHidden(ctx context.Context, id int64, hidden bool) error {
+	query, _, _ := goqu.Update("reviews").Set(goqu.Record{"is_hidden": hidden}).Where(goqu.Ex{"id": id}).ToSQL()
+	_, err := r.db.Exec(query)
+	if err != nil {
+		r.logger.Error(
+			"Review Repository Error",
+			zap.String("method", "SetHidden"),
+			zap.String("error", err.Error()),
+		)
+		return err
+	}
+	return nil
+}
+
+func (r *ReviewRepository) Delete(ctx context.Context, id int64) error {
+	query, _, _ := goqu.Delete("reviews").Where(goqu.Ex{"id": id}).ToSQL()
+	_, err := r.db.Exec(query)
+	if err != nil {
+		r.logger.Error(
+			"Review Repository Error",
+			zap.String("method", "Delete"),
+			zap.String("error", err.Error()),
+		)
+		return err
+	}
+	return nil
+}
+
+// GetReviewsForUser returns visible reviews of the user, newest first.
+func (r *ReviewRepository) GetReviewsForUser(ctx context.Context, targetUserEmail string, page dto.PageRequest) (*dto.Page[dto.ReviewToGetDto], error) {
 	var reviews []dto.ReviewToGetDto
-	query, _, err := goqu.
+	query, _, err := paginate(goqu.
 		Select(
-			"reviews.*",
-			goqu.I("users.username").As("reviewer_username"),
+			"reviews.id", "reviews.target_user_email",
+			goqu.COALESCE(goqu.I("reviews.reviewer_user_email"), ""),
+			"reviews.rating", "reviews.comment", "reviews.created_at",
+			"reviews.exchange_id", "reviews.updated_at",
+			goqu.I("targets.username").As("target_username"),
+			// empty for reviews of deleted users
+			goqu.COALESCE(goqu.I("reviewers.username"), "").As("reviewer_username"),
 		).
 		From("reviews").
 		Join(
-			goqu.T("users"),
+			goqu.T("users").As("targets"),
 			goqu.On(
-				goqu.I("reviews.reviewer_user_email").Eq(goqu.I("users.email")),
+				goqu.I("reviews.target_user_email").Eq(goqu.I("targets.email")),
+			),
+		).
+		LeftJoin(
+			goqu.T("users").As("reviewers"),
+			goqu.On(
+				goqu.I("reviews.reviewer_user_email").Eq(goqu.I("reviewers.email")),
 			),
 		).
 		Where(goqu.Ex{
 			"reviews.target_user_email": targetUserEmail,
-		}).
-		Limit(limit).
-		Offset(offset).
+			"reviews.is_hidden":         false,
+		}), goqu.I("reviews.created_at"), goqu.I("reviews.id"), page, true).
 		ToSQL()
 	if err != nil {
 		r.logger.Error(
@@ -85,7 +190,8 @@ func (r *ReviewRepository) GetReviewsForUser(ctx context.Context, targetUserEmai
 		var review dto.ReviewToGetDto
 		if err := rows.Scan(
 			&review.ID, &review.TargetUserEmail, &review.ReviewerUserEmail, &review.Rating,
-			&review.Comment, &review.CreatedAt, &review.ReviewerUsername); err != nil {
+			&review.Comment, &review.CreatedAt, &review.ExchangeID, &review.UpdatedAt,
+			&review.TargetUsername, &review.ReviewerUsername); err != nil {
 			r.logger.Error(
 				"Review Repository Error",
 				zap.String("method", "GetReviewsForUser"),
@@ -105,5 +211,7 @@ func (r *ReviewRepository) GetReviewsForUser(ctx context.Context, targetUserEmai
 		return nil, err
 	}
 
-	return &reviews, nil
+	return dto.NewPage(reviews, page.Limit, func(review *dto.ReviewToGetDto) dto.Cursor {
+		return dto.Cursor{Key: review.CreatedAt, ID: int64(review.ID)}
+	}), nil
 }
@@ -0,0 +1,133 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+
+	"example.com/m/internal/api/v1/core/application/dto"
+	"github.com/doug-martin/goqu/v9"
+	"go.uber.org/zap"
+)
+
+type RoleRepository struct {
+	db     *sql.DB
+	logger *zap.Logger
+}
+
+func NewRoleRepository(db *sql.DB, logger *zap.Logger) *RoleRepository {
+	return &RoleRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+func (r *RoleRepository) GetByEmail(ctx context.Context, email string) ([]dto.RoleDto, error) {
+	query, _, err := goqu.From("user_roles").
+		Select("id", "user_email", "role", "place_id", goqu.COALESCE(goqu.I("granted_by"), ""), "created_at").
+		Where(goqu.Ex{"user_email": email}).
+		Order(goqu.I("id").Asc()).
+		ToSQL()
+	if err != nil {
+		r.logger.Error(
+			"Role Repository Error",
+			zap.String("method", "GetByEmail"),
+			zap.String("error", err.Error()),
+		)
+		return nil, err
+	}
+
+	rows, err := r.db.Query(query)
+	if err != nil {
+		r.logger.Error(
+			"Role Repository Error",
+			zap.String("method", "GetByEmail"),
+			zap.String("error", err.Error()),
+		)
+		return nil, err
+	}
+	defer rows.Close()
+
+	roles := []dto.RoleDto{}
+	for rows.Next() {
+		var role dto.RoleDto
+		if err := rows.Scan(&role.ID, &role.UserEmail, &role.Role, &role.PlaceID, &role.GrantedBy, &role.CreatedAt); err != nil {
+			r.logger.Error(
+				"Role Repository Error",
+				zap.String("method", "GetByEmail"),
+				zap.String("error", err.Error()),
+			)
+			return nil, err
+		}
+		roles = append(roles, role)
+	}
+
+	if err := rows.Err(); err != nil {
+		r.logger.Error(
+			"Role Repository Error",
+			zap.String("method", "GetByEmail"),
+			zap.String("error", err.Error()),
+		)
+		return nil, err
+	}
+	return roles, nil
+}
+
+// Create grants the role. Granting a role the user already has is a no-op.
+func (r *RoleRepository) Create(ctx context.Context, role *dto.RoleDto) error {
+	query, _, err := goqu.Insert("user_roles").Rows(*role).OnConflict(goqu.DoNothing()).ToSQL()
+	if err != nil {
+		r.logger.Error(
+			"Role Repository Error",
+			zap.String("method", "Create"),
+			zap.String("error", err.Error()),
+		)
+		return err
+	}
+
+	if _, err := r.db.Exec(query); err != nil {
+		r.logger.Error(
+			"Role Repository Error",
+			zap.String("method", "Create"),
+			zap.String("error", err.Error()),
+		)
+		return err
+	}
+	return nil
+}
+
+// Delete revokes the role and reports whether the user had it.
+func (r *RoleRepository) Delete(ctx context.Context, email string, role string, placeID *int64) (bool, error) {
+	where := goqu.Ex{"user_email": email, "role": role, "place_id": nil}
+	if placeID != nil {
+		where["place_id"] = *placeID
+	}
+	query, _, err := goqu.Delete("user_roles").Where(where).ToSQL()
+	if err != nil {
+		r.logger.Error(
+			"Role Repository Error",
+			zap.String("method", "Delete"),
+			zap.String("error", err.Error()),
+		)
+		return false, err
+	}
+
+	result, err := r.db.Exec(query)
+	if err != nil {
+		r.logger.Error(
+			"Role Repository Error",
+			zap.String("method", "Delete"),
+			zap.String("error", err.Error()),
+		)
+		return false, err
+	}
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		r.logger.Error(
+			"Role Repository Error",
+			zap.String("method", "Delete"),
+			zap.String("error", err.Error()),
+		)
+		return false, err
+	}
+	return deleted != 0, nil
+}
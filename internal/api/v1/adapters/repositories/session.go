@@ -0,0 +1,249 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+
+	"example.com/m/internal/api/v1/core/application/dto"
+	"example.com/m/internal/config"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+// SessionRepository keeps a hash per session under "session:<id>" and the set
+// of session ids of a user under "sessions:<email>".
+type SessionRepository struct {
+	rdb    *redis.Client
+	logger *zap.Logger
+}
+
+func NewSessionRepository(rdb *redis.Client, logger *zap.Logger) *SessionRepository {
+	return &SessionRepository{
+		rdb:    rdb,
+		logger: logger,
+	}
+}
+
+func sessionKey(id string) string {
+	return "session:" + id
+}
+
+func userSessionsKey(email string) string {
+	return "sessions:" + email
+}
+
+func (r *SessionRepository) Create(ctx *context.Context, s *dto.SessionDto) error {
+	_, err := r.rdb.TxPipelined(*ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(*ctx, sessionKey(s.ID), s)
+		pipe.Expire(*ctx, sessionKey(s.ID), config.Config.RefreshTokenExpiration)
+		pipe.SAdd(*ctx, userSessionsKey(s.Email), s.ID)
+		pipe.Expire(*ctx, userSessionsKey(s.Email), config.Config.RefreshTokenExpiration)
+		return nil
+	})
+	if err != nil {
+		r.logger.Error(
+			"Session Repository Error",
+			zap.String("method", "Create"),
+			zap.String("error", err.Error()),
+		)
+		return err
+	}
+	return nil
+}
+
+func (r *SessionRepository) Get(ctx *context.Context, id string) (*dto.SessionDto, error) {
+	cmd := r.rdb.HGetAll(*ctx, sessionKey(id))
+	if err := cmd.Err(); err != nil {
+		r.logger.Error(
+			"Session Repository Error",
+			zap.String("method", "Get"),
+			zap.String("error", err.Error()),
+		)
+		return nil, err
+	}
+	if len(cmd.Val()) == 0 {
+		return nil, nil
+	}
+
+	var s dto.SessionDto
+	if err := cmd.Scan(&s); err != nil {
+		r.logger.Error(
+			"Session Repository Error",
+			zap.String("method", "Get"),
+			zap.String("error", err.Error()),
+		)
+		return nil, err
+	}
+	return &s, nil
+}
+
+// Rotate replaces the refresh token hash of the session if it still equals
+// oldHash and prolongs the session. It returns false when the session is gone
+// or the token was already rotated by a concurrent request.
+func (r *SessionRepository) Rotate(ctx *context.Context, s *dto.SessionDto, oldHash string, newHash string) (bool, error) {
+	rotated := false
+	key := sessionKey(s.ID)
+	err := r.rdb.Watch(*ctx, func(tx *redis.Tx) error {
+		hash, err := tx.HGet(*ctx, key, "refresh_token_hash").Result()
+		if errors.Is(err, redis.Nil) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if hash != oldHash {
+			return nil
+		}
+
+		_, err = tx.TxPipelined(*ctx, func(pipe redis.Pipeliner) error {
+			pipe.HSet(*ctx, key, "refresh_token_hash", newHash)
+			pipe.Expire(*ctx, key, config.Config.RefreshTokenExpiration)
+			pipe.Expire(*ctx, userSessionsKey(s.Email), config.Config.RefreshTokenExpiration)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		rotated = true
+		return nil
+	}, key)
+	if errors.Is(err, redis.TxFailedErr) {
+		return false, nil
+	} else if err != nil {
+		r.logger.Error(
+			"Session Repository Error",
+			zap.String("method", "Rotate"),
+			zap.String("error", err.Error()),
+		)
+		return false, err
+	}
+	return rotated, nil
+}
+
+// GetAllByEmail returns active sessions of the user. Ids of sessions which
+// have expired are removed from the user's set.
+func (r *SessionRepository) GetAllByEmail(ctx *context.Context, email string) ([]dto.SessionDto, error) {
+	ids, err := r.rdb.SMembers(*ctx, userSessionsKey(email)).Result()
+	if err != nil {
+		r.logger.Error(
+			"Session Repository Error",
+			zap.String("method", "GetAllByEmail"),
+			zap.String("error", err.Error()),
+		)
+		return nil, err
+	}
+
+	cmds := make([]*redis.MapStringStringCmd, len(ids))
+	_, err = r.rdb.Pipelined(*ctx, func(pipe redis.Pipeliner) error {
+		for i, id := range ids {
+			cmds[i] = pipe.HGetAll(*ctx, sessionKey(id))
+		}
+		return nil
+	})
+	if err != nil {
+		r.logger.Error(
+			"Session Repository Error",
+			zap.String("method", "GetAllByEmail"),
+			zap.String("error", err.Error()),
+		)
+		return nil, err
+	}
+
+	sessions := []dto.SessionDto{}
+	var expired []interface{}
+	for i, cmd := range cmds {
+		if len(cmd.Val()) == 0 {
+			expired = append(expired, ids[i])
+			continue
+		}
+		var s dto.SessionDto
+		if err := cmd.Scan(&s); err != nil {
+			r.logger.Error(
+				"Session Repository Error",
+				zap.String("method", "GetAllByEmail"),
+				zap.String("error", err.Error()),
+			)
+			return nil, err
+		}
+		sessions = append(sessions, s)
+	}
+
+	if len(expired) != 0 {
+		if err := r.rdb.SRem(*ctx, userSessionsKey(email), expired...).Err(); err != nil {
+			r.logger.Error(
+				"Session Repository Error",
+				zap.String("method", "GetAllByEmail"),
+				zap.String("error", err.Error()),
+			)
+		}
+	}
+
+	return sessions, nil
+}
+
+// touchScript updates fields of a session only if it still exists, so that a
+// request racing with logout doesn't bring the session back without a TTL.
+var touchScript = redis.NewScript(`
+if redis.call("EXISTS", KEYS[1]) == 1 then
+	return redis.call("HSET", KEYS[1], unpack(ARGV))
+end
+return 0
+`)
+
+// Touch records the last activity of the session.
+func (r *SessionRepository) Touch(ctx *context.Context, id string, lastSeenAt string, ip string, userAgent string) error {
+	err := touchScript.Run(*ctx, r.rdb, []string{sessionKey(id)},
+		"last_seen_at", lastSeenAt, "ip", ip, "user_agent", userAgent,
+	).Err()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		r.logger.Error(
+			"Session Repository Error",
+			zap.String("method", "Touch"),
+			zap.String("error", err.Error()),
+		)
+		return err
+	}
+	return nil
+}
+
+func (r *SessionRepository) Delete(ctx *context.Context, email string, id string) error {
+	_, err := r.rdb.TxPipelined(*ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(*ctx, sessionKey(id))
+		pipe.SRem(*ctx, userSessionsKey(email), id)
+		return nil
+	})
+	if err != nil {
+		r.logger.Error(
+			"Session Repository Error",
+			zap.String("method", "Delete"),
+			zap.String("error", err.Error()),
+		)
+		return err
+	}
+	return nil
+}
+
+func (r *SessionRepository) DeleteAllByEmail(ctx *context.Context, email string) error {
+	ids, err := r.rdb.SMembers(*ctx, userSessionsKey(email)).Result()
+	if err != nil {
+		r.logger.Error(
+			"Session Repository Error",
+			zap.String("method", "DeleteAllByEmail"),
+			zap.String("error", err.Error()),
+		)
+		return err
+	}
+
+	keys := []string{userSessionsKey(email)}
+	for _, id := range ids {
+		keys = append(keys, sessionKey(id))
+	}
+	if err := r.rdb.Del(*ctx, keys...).Err(); err != nil {
+		r.logger.Error(
+			"Session Repository Error",
+			zap.String("method", "DeleteAllByEmail"),
+			zap.String("error", err.Error()),
+		)
+		return err
+	}
+	return nil
+}
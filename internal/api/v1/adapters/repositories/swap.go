@@ -0,0 +1,326 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"example.com/m/internal/api/v1/core/application/dto"
+	"github.com/doug-martin/goqu/v9"
+	"github.com/doug-martin/goqu/v9/exp"
+	"go.uber.org/zap"
+)
+
+type SwapRepository struct {
+	db     DBTX
+	logger *zap.Logger
+}
+
+func NewSwapRepository(db *sql.DB, logger *zap.Logger) *SwapRepository {
+	return &SwapRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// Create stores the offer together with its posts. It should be called in a
+// transaction.
+func (r *SwapRepository) Create(ctx context.Context, o *dto.SwapOfferToCreateDto) (*int64, error) {
+	var id int64
+	query, _, _ := goqu.Insert("swap_offers").Rows(o).Returning("id").ToSQL()
+	if err := r.db.QueryRowContext(ctx, query).Scan(&id); err != nil {
+		r.logger.Error(
+			"Swap Repository Error",
+			zap.String("method", "Create"),
+			zap.String("error", err.Error()),
+		)
+		return nil, err
+	}
+
+	rows := make([]interface{}, 0, len(o.Posts))
+	for _, post := range o.Posts {
+		rows = append(rows, goqu.Record{
+			"offer_id":   id,
+			"post_id":    post.PostID,
+			"post_title": post.PostTitle,
+			"side":       post.Side,
+		})
+	}
+	query, _, _ = goqu.Insert("swap_offer_posts").Rows(rows...).ToSQL()
+	if _, err := r.db.ExecContext(ctx, query); err != nil {
+		r.logger.Error(
+			"Swap Repository Error",
+			zap.String("method", "Create"),
+			zap.String("error", err.Error()),
+		)
+		return nil, err
+	}
+	return &id, nil
+}
+
+func (r *SwapRepository) Get(ctx context.Context, id int64) (*dto.SwapOfferDto, error) {
+	return r.get(ctx, "Get", id, false)
+}
+
+// GetForUpdate returns the offer and locks it until the end of the
+// transaction.
+func (r *SwapRepository) GetForUpdate(ctx context.Context, id int64) (*dto.SwapOfferDto, error) {
+	return r.get(ctx, "GetForUpdate", id, true)
+}
+
+func (r *SwapRepository) get(ctx context.Context, method string, id int64, forUpdate bool) (*dto.SwapOfferDto, error) {
+	query := offerSelect().Where(goqu.Ex{"swap_offers.id": id})
+	if forUpdate {
+		query = query.ForUpdate(exp.Wait, goqu.T("swap_offers"))
+	}
+	sqlQuery, _, _ := query.ToSQL()
+
+	var offer dto.SwapOfferDto
+	err := scanOffer(r.db.QueryRowContext(ctx, sqlQuery), &offer)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		r.logger.Error(
+			"Swap Repository Error",
+			zap.String("method", method),
+			zap.String("error", err.Error()),
+		)
+		return nil, err
+	}
+
+	offers := []dto.SwapOfferDto{offer}
+	if err := r.loadPosts(ctx, method, offers); err != nil {
+		return nil, err
+	}
+	return &offers[0], nil
+}
+
+func (r *SwapRepository) UpdateStatus(ctx context.Context, id int64, status string, updatedAt string) error {
+	query, _, _ := goqu.Update("swap_offers").
+		Set(goqu.Record{"status": status, "updated_at": updatedAt}).
+		Where(goqu.C("id").Eq(id)).
+		ToSQL()
+	if _, err := r.db.ExecContext(ctx, query); err != nil {
+		r.logger.Error(
+			"Swap Repository Error",
+			zap.String("method", "UpdateStatus"),
+			zap.String("error", err.Error()),
+		)
+		return err
+	}
+	return nil
+}
+
+// CancelPendingWithPosts cancels the pending offers other than exceptID which
+// include any of the posts, and returns them.
+func (r *SwapRepository) CancelPendingWithPosts(ctx context.Context, postIDs []int64, exceptID int64, updatedAt string) ([]dto.SwapOfferDto, error) {
+	return r.cancelPending(ctx, "CancelPendingWithPosts", updatedAt,
+		goqu.C("id").Neq(exceptID),
+		goqu.C("id").In(
+			goqu.From("swap_offer_posts").Select("offer_id").Where(goqu.Ex{"post_id": postIDs}),
+		),
+	)
+}
+
+// CancelPendingOfUser cancels the pending offers made by or to the user, and
+// returns them.
+func (r *SwapRepository) CancelPendingOfUser(ctx context.Context, email string, updatedAt string) ([]dto.SwapOfferDto, error) {
+	return r.cancelPending(ctx, "CancelPendingOfUser", updatedAt, goqu.Or(
+		goqu.C("proposer_email").Eq(email),
+		goqu.C("recipient_email").Eq(email),
+	))
+}
+
+func (r *SwapRepository) cancelPending(ctx context.Context, method string, updatedAt string, where ...exp.Expression) ([]dto.SwapOfferDto, error) {
+	query, _, _ := goqu.Update("swap_offers").
+		Set(goqu.Record{"status": dto.SwapStatusCancelled, "updated_at": updatedAt}).
+		Where(append([]exp.Expression{goqu.C("status").Eq(dto.SwapStatusPending)}, where...)...).
+		Returning("id", "proposer_email", "recipient_email").
+		ToSQL()
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		r.logger.Error(
+			"Swap Repository Error",
+			zap.String("method", method),
+			zap.String("error", err.Error()),
+		)
+		return nil, err
+	}
+	defer rows.Close()
+
+	offers := []dto.SwapOfferDto{}
+	for rows.Next() {
+		offer := dto.SwapOfferDto{Status: dto.SwapStatusCancelled, UpdatedAt: updatedAt}
+		if err := rows.Scan(&offer.ID, &offer.ProposerEmail, &offer.RecipientEmail); err != nil {
+			r.logger.Error(
+				"Swap Repository Error",
+				zap.String("method", method),
+				zap.String("error", err.Error()),
+			)
+			return nil, err
+		}
+		offers = append(offers, offer)
+	}
+
+	if err := rows.Err(); err != nil {
+		r.logger.Error(
+			"Swap Repository Error",
+			zap.String("method", method),
+			zap.String("error", err.Error()),
+		)
+		return nil, err
+	}
+	return offers, nil
+}
+
+// GetSent returns offers made by the user, newest first.
+func (r *SwapRepository) GetSent(ctx context.Context, email string, page dto.PageRequest) (*dto.Page[dto.SwapOfferDto], error) {
+	return r.list(ctx, "GetSent", goqu.Ex{"swap_offers.proposer_email": email}, page)
+}
+
+// GetReceived returns offers made to the user, newest first.
+func (r *SwapRepository) GetReceived(ctx context.Context, email string, page dto.PageRequest) (*dto.Page[dto.SwapOfferDto], error) {
+	return r.list(ctx, "GetReceived", goqu.Ex{"swap_offers.recipient_email": email}, page)
+}
+
+func offerSelect() *goqu.SelectDataset {
+	return goqu.
+		Select(
+			"swap_offers.id", "swap_offers.proposer_email",
+			goqu.I("proposers.username").As("proposer_username"),
+			"swap_offers.recipient_email",
+			goqu.I("recipients.username").As("recipient_username"),
+			"swap_offers.parent_id", "swap_offers.status",
+			"swap_offers.created_at", "swap_offers.updated_at",
+		).
+		From("swap_offers").
+		Join(
+			goqu.T("users").As("proposers"),
+			goqu.On(goqu.I("swap_offers.proposer_email").Eq(goqu.I("proposers.email"))),
+		).
+		Join(
+			goqu.T("users").As("recipients"),
+			goqu.On(goqu.I("swap_offers.recipient_email").Eq(goqu.I("recipients.email"))),
+		)
+}
+
+func scanOffer(row rowScanner, o *dto.SwapOfferDto) error {
+	return row.Scan(
+		&o.ID, &o.ProposerEmail, &o.ProposerUsername,
+		&o.RecipientEmail, &o.RecipientUsername,
+		&o.ParentID, &o.Status, &o.CreatedAt, &o.UpdatedAt,
+	)
+}
+
+func (r *SwapRepository) list(ctx context.Context, method string, where exp.Expression, page dto.PageRequest) (*dto.Page[dto.SwapOfferDto], error) {
+	query, _, _ := paginate(offerSelect().Where(where),
+		goqu.I("swap_offers.created_at"), goqu.I("swap_offers.id"), page, true).
+		ToSQL()
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		r.logger.Error(
+			"Swap Repository Error",
+			zap.String("method", method),
+			zap.String("error", err.Error()),
+		)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var offers []dto.SwapOfferDto
+	for rows.Next() {
+		var offer dto.SwapOfferDto
+		if err := scanOffer(rows, &offer); err != nil {
+			r.logger.Error(
+				"Swap Repository Error",
+				zap.String("method", method),
+				zap.String("error", err.Error()),
+			)
+			return nil, err
+		}
+		offers = append(offers, offer)
+	}
+
+	if err := rows.Err(); err != nil {
+		r.logger.Error(
+			"Swap Repository Error",
+			zap.String("method", method),
+			zap.String("error", err.Error()),
+		)
+		return nil, err
+	}
+
+	if err := r.loadPosts(ctx, method, offers); err != nil {
+		return nil, err
+	}
+
+	return dto.NewPage(offers, page.Limit, func(o *dto.SwapOfferDto) dto.Cursor {
+		return dto.Cursor{Key: o.CreatedAt, ID: o.ID}
+	}), nil
+}
+
+// loadPosts fills the offered and requested posts of the offers.
+func (r *SwapRepository) loadPosts(ctx context.Context, method string, offers []dto.SwapOfferDto) error {
+	if len(offers) == 0 {
+		return nil
+	}
+
+	byID := make(map[int64]*dto.SwapOfferDto, len(offers))
+	ids := make([]int64, 0, len(offers))
+	for i := range offers {
+		offers[i].OfferedPosts = []dto.SwapPostDto{}
+		offers[i].RequestedPosts = []dto.SwapPostDto{}
+		byID[offers[i].ID] = &offers[i]
+		ids = append(ids, offers[i].ID)
+	}
+
+	query, _, _ := goqu.From("swap_offer_posts").
+		Select("offer_id", "post_id", "post_title", "side").
+		Where(goqu.Ex{"offer_id": ids}).
+		Order(goqu.C("post_title").Asc()).
+		ToSQL()
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		r.logger.Error(
+			"Swap Repository Error",
+			zap.String("method", method),
+			zap.String("error", err.Error()),
+		)
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var offerID int64
+		var post dto.SwapPostDto
+		if err := rows.Scan(&offerID, &post.PostID, &post.PostTitle, &post.Side); err != nil {
+			r.logger.Error(
+				"Swap Repository Error",
+				zap.String("method", method),
+				zap.String("error", err.Error()),
+			)
+			return err
+		}
+
+		offer := byID[offerID]
+		if post.Side == dto.SwapSideOffered {
+			offer.OfferedPosts = append(offer.OfferedPosts, post)
+		} else {
+			offer.RequestedPosts = append(offer.RequestedPosts, post)
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		r.logger.Error(
+			"Swap Repository Error",
+			zap.String("method", method),
+			zap.String("error", err.Error()),
+		)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+// throttleLevelTTL is how long a lockout counts for the backoff of the next one.
+const throttleLevelTTL = 24 * time.Hour
+
+// ThrottleRepository keeps failed attempts of a key in a sorted set
+// "attempts:<key>" scored by time, the lockout under "lockout:<key>" and the
+// number of recent lockouts under "lockout_level:<key>".
+type ThrottleRepository struct {
+	rdb    *redis.Client
+	logger *zap.Logger
+}
+
+func NewThrottleRepository(rdb *redis.Client, logger *zap.Logger) *ThrottleRepository {
+	return &ThrottleRepository{
+		rdb:    rdb,
+		logger: logger,
+	}
+}
+
+// LockedFor returns how long the key stays locked, zero if it isn't.
+func (r *ThrottleRepository) LockedFor(ctx *context.Context, key string) (time.Duration, error) {
+	ttl, err := r.rdb.PTTL(*ctx, "lockout:"+key).Result()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		r.logger.Error(
+			"Throttle Repository Error",
+			zap.String("method", "LockedFor"),
+			zap.String("error", err.Error()),
+		)
+		return 0, err
+	}
+	if ttl < 0 {
+		return 0, nil
+	}
+	return ttl, nil
+}
+
+var addAttemptScript = redis.NewScript(`
+local now = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+redis.call("ZREMRANGEBYSCORE", KEYS[1], "-inf", now - window)
+redis.call("ZADD", KEYS[1], now, ARGV[3])
+redis.call("PEXPIRE", KEYS[1], window)
+return redis.call("ZCARD", KEYS[1])
+`)
+
+// AddAttempt records an attempt and returns the number of attempts within the
+// last window.
+func (r *ThrottleRepository) AddAttempt(ctx *context.Context, key string, window time.Duration) (int64, error) {
+	count, err := addAttemptScript.Run(*ctx, r.rdb, []string{"attempts:" + key},
+		time.Now().UnixMilli(), window.Milliseconds(), uuid.NewString(),
+	).Int64()
+	if err != nil {
+		r.logger.Error(
+			"Throttle Repository Error",
+			zap.String("method", "AddAttempt"),
+			zap.String("error", err.Error()),
+		)
+		return 0, err
+	}
+	return count, nil
+}
+
+var lockScript = redis.NewScript(`
+local level = redis.call("INCR", KEYS[2])
+redis.call("PEXPIRE", KEYS[2], ARGV[3])
+local duration = tonumber(ARGV[1]) * 2 ^ (level - 1)
+if duration > tonumber(ARGV[2]) then
+	duration = tonumber(ARGV[2])
+end
+redis.call("SET", KEYS[1], level, "PX", duration)
+redis.call("DEL", KEYS[3])
+return duration
+`)
+
+// Lock locks the key for base doubled by each lockout during the last day,
+// but not longer than max, and starts counting attempts anew.
+func (r *ThrottleRepository) Lock(ctx *context.Context, key string, base time.Duration, max time.Duration) (time.Duration, error) {
+	ms, err := lockScript.Run(*ctx, r.rdb,
+		[]string{"lockout:" + key, "lockout_level:" + key, "attempts:" + key},
+		base.Milliseconds(), max.Milliseconds(), strconv.FormatInt(throttleLevelTTL.Milliseconds(), 10),
+	).Int64()
+	if err != nil {
+		r.logger.Error(
+			"Throttle Repository Error",
+			zap.String("method", "Lock"),
+			zap.String("error", err.Error()),
+		)
+		return 0, err
+	}
+	return time.Duration(ms) * time.Millisecond, nil
+}
+
+// Reset forgets attempts and lockouts of the key after a successful attempt.
+func (r *ThrottleRepository) Reset(ctx *context.Context, key string) error {
+	if err := r.rdb.Del(*ctx, "attempts:"+key, "lockout_level:"+key).Err(); err != nil {
+		r.logger.Error(
+			"Throttle Repository Error",
+			zap.String("method", "Reset"),
+			zap.String("error", err.Error()),
+		)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+
+	"go.uber.org/zap"
+)
+
+// DBTX is implemented by both *sql.DB and *sql.Tx, so the same repository can
+// run its queries either directly or inside a transaction.
+type DBTX interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// Tx holds repositories bound to one database transaction.
+type Tx struct {
+	Bookings  *BookingRepository
+	Waitlist  *WaitlistRepository
+	Posts     *PostRepository
+	Exchanges *ExchangeRepository
+	Swaps     *SwapRepository
+	NoShows   *NoShowRepository
+	Slots     *PickupSlotRepository
+}
+
+type UnitOfWork struct {
+	db     *sql.DB
+	logger *zap.Logger
+}
+
+func NewUnitOfWork(db *sql.DB, logger *zap.Logger) *UnitOfWork {
+	return &UnitOfWork{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// Do runs fn inside a transaction. The transaction is committed when fn
+// returns nil and rolled back otherwise; the error of fn is returned as is.
+func (u *UnitOfWork) Do(ctx context.Context, fn func(tx *Tx) error) error {
+	sqlTx, err := u.db.BeginTx(ctx, nil)
+	if err != nil {
+		u.logger.Error(
+			"Unit Of Work Error",
+			zap.String("method", "Do"),
+			zap.String("error", err.Error()),
+		)
+		return err
+	}
+	defer sqlTx.Rollback()
+
+	tx := &Tx{
+		Bookings:  &BookingRepository{db: sqlTx, logger: u.logger},
+		Waitlist:  &WaitlistRepository{db: sqlTx, logger: u.logger},
+		Posts:     &PostRepository{db: sqlTx, logger: u.logger},
+		Exchanges: &ExchangeRepository{db: sqlTx, logger: u.logger},
+		Swaps:     &SwapRepository{db: sqlTx, logger: u.logger},
+		NoShows:   &NoShowRepository{db: sqlTx, logger: u.logger},
+		Slots:     &PickupSlotRepository{db: sqlTx, logger: u.logger},
+	}
+	if err := fn(tx); err != nil {
+		return err
+	}
+
+	if err := sqlTx.Commit(); err != nil {
+		u.logger.Error(
+			"Unit Of Work Error",
+			zap.String("method", "Do"),
+			zap.String("error", err.Error()),
+		)
+		return err
+	}
+	return nil
+}
@@ -11,6 +11,13 @@ import (
 	"go.uber.org/zap"
 )
 
+var userColumns = []interface{}{
+	"email", "username", "password", "created_at", "updated_at", "telegram_username",
+	goqu.L("EXISTS (SELECT 1 FROM user_roles WHERE user_roles.user_email = users.email AND user_roles.role = ?)", dto.RoleAdmin).As("is_admin"),
+	"is_verified", "deletion_scheduled_at", "avatar_url", "bio", "faculty",
+	"email_visibility", "telegram_visibility", "no_show_count", "booking_suspended_until",
+}
+
 type UserRepository struct {
 	db     *sql.DB
 	logger *zap.Logger
@@ -50,7 +57,7 @@ func (r *UserRepository) Create(ctx context.Context, u *dto.UserDto) error {
 func (r *UserRepository) GetAverageReviewRatingByEmail(ctx context.Context, email *string) (float64, error) {
 	query, _, err := goqu.From("reviews").
 		Select(goqu.COALESCE(goqu.AVG("rating"), 0).As("average_rating")).
-		Where(goqu.Ex{"target_user_email": *email}, goqu.I("rating").Gt(0)).
+		Where(goqu.Ex{"target_user_email": *email, "is_hidden": false}, goqu.I("rating").Gt(0)).
 		ToSQL()
 	if err != nil {
 		r.logger.Error(
@@ -76,7 +83,7 @@ func (r *UserRepository) GetAverageReviewRatingByEmail(ctx context.Context, emai
 }
 
 func (r *UserRepository) GetByUsername(ctx context.Context, username *string) (*dto.UserDto, error) {
-	query, _, err := goqu.From("users").Where(goqu.Ex{
+	query, _, err := goqu.From("users").Select(userColumns...).Where(goqu.Ex{
 		"username": *username,
 	}).ToSQL()
 	if err != nil {
@@ -89,7 +96,7 @@ func (r *UserRepository) GetByUsername(ctx context.Context, username *string) (*
 	}
 
 	var user dto.UserDto
-	err = r.db.QueryRow(query).Scan(&user.Email, &user.Username, &user.Password, &user.CreatedAt, &user.UpdatedAt, &user.TelegramUsername, &user.IsAdmin)
+	err = r.db.QueryRow(query).Scan(&user.Email, &user.Username, &user.Password, &user.CreatedAt, &user.UpdatedAt, &user.TelegramUsername, &user.IsAdmin, &user.IsVerified, &user.DeletionScheduledAt, &user.AvatarURL, &user.Bio, &user.Faculty, &user.EmailVisibility, &user.TelegramVisibility, &user.NoShowCount, &user.BookingSuspendedUntil)
 	if errors.Is(err, sql.ErrNoRows) {
 		return nil, nil
 	}
@@ -106,7 +113,7 @@ func (r *UserRepository) GetByUsername(ctx context.Context, username *string) (*
 }
 
 func (r *UserRepository) GetByEmail(ctx context.Context, email *string) (*dto.UserDto, error) {
-	query, _, err := goqu.From("users").Where(goqu.Ex{
+	query, _, err := goqu.From("users").Select(userColumns...).Where(goqu.Ex{
 		"email": *email,
 	}).ToSQL()
 	if err != nil {
@@ -119,7 +126,7 @@ func (r *UserRepository) GetByEmail(ctx context.Context, email *string) (*dto.Us
 	}
 
 	var user dto.UserDto
-	err = r.db.QueryRow(query).Scan(&user.Email, &user.Username, &user.Password, &user.CreatedAt, &user.UpdatedAt, &user.TelegramUsername, &user.IsAdmin)
+	err = r.db.QueryRow(query).Scan(&user.Email, &user.Username, &user.Password, &user.CreatedAt, &user.UpdatedAt, &user.TelegramUsername, &user.IsAdmin, &user.IsVerified, &user.DeletionScheduledAt, &user.AvatarURL, &user.Bio, &user.Faculty, &user.EmailVisibility, &user.TelegramVisibility, &user.NoShowCount, &user.BookingSuspendedUntil)
 	if errors.Is(err, sql.ErrNoRows) {
 		return nil, nil
 	}
@@ -181,3 +188,318 @@ func (r *UserRepository) UpdateByEmail(ctx context.Context, email *string, u *dt
 
 	return nil
 }
+
+func (r *UserRepository) SetVerified(ctx context.Context, email string) error {
+	query, _, _ := goqu.Update("users").
+		Set(goqu.Record{"is_verified": true}).
+		Where(goqu.C("email").Eq(email)).
+		ToSQL()
+
+	_, err := r.db.Exec(query)
+	if err != nil {
+		r.logger.Error(
+			"User Repository Error",
+			zap.String("method", "SetVerified"),
+			zap.String("error", err.Error()),
+		)
+		return err
+	}
+	return nil
+}
+
+// ClaimUnverified verifies the account and removes its password if the
+// account hasn't been verified yet.
+func (r *UserRepository) ClaimUnverified(ctx context.Context, email string) error {
+	query, _, err := goqu.Update("users").
+		Set(goqu.Record{"is_verified": true, "password": ""}).
+		Where(goqu.C("email").Eq(email), goqu.C("is_verified").IsFalse()).
+		ToSQL()
+	if err != nil {
+		r.logger.Error(
+			"User Repository Error",
+			zap.String("method", "ClaimUnverified"),
+			zap.String("error", err.Error()),
+		)
+		return err
+	}
+
+	_, err = r.db.Exec(query)
+	if err != nil {
+		r.logger.Error(
+			"User Repository Error",
+			zap.String("method", "ClaimUnverified"),
+			zap.String("error", err.Error()),
+		)
+		return err
+	}
+	return nil
+}
+
+// SetDeletionScheduledAt schedules the deletion of the account, nil cancels it.
+func (r *UserRepository) SetDeletionScheduledAt(ctx context.Context, email string, at *string) error {
+	query, _, _ := goqu.Update("users").
+		Set(goqu.Record{"deletion_scheduled_at": at}).
+		Where(goqu.C("email").Eq(email)).
+		ToSQL()
+
+	_, err := r.db.Exec(query)
+	if err != nil {
+		r.logger.Error(
+			"User Repository Error",
+			zap.String("method", "SetDeletionScheduledAt"),
+			zap.String("error", err.Error()),
+		)
+		return err
+	}
+	return nil
+}
+
+// GetScheduledForDeletion returns emails of accounts whose deletion time is before now.
+func (r *UserRepository) GetScheduledForDeletion(ctx context.Context, now string) ([]string, error) {
+	query, _, _ := goqu.From("users").
+		Select("email").
+		Where(goqu.I("deletion_scheduled_at").Lte(now)).
+		ToSQL()
+
+	rows, err := r.db.Query(query)
+	if err != nil {
+		r.logger.Error(
+			"User Repository Error",
+			zap.String("method", "GetScheduledForDeletion"),
+			zap.String("error", err.Error()),
+		)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var emails []string
+	for rows.Next() {
+		var email string
+		if err := rows.Scan(&email); err != nil {
+			r.logger.Error(
+				"User Repository Error",
+				zap.String("method", "GetScheduledForDeletion"),
+				zap.String("error", err.Error()),
+			)
+			return nil, err
+		}
+		emails = append(emails, email)
+	}
+
+	if err := rows.Err(); err != nil {
+		r.logger.Error(
+			"User Repository Error",
+			zap.String("method", "GetScheduledForDeletion"),
+			zap.String("error", err.Error()),
+		)
+		return nil, err
+	}
+	return emails, nil
+}
+
+// Delete removes the account with everything it owns. Reviews and exchanges
+// are kept without the user, see the foreign keys of these tables.
+func (r *UserRepository) Delete(ctx context.Context, email string) error {
+	query, _, _ := goqu.Delete("users").Where(goqu.C("email").Eq(email)).ToSQL()
+
+	_, err := r.db.Exec(query)
+	if err != nil {
+		r.logger.Error(
+			"User Repository Error",
+			zap.String("method", "Delete"),
+			zap.String("error", err.Error()),
+		)
+		return err
+	}
+	return nil
+}
+
+func (r *UserRepository) SetAvatarURL(ctx context.Context, email string, avatarURL string) error {
+	query, _, _ := goqu.Update("users").
+		Set(goqu.Record{"avatar_url": avatarURL}).
+		Where(goqu.C("email").Eq(email)).
+		ToSQL()
+
+	_, err := r.db.Exec(query)
+	if err != nil {
+		r.logger.Error(
+			"User Repository Error",
+			zap.String("method", "SetAvatarURL"),
+			zap.String("error", err.Error()),
+		)
+		return err
+	}
+	return nil
+}
+
+func (r *UserRepository) GetPreferredPlaces(ctx context.Context, email string) ([]dto.PlaceDto, error) {
+	query, _, _ := goqu.From("places").
+		Select("places.id", "places.name", "places.description", "places.address", "places.city").
+		Join(goqu.T("user_preferred_places"), goqu.On(goqu.I("user_preferred_places.place_id").Eq(goqu.I("places.id")))).
+		Where(goqu.Ex{"user_preferred_places.user_email": email}).
+		Order(goqu.I("places.id").Asc()).
+		ToSQL()
+
+	rows, err := r.db.Query(query)
+	if err != nil {
+		r.logger.Error(
+			"User Repository Error",
+			zap.String("method", "GetPreferredPlaces"),
+			zap.String("error", err.Error()),
+		)
+		return nil, err
+	}
+	defer rows.Close()
+
+	places := []dto.PlaceDto{}
+	for rows.Next() {
+		var place dto.PlaceDto
+		if err := rows.Scan(&place.ID, &place.Name, &place.Description, &place.Address, &place.City); err != nil {
+			r.logger.Error(
+				"User Repository Error",
+				zap.String("method", "GetPreferredPlaces"),
+				zap.String("error", err.Error()),
+			)
+			return nil, err
+		}
+		places = append(places, place)
+	}
+
+	if err := rows.Err(); err != nil {
+		r.logger.Error(
+			"User Repository Error",
+			zap.String("method", "GetPreferredPlaces"),
+			zap.String("error", err.Error()),
+		)
+		return nil, err
+	}
+	return places, nil
+}
+
+// SetPreferredPlaces replaces the preferred places of the user. It returns
+// false and changes nothing when some of the places don't exist.
+func (r *UserRepository) SetPreferredPlaces(ctx context.Context, email string, placeIDs []int64) (bool, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		r.logger.Error(
+			"User Repository Error",
+			zap.String("method", "SetPreferredPlaces"),
+			zap.String("error", err.Error()),
+		)
+		return false, err
+	}
+	defer tx.Rollback()
+
+	query, _, _ := goqu.Delete("user_preferred_places").Where(goqu.Ex{"user_email": email}).ToSQL()
+	if _, err := tx.Exec(query); err != nil {
+		r.logger.Error(
+			"User Repository Error",
+			zap.String("method", "SetPreferredPlaces"),
+			zap.String("error", err.Error()),
+		)
+		return false, err
+	}
+
+	if len(placeIDs) != 0 {
+		query, _, _ = goqu.Insert("user_preferred_places").
+			Cols("user_email", "place_id").
+			FromQuery(goqu.From("places").
+				Select(goqu.V(email), goqu.I("id")).
+				Where(goqu.I("id").In(placeIDs))).
+			ToSQL()
+		result, err := tx.Exec(query)
+		if err != nil {
+			r.logger.Error(
+				"User Repository Error",
+				zap.String("method", "SetPreferredPlaces"),
+				zap.String("error", err.Error()),
+			)
+			return false, err
+		}
+
+		unique := map[int64]bool{}
+		for _, id := range placeIDs {
+			unique[id] = true
+		}
+		if inserted, _ := result.RowsAffected(); inserted != int64(len(unique)) {
+			return false, nil
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		r.logger.Error(
+			"User Repository Error",
+			zap.String("method", "SetPreferredPlaces"),
+			zap.String("error", err.Error()),
+		)
+		return false, err
+	}
+	return true, nil
+}
+
+// HasActiveBooking reports whether one of the users has an active booking on
+// a post of the other.
+func (r *UserRepository) HasActiveBooking(ctx context.Context, email string, otherEmail string) (bool, error) {
+	bookings := goqu.From("bookings").
+		Select(goqu.L("1")).
+		Join(goqu.T("posts"), goqu.On(goqu.I("bookings.post_id").Eq(goqu.I("posts.id")))).
+		Where(
+			goqu.Ex{"bookings.status": activeBookingStatuses},
+			goqu.Or(
+				goqu.Ex{"bookings.user_email": email, "posts.user_email": otherEmail},
+				goqu.Ex{"bookings.user_email": otherEmail, "posts.user_email": email},
+			),
+		)
+	query, _, err := goqu.Select(goqu.L("EXISTS ?", bookings)).ToSQL()
+	if err != nil {
+		r.logger.Error(
+			"User Repository Error",
+			zap.String("method", "HasActiveBooking"),
+			zap.String("error", err.Error()),
+		)
+		return false, err
+	}
+
+	var exists bool
+	if err := r.db.QueryRow(query).Scan(&exists); err != nil {
+		r.logger.Error(
+			"User Repository Error",
+			zap.String("method", "HasActiveBooking"),
+			zap.String("error", err.Error()),
+		)
+		return false, err
+	}
+	return exists, nil
+}
+
+// GetReliability returns the number of books the user picked up and the
+// no-show counter of the user.
+func (r *UserRepository) GetReliability(ctx context.Context, email string) (*dto.ReliabilityDto, error) {
+	completed := goqu.From("bookings").
+		Select(goqu.COUNT("*")).
+		Where(goqu.Ex{"user_email": email, "status": dto.BookingStatusCompleted})
+	query, _, err := goqu.From("users").
+		Select(completed, "no_show_count", "booking_suspended_until").
+		Where(goqu.Ex{"email": email}).
+		ToSQL()
+	if err != nil {
+		r.logger.Error(
+			"User Repository Error",
+			zap.String("method", "GetReliability"),
+			zap.String("error", err.Error()),
+		)
+		return nil, err
+	}
+
+	var reliability dto.ReliabilityDto
+	err = r.db.QueryRow(query).Scan(&reliability.CompletedBookings, &reliability.NoShows, &reliability.SuspendedUntil)
+	if err != nil {
+		r.logger.Error(
+			"User Repository Error",
+			zap.String("method", "GetReliability"),
+			zap.String("error", err.Error()),
+		)
+		return nil, err
+	}
+	return &reliability, nil
+}
@@ -0,0 +1,228 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"example.com/m/internal/api/v1/core/application/dto"
+	"github.com/doug-martin/goqu/v9"
+	"go.uber.org/zap"
+)
+
+// positionQuery counts the entries of the same post queued before the row,
+// including the row itself.
+const positionQuery = "(SELECT COUNT(*) FROM booking_waitlist AS w2 WHERE w2.post_id = booking_waitlist.post_id AND w2.id <= booking_waitlist.id)"
+
+type WaitlistRepository struct {
+	db     DBTX
+	logger *zap.Logger
+}
+
+func NewWaitlistRepository(db *sql.DB, logger *zap.Logger) *WaitlistRepository {
+	return &WaitlistRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+func (r *WaitlistRepository) Add(ctx context.Context, e *dto.WaitlistEntryToCreateDto) (*int64, error) {
+	var id int64
+	query, _, _ := goqu.Insert("booking_waitlist").Rows(*e).Returning("id").ToSQL()
+	err := r.db.QueryRow(query).Scan(&id)
+	if err != nil {
+		r.logger.Error(
+			"Waitlist Repository Error",
+			zap.String("method", "Add"),
+			zap.String("error", err.Error()),
+		)
+		return nil, err
+	}
+	return &id, nil
+}
+
+// Get returns the entry of the user in the waitlist of the post together with
+// its position, starting from 1.
+func (r *WaitlistRepository) Get(ctx context.Context, postID int64, userEmail string) (*dto.WaitlistEntryDto, error) {
+	var entry dto.WaitlistEntryDto
+	query, _, _ := goqu.
+		Select("id", "post_id", "user_email", "created_at", goqu.L(positionQuery).As("position")).
+		From("booking_waitlist").
+		Where(goqu.Ex{
+			"post_id":    postID,
+			"user_email": userEmail,
+		}).
+		ToSQL()
+	err := r.db.QueryRow(query).Scan(&entry.ID, &entry.PostID, &entry.UserEmail, &entry.CreatedAt, &entry.Position)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		r.logger.Error(
+			"Waitlist Repository Error",
+			zap.String("method", "Get"),
+			zap.String("error", err.Error()),
+		)
+		return nil, err
+	}
+	return &entry, nil
+}
+
+func (r *WaitlistRepository) GetByUser(ctx context.Context, userEmail string) (*[]dto.WaitlistEntryDto, error) {
+	entries := []dto.WaitlistEntryDto{}
+	query, _, _ := goqu.
+		Select(
+			"booking_waitlist.id", "booking_waitlist.post_id", "booking_waitlist.user_email",
+			"booking_waitlist.created_at", goqu.L(positionQuery).As("position"),
+			goqu.I("posts.title").As("post_title"),
+		).
+		From("booking_waitlist").
+		Join(
+			goqu.T("posts"),
+			goqu.On(goqu.I("booking_waitlist.post_id").Eq(goqu.I("posts.id"))),
+		).
+		Where(goqu.Ex{"booking_waitlist.user_email": userEmail}).
+		Order(goqu.I("booking_waitlist.created_at").Asc()).
+		ToSQL()
+
+	rows, err := r.db.Query(query)
+	if err != nil {
+		r.logger.Error(
+			"Waitlist Repository Error",
+			zap.String("method", "GetByUser"),
+			zap.String("error", err.Error()),
+		)
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var entry dto.WaitlistEntryDto
+		if err := rows.Scan(&entry.ID, &entry.PostID, &entry.UserEmail, &entry.CreatedAt, &entry.Position, &entry.PostTitle); err != nil {
+			r.logger.Error(
+				"Waitlist Repository Error",
+				zap.String("method", "GetByUser"),
+				zap.String("error", err.Error()),
+			)
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+
+	if err := rows.Err(); err != nil {
+		r.logger.Error(
+			"Waitlist Repository Error",
+			zap.String("method", "GetByUser"),
+			zap.String("error", err.Error()),
+		)
+		return nil, err
+	}
+
+	return &entries, nil
+}
+
+// PopFirst removes the earliest entry of the post's waitlist and returns it.
+func (r *WaitlistRepository) PopFirst(ctx context.Context, postID int64) (*dto.WaitlistEntryDto, error) {
+	var entry dto.WaitlistEntryDto
+	first := goqu.From("booking_waitlist").
+		Select("id").
+		Where(goqu.Ex{"post_id": postID}).
+		Order(goqu.C("id").Asc()).
+		Limit(1)
+	query, _, _ := goqu.Delete("booking_waitlist").
+		Where(goqu.C("id").Eq(first)).
+		Returning("id", "post_id", "user_email", "created_at").
+		ToSQL()
+	err := r.db.QueryRow(query).Scan(&entry.ID, &entry.PostID, &entry.UserEmail, &entry.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		r.logger.Error(
+			"Waitlist Repository Error",
+			zap.String("method", "PopFirst"),
+			zap.String("error", err.Error()),
+		)
+		return nil, err
+	}
+	entry.Position = 1
+	return &entry, nil
+}
+
+func (r *WaitlistRepository) Remove(ctx context.Context, postID int64, userEmail string) error {
+	query, _, _ := goqu.Delete("booking_waitlist").Where(goqu.Ex{
+		"post_id":    postID,
+		"user_email": userEmail,
+	}).ToSQL()
+	_, err := r.db.Exec(query)
+	if err != nil {
+		r.logger.Error(
+			"Waitlist Repository Error",
+			zap.String("method", "Remove"),
+			zap.String("error", err.Error()),
+		)
+		return err
+	}
+	return nil
+}
+
+// RemoveByUser removes the user from the waitlists of all posts.
+func (r *WaitlistRepository) RemoveByUser(ctx context.Context, userEmail string) error {
+	query, _, _ := goqu.Delete("booking_waitlist").Where(goqu.Ex{
+		"user_email": userEmail,
+	}).ToSQL()
+	_, err := r.db.ExecContext(ctx, query)
+	if err != nil {
+		r.logger.Error(
+			"Waitlist Repository Error",
+			zap.String("method", "RemoveByUser"),
+			zap.String("error", err.Error()),
+		)
+		return err
+	}
+	return nil
+}
+
+// RemoveAll clears the waitlist of the post and returns emails of the removed users.
+func (r *WaitlistRepository) RemoveAll(ctx context.Context, postID int64) ([]string, error) {
+	emails := []string{}
+	query, _, _ := goqu.Delete("booking_waitlist").
+		Where(goqu.Ex{"post_id": postID}).
+		Returning("user_email").
+		ToSQL()
+
+	rows, err := r.db.Query(query)
+	if err != nil {
+		r.logger.Error(
+			"Waitlist Repository Error",
+			zap.String("method", "RemoveAll"),
+			zap.String("error", err.Error()),
+		)
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var email string
+		if err := rows.Scan(&email); err != nil {
+			r.logger.Error(
+				"Waitlist Repository Error",
+				zap.String("method", "RemoveAll"),
+				zap.String("error", err.Error()),
+			)
+			return nil, err
+		}
+		emails = append(emails, email)
+	}
+
+	if err := rows.Err(); err != nil {
+		r.logger.Error(
+			"Waitlist Repository Error",
+			zap.String("method", "RemoveAll"),
+			zap.String("error", err.Error()),
+		)
+		return nil, err
+	}
+
+	return emails, nil
+}
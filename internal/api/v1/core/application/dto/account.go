@@ -0,0 +1,11 @@
+package dto
+
+// ExportFileDto is a file of the personal data export archive.
+type ExportFileDto struct {
+	Name string
+	Data []byte
+}
+
+type AccountDeletionDto struct {
+	DeletionScheduledAt string `json:"deletion_scheduled_at"`
+}
@@ -3,9 +3,55 @@ package dto
 type AuthorizeUserDto struct {
 	Email    string `json:"email" db:"email" binding:"required,email,max=64,min=6"`
 	Password string `json:"password" db:"password" binding:"required,max=64,min=6"`
+	// shown in the list of sessions, e.g. "iPhone 15"
+	DeviceName string `json:"device_name" binding:"omitempty,max=64"`
+}
+
+type ForgotPasswordDto struct {
+	Email string `json:"email" binding:"required,email,max=64,min=6"`
+}
+
+type ResetPasswordDto struct {
+	Email       string `json:"email" binding:"required,email,max=64,min=6"`
+	Code        string `json:"code" binding:"required,len=6,numeric"`
+	NewPassword string `json:"new_password" binding:"required,max=64,min=6"`
 }
 
 type ChangeUserPasswordDto struct {
 	OldPassword string `json:"old_password" db:"password" binding:"required,max=64,min=6"`
 	NewPassword string `json:"new_password" db:"password" binding:"required,max=64,min=6"`
 }
+
+type RefreshTokenDto struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+type TokensDto struct {
+	// short-lived JWT for the Authorization header
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+	// access token lifetime in seconds
+	ExpiresIn int64 `json:"expires_in"`
+}
+
+// SessionDto is a login on one device. It is stored in Redis as a hash until
+// logout or until the refresh token expires.
+type SessionDto struct {
+	ID               string `json:"id" redis:"id"`
+	Email            string `json:"email" redis:"email"`
+	RefreshTokenHash string `json:"-" redis:"refresh_token_hash"`
+	DeviceName       string `json:"device_name" redis:"device_name"`
+	IP               string `json:"ip" redis:"ip"`
+	UserAgent        string `json:"user_agent" redis:"user_agent"`
+	CreatedAt        string `json:"created_at" redis:"created_at"`
+	LastSeenAt       string `json:"last_seen_at" redis:"last_seen_at"`
+	// the session of the token the list was requested with
+	Current bool `json:"current" redis:"-"`
+}
+
+// SessionMetaDto describes the client a session is used from.
+type SessionMetaDto struct {
+	DeviceName string
+	IP         string
+	UserAgent  string
+}
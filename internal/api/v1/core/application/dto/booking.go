@@ -1,18 +1,89 @@
 package dto
 
+const (
+	BookingStatusPending   = "pending"
+	BookingStatusConfirmed = "confirmed"
+	BookingStatusRejected  = "rejected"
+	BookingStatusExpired   = "expired"
+	BookingStatusCancelled = "cancelled"
+	BookingStatusCompleted = "completed"
+	// the owner reported that the booker didn't come for the book
+	BookingStatusNoShow = "no_show"
+)
+
+// HandoverCodeDto is shown by the booker to the owner when the book is handed
+// over. QRPayload encodes the same code for scanning.
+type HandoverCodeDto struct {
+	Code      string `json:"code"`
+	QRPayload string `json:"qr_payload"`
+	ExpiresAt string `json:"expires_at"`
+}
+
+type ConfirmHandoverDto struct {
+	Code string `json:"code" binding:"required,len=6,numeric"`
+}
+
 type BookBookDto struct {
 	PostID int64 `json:"post_id" db:"post_id" binding:"required"`
 }
 
+// BookingDto is shown to the booker and the owner, the booker is identified
+// by username only.
 type BookingDto struct {
-	ID        int64  `json:"id" db:"id"`
+	ID             int64  `json:"id" db:"id"`
+	UserEmail      string `json:"-" db:"user_email"`
+	BookerUsername string `json:"booker_username" db:"booker_username"`
+	PostID         int64  `json:"post_id" db:"post_id"`
+	CreatedAt      string `json:"created_at" db:"created_at"`
+	Status         string `json:"status" db:"status" enum:"pending,confirmed,rejected,expired,cancelled,completed,no_show"`
+	ExpiresAt      string `json:"expires_at" db:"expires_at"`
+	UpdatedAt      string `json:"updated_at" db:"updated_at"`
+	// set when the booking was made by an accepted swap offer
+	SwapID *int64 `json:"swap_id,omitempty" db:"swap_id"`
+	// pickup slot reserved by the booker
+	SlotID *int64 `json:"slot_id,omitempty" db:"slot_id"`
+}
+
+type BookingToCreateDto struct {
 	UserEmail string `json:"user_email" db:"user_email"`
 	PostID    int64  `json:"post_id" db:"post_id"`
 	CreatedAt string `json:"created_at" db:"created_at"`
+	Status    string `json:"status" db:"status"`
+	ExpiresAt string `json:"expires_at" db:"expires_at"`
+	UpdatedAt string `json:"updated_at" db:"updated_at"`
+	SwapID    *int64 `json:"swap_id" db:"swap_id"`
 }
 
-type BookingToCreateDto struct {
+type UpdateBookingDto struct {
+	Status    string `json:"status,omitempty" db:"status" goqu:"omitempty"`
+	ExpiresAt string `json:"expires_at,omitempty" db:"expires_at" goqu:"omitempty"`
+	// sets in service automatically
+	UpdatedAt string `json:"updated_at" db:"updated_at"`
+}
+
+type IncomingBookingDto struct {
+	BookingDto
+	PostTitle string `json:"post_title" db:"post_title"`
+}
+
+type WaitlistEntryDto struct {
+	ID        int64  `json:"id" db:"id"`
+	PostID    int64  `json:"post_id" db:"post_id"`
 	UserEmail string `json:"user_email" db:"user_email"`
+	CreatedAt string `json:"created_at" db:"created_at"`
+	Position  int64  `json:"position" db:"position"`
+	PostTitle string `json:"post_title,omitempty" db:"post_title"`
+}
+
+type NoShowReportToCreateDto struct {
+	BookingID     int64  `json:"booking_id" db:"booking_id"`
+	ReporterEmail string `json:"reporter_email" db:"reporter_email"`
+	UserEmail     string `json:"user_email" db:"user_email"`
+	CreatedAt     string `json:"created_at" db:"created_at"`
+}
+
+type WaitlistEntryToCreateDto struct {
 	PostID    int64  `json:"post_id" db:"post_id"`
+	UserEmail string `json:"user_email" db:"user_email"`
 	CreatedAt string `json:"created_at" db:"created_at"`
 }
@@ -11,22 +11,14 @@ type (
 		CreatedAt string `json:"created_at"`
 	}
 
-	ChatDto struct {
-		Messages []ChatMessageDto `json:"messages" binding:"required"`
-	}
-
 	DbMessageDto struct {
+		ID        int64  `db:"id" goqu:"skipinsert"`
 		Email     string `db:"email"`
 		Text      string `db:"text"`
 		Role      string `db:"role"`
 		CreatedAt string `db:"created_at"`
 	}
 
-	ChatQueryDto struct {
-		Limit  int `form:"limit" binding:"required,min=1,max=100"`
-		Offset int `form:"offset" binding:"min=0"`
-	}
-
 	YandexRequstDto struct {
 		ModelUri          string                     `json:"model_uri"`
 		CompletionOptions YandexCompletionOptionsDto `json:"completion_options"`
@@ -66,6 +58,7 @@ const (
 )
 
 type ChatMessage struct {
+	ID        int64
 	Message   string
 	Writer    writerType
 	CreatedAt string
@@ -0,0 +1,33 @@
+package dto
+
+type ExchangeDto struct {
+	ID                int64   `json:"id" db:"id"`
+	PostID            *int64  `json:"post_id" db:"post_id"`
+	PostTitle         string  `json:"post_title" db:"post_title"`
+	PlaceID           *int64  `json:"place_id" db:"place_id"`
+	PlaceName         string  `json:"place_name" db:"place_name"`
+	BookingID         *int64  `json:"booking_id" db:"booking_id"`
+	OwnerEmail        string  `json:"-" db:"owner_email"`
+	OwnerUsername     string  `json:"owner_username" db:"owner_username"`
+	RecipientEmail    string  `json:"-" db:"recipient_email"`
+	RecipientUsername string  `json:"recipient_username" db:"recipient_username"`
+	BookedAt          *string `json:"booked_at" db:"booked_at"`
+	TakenAt           string  `json:"taken_at" db:"taken_at"`
+	// set for loans only
+	DueAt      *string `json:"due_at,omitempty" db:"due_at"`
+	ReturnedAt *string `json:"returned_at,omitempty" db:"returned_at"`
+	// used by the reminder worker only
+	OverdueReminderSentAt *string `json:"-" db:"overdue_reminder_sent_at"`
+}
+
+type ExchangeToCreateDto struct {
+	PostID         int64   `json:"post_id" db:"post_id"`
+	PostTitle      string  `json:"post_title" db:"post_title"`
+	PlaceID        int64   `json:"place_id" db:"place_id"`
+	BookingID      *int64  `json:"booking_id" db:"booking_id"`
+	OwnerEmail     string  `json:"owner_email" db:"owner_email"`
+	RecipientEmail string  `json:"recipient_email" db:"recipient_email"`
+	BookedAt       *string `json:"booked_at" db:"booked_at"`
+	TakenAt        string  `json:"taken_at" db:"taken_at"`
+	DueAt          *string `json:"due_at" db:"due_at"`
+}
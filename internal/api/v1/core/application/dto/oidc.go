@@ -0,0 +1,24 @@
+package dto
+
+// OIDCLoginDto is kept in Redis between redirecting the user to the identity
+// provider and the callback.
+type OIDCLoginDto struct {
+	Verifier   string `redis:"verifier"`
+	Nonce      string `redis:"nonce"`
+	DeviceName string `redis:"device_name"`
+}
+
+type OIDCClaimsDto struct {
+	Issuer            string
+	Subject           string
+	Email             string
+	EmailVerified     bool
+	PreferredUsername string
+}
+
+type UserIdentityDto struct {
+	Issuer    string `json:"issuer" db:"issuer"`
+	Subject   string `json:"subject" db:"subject"`
+	UserEmail string `json:"user_email" db:"user_email"`
+	CreatedAt string `json:"created_at" db:"created_at"`
+}
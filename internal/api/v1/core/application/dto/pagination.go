@@ -0,0 +1,94 @@
+package dto
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"math"
+	"strconv"
+	"time"
+
+	"example.com/m/internal/api/v1/core/application/exceptions"
+)
+
+const (
+	DefaultPageLimit = 20
+	MaxPageLimit     = 100
+)
+
+// Page is a part of a list ordered by a unique key. NextCursor points right
+// after the last item and is empty when there are no more items.
+type Page[T any] struct {
+	Items      []T    `json:"items"`
+	NextCursor string `json:"next_cursor"`
+	HasMore    bool   `json:"has_more"`
+}
+
+// Cursor is the keyset position of an item: the value of the sort column and
+// the id breaking ties between equal values. Clients get it as an opaque string.
+type Cursor struct {
+	Key string `json:"k"`
+	ID  int64  `json:"i"`
+}
+
+// CursorKey is the type of the sort column the key of a cursor is compared
+// with.
+type CursorKey int
+
+const (
+	CursorKeyTime CursorKey = iota
+	CursorKeyText
+	CursorKeyNumber
+)
+
+func (c Cursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeCursor parses a cursor whose key is of the given type. A key of
+// another type can only come from a tampered cursor, and the database would
+// fail to compare it with the sort column.
+func DecodeCursor(s string, key CursorKey) (*Cursor, *exceptions.Error_) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, &exceptions.ErrInvalidCursor
+	}
+	var c Cursor
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, &exceptions.ErrInvalidCursor
+	}
+
+	switch key {
+	case CursorKeyTime:
+		if _, err := time.Parse(time.RFC3339Nano, c.Key); err != nil {
+			return nil, &exceptions.ErrInvalidCursor
+		}
+	case CursorKeyNumber:
+		number, err := strconv.ParseFloat(c.Key, 64)
+		if err != nil || math.IsNaN(number) || math.IsInf(number, 0) {
+			return nil, &exceptions.ErrInvalidCursor
+		}
+	}
+	return &c, nil
+}
+
+type PageRequest struct {
+	// nil for the first page
+	Cursor *Cursor
+	Limit  uint
+}
+
+// NewPage builds a page from up to limit+1 items fetched after the cursor, the
+// extra item only tells that there are more.
+func NewPage[T any](items []T, limit uint, cursorOf func(*T) Cursor) *Page[T] {
+	page := &Page[T]{Items: items}
+	if page.Items == nil {
+		page.Items = []T{}
+	}
+	if uint(len(page.Items)) > limit {
+		page.Items = page.Items[:limit]
+		page.HasMore = true
+		page.NextCursor = cursorOf(&page.Items[limit-1]).Encode()
+	}
+	return page
+}
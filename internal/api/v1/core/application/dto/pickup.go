@@ -0,0 +1,67 @@
+package dto
+
+// OpeningHoursDto is one interval when the place is open. Weekday is 0 for
+// Sunday, times are "HH:MM" in UTC.
+type OpeningHoursDto struct {
+	Weekday  int    `json:"weekday" db:"weekday" binding:"min=0,max=6"`
+	OpensAt  string `json:"opens_at" db:"opens_at" binding:"required,datetime=15:04"`
+	ClosesAt string `json:"closes_at" db:"closes_at" binding:"required,datetime=15:04"`
+}
+
+// SetOpeningHoursDto replaces the opening hours of the place, an empty list
+// removes them.
+type SetOpeningHoursDto struct {
+	Hours []OpeningHoursDto `json:"hours" binding:"max=28,dive"`
+}
+
+type PickupSlotDto struct {
+	ID       int64  `json:"id" db:"id"`
+	PlaceID  int64  `json:"place_id" db:"place_id"`
+	StartsAt string `json:"starts_at" db:"starts_at"`
+	EndsAt   string `json:"ends_at" db:"ends_at"`
+	Capacity int    `json:"capacity" db:"capacity"`
+	// active bookings which reserved the slot
+	Reserved int `json:"reserved" db:"reserved"`
+}
+
+type CreatePickupSlotDto struct {
+	StartsAt string `json:"starts_at" binding:"required,datetime=2006-01-02T15:04:05Z07:00"`
+	EndsAt   string `json:"ends_at" binding:"required,datetime=2006-01-02T15:04:05Z07:00"`
+	Capacity int    `json:"capacity" binding:"required,min=1,max=100"`
+}
+
+type PickupSlotToCreateDto struct {
+	PlaceID   int64  `json:"place_id" db:"place_id"`
+	StartsAt  string `json:"starts_at" db:"starts_at"`
+	EndsAt    string `json:"ends_at" db:"ends_at"`
+	Capacity  int    `json:"capacity" db:"capacity"`
+	CreatedAt string `json:"created_at" db:"created_at"`
+}
+
+type ReservePickupSlotDto struct {
+	SlotID int64 `json:"slot_id" binding:"required,min=1"`
+}
+
+// PickupDto is the appointment of a booking with a reserved slot.
+type PickupDto struct {
+	BookingID    int64  `json:"booking_id" db:"booking_id"`
+	SlotID       int64  `json:"slot_id" db:"slot_id"`
+	BookerEmail  string `json:"booker_email" db:"booker_email"`
+	OwnerEmail   string `json:"owner_email" db:"owner_email"`
+	PostTitle    string `json:"post_title" db:"post_title"`
+	PlaceName    string `json:"place_name" db:"place_name"`
+	PlaceAddress string `json:"place_address" db:"place_address"`
+	PlaceCity    string `json:"place_city" db:"place_city"`
+	StartsAt     string `json:"starts_at" db:"starts_at"`
+	EndsAt       string `json:"ends_at" db:"ends_at"`
+}
+
+// CalendarEventDto is exported as an iCalendar event. Times are RFC 3339.
+type CalendarEventDto struct {
+	UID         string
+	Summary     string
+	Description string
+	Location    string
+	StartsAt    string
+	EndsAt      string
+}
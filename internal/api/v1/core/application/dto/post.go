@@ -1,9 +1,15 @@
 package dto
 
+const (
+	ListingTypeGiveaway = "giveaway"
+	// the book is returned to the owner after the loan period
+	ListingTypeLoan = "loan"
+)
+
 type PostDto struct {
 	ID              int64    `json:"id" db:"id"`
 	Images          []string `json:"images" db:"images" binding:"min=0,max=5,dive,required,min=5"`
-	UserEmail       string   `json:"user_email" db:"user_email" binding:"required,email,min=6,max=64"`
+	UserEmail       string   `json:"user_email,omitempty" db:"user_email" binding:"required,email,min=6,max=64"`
 	PlaceID         int64    `json:"place_id" db:"place_id"`
 	Title           string   `json:"title" db:"title" binding:"required,max=300,min=1"`
 	Description     string   `json:"description" db:"description" binding:"required,max=500,min=1"`
@@ -19,9 +25,16 @@ type PostDto struct {
 	PagesCount      int      `json:"pages_count" db:"pages_count"`
 	Summary         string   `json:"summary" db:"summary"`
 	Quote           string   `json:"quote" db:"quote"`
+	ListingType     string   `json:"listing_type" db:"listing_type" enum:"giveaway,loan"`
+	LoanPeriodDays  *int     `json:"loan_period_days,omitempty" db:"loan_period_days"`
 	PlaceName       string   `json:"place_name" db:"place_name"`
 	PlaceAddress    string   `json:"place_address" db:"place_address"`
 	OwnerUsername   string   `json:"owner_username" db:"owner_username"`
+	// set by search only
+	Relevance float64 `json:"relevance,omitempty" db:"relevance"`
+	Snippet   string  `json:"snippet,omitempty" db:"snippet"`
+	// set by the list of available posts only
+	OwnerRating float64 `json:"owner_rating,omitempty" db:"owner_rating"`
 }
 
 type PostToGetDto struct {
@@ -58,6 +71,8 @@ type PostDtoWithoutId struct {
 	CreatedAt       string   `json:"created_at" db:"created_at"`
 	Cover           string   `json:"cover" db:"cover" binding:"min=0,max=60"`
 	PagesCount      int      `json:"pages_count" db:"pages_count"`
+	ListingType     string   `json:"listing_type" db:"listing_type"`
+	LoanPeriodDays  *int     `json:"loan_period_days" db:"loan_period_days"`
 }
 
 type CreatePostDto struct {
@@ -72,6 +87,9 @@ type CreatePostDto struct {
 	Condition       string   `json:"condition" db:"condition" binding:"max=40,min=1"`
 	Cover           string   `json:"cover" db:"cover" binding:"min=0,max=60"`
 	PagesCount      int      `json:"pages_count" db:"pages_count"`
+	ListingType     string   `json:"listing_type" db:"listing_type" binding:"omitempty,oneof=giveaway loan"`
+	// required for loans, in days
+	LoanPeriodDays *int `json:"loan_period_days" db:"loan_period_days" binding:"omitempty,min=1,max=365"`
 }
 
 type UpdatePostDto struct {
@@ -89,6 +107,36 @@ type UpdatePostDto struct {
 	PagesCount      int    `json:"pages_count,omitempty" db:"pages_count"`
 }
 
+type ReorderImagesDto struct {
+	// all images of the post in the new order, the first one becomes primary
+	Images []string `json:"images" binding:"required,min=1,max=5"`
+}
+
+type ImageDto struct {
+	Url string `json:"url" binding:"required"`
+}
+
 type ImageUploadResponseDto struct {
 	Url string `json:"url"`
 }
+
+type FacetValueDto struct {
+	Value string `json:"value"`
+	// human readable value, e.g. the place name for a place ID
+	Label string `json:"label"`
+	Count int64  `json:"count"`
+}
+
+type PostFacetsDto struct {
+	Genres     []FacetValueDto `json:"genres"`
+	Conditions []FacetValueDto `json:"conditions"`
+	Covers     []FacetValueDto `json:"covers"`
+	Places     []FacetValueDto `json:"places"`
+	Cities     []FacetValueDto `json:"cities"`
+}
+
+type AvailablePostsDto struct {
+	Page[PostDto]
+	// only the first page has facets, they don't change between pages
+	Facets *PostFacetsDto `json:"facets,omitempty"`
+}
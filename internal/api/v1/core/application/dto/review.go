@@ -11,15 +11,22 @@ type ReviewDto struct {
 	Rating            int    `json:"rating" db:"rating"`
 	Comment           string `json:"comment" db:"comment"`
 	CreatedAt         string `json:"created_at" db:"created_at"`
+	ExchangeID        *int64 `json:"exchange_id" db:"exchange_id"`
+	UpdatedAt         string `json:"updated_at" db:"updated_at"`
+	IsHidden          bool   `json:"is_hidden" db:"is_hidden"`
 }
 
+// ReviewToGetDto is a public review, emails of both users stay internal.
 type ReviewToGetDto struct {
 	ID                int    `json:"id" db:"id"`
-	TargetUserEmail   string `json:"target_user_email" db:"target_user_email" binding:"required,email"`
-	ReviewerUserEmail string `json:"reviewer_user_email" db:"reviewer_user_email" binding:"required,email"`
+	TargetUserEmail   string `json:"-" db:"target_user_email"`
+	ReviewerUserEmail string `json:"-" db:"reviewer_user_email"`
 	Rating            int    `json:"rating" db:"rating" binding:"required,min=1,max=5"`
 	Comment           string `json:"comment" db:"comment" binding:"max=500,min=1"`
 	CreatedAt         string `json:"created_at" db:"created_at"`
+	ExchangeID        *int64 `json:"exchange_id" db:"exchange_id"`
+	UpdatedAt         string `json:"updated_at" db:"updated_at"`
+	TargetUsername    string `json:"target_username" db:"target_username"`
 	ReviewerUsername  string `json:"reviewer_username" db:"reviewer_username"`
 }
 
@@ -29,28 +36,49 @@ type ReviewWithoutIDDto struct {
 	Rating            int    `json:"rating" db:"rating" binding:"required,min=1,max=5"`
 	Comment           string `json:"comment" db:"comment" binding:"max=500,min=1"`
 	CreatedAt         string `json:"created_at" db:"created_at"`
+	ExchangeID        int64  `json:"exchange_id" db:"exchange_id"`
+	UpdatedAt         string `json:"updated_at" db:"updated_at"`
 }
 
+// ReviewToCreateDto is a review of the other side of the exchange.
 type ReviewToCreateDto struct {
-	Rating          *int    `json:"rating" db:"rating" binding:"max=5"`
-	Comment         *string `json:"comment" db:"comment" binding:"max=500"`
-	TargetUserEmail string  `json:"target_user_email" db:"target_user_email" binding:"required,email"`
-	// ReviewerUserEmail string `json:"reviewer_user_email" db:"reviewer_user_email"`
+	Rating     *int    `json:"rating" db:"rating" binding:"max=5"`
+	Comment    *string `json:"comment" db:"comment" binding:"max=500"`
+	ExchangeID int64   `json:"exchange_id" db:"exchange_id" binding:"required"`
 }
 
 func (d *ReviewToCreateDto) Validate() *exceptions.Error_ {
-	if d.Rating == nil && d.Comment == nil {
+	return validateReview(d.Rating, d.Comment)
+}
+
+type ReviewToUpdateDto struct {
+	Rating  *int    `json:"rating" db:"rating" binding:"max=5"`
+	Comment *string `json:"comment" db:"comment" binding:"max=500"`
+}
+
+func (d *ReviewToUpdateDto) Validate() *exceptions.Error_ {
+	return validateReview(d.Rating, d.Comment)
+}
+
+type UpdateReviewDto struct {
+	Rating    *int    `json:"rating,omitempty" db:"rating" goqu:"omitnil"`
+	Comment   *string `json:"comment,omitempty" db:"comment" goqu:"omitnil"`
+	UpdatedAt string  `json:"updated_at" db:"updated_at"`
+}
+
+func validateReview(rating *int, comment *string) *exceptions.Error_ {
+	if rating == nil && comment == nil {
 		return &exceptions.ErrNotAllFields
 	}
-	if d.Rating != nil && d.Comment != nil {
-		if *d.Rating == 0 && *d.Comment == "" {
+	if rating != nil && comment != nil {
+		if *rating == 0 && *comment == "" {
 			return &exceptions.ErrNotAllFields
 		}
 	}
-	if d.Rating != nil && *d.Rating < 0 || *d.Rating > 5 {
+	if rating != nil && (*rating < 0 || *rating > 5) {
 		return &exceptions.ErrInvalidRating
 	}
-	if d.Comment != nil && len(*d.Comment) > 500 {
+	if comment != nil && len(*comment) > 500 {
 		return &exceptions.ErrInvalidComment
 	}
 	return nil
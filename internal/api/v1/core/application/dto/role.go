@@ -0,0 +1,41 @@
+package dto
+
+const (
+	RoleModerator    = "moderator"
+	RolePlaceManager = "place_manager"
+	RoleAdmin        = "admin"
+)
+
+const (
+	PermissionManageRoles     = "roles:manage"
+	PermissionCreatePlaces    = "places:create"
+	PermissionManagePlaces    = "places:manage"
+	PermissionModerateReviews = "reviews:moderate"
+	PermissionViewExchanges   = "exchanges:view"
+)
+
+// RolePermissions lists what each role allows. Every user has the implicit
+// "user" role which needs no permissions from this list.
+var RolePermissions = map[string][]string{
+	RoleModerator:    {PermissionModerateReviews},
+	RolePlaceManager: {PermissionManagePlaces},
+	RoleAdmin: {
+		PermissionManageRoles, PermissionCreatePlaces, PermissionManagePlaces,
+		PermissionModerateReviews, PermissionViewExchanges,
+	},
+}
+
+type RoleDto struct {
+	ID        int64  `json:"id" db:"id" goqu:"skipinsert"`
+	UserEmail string `json:"user_email" db:"user_email"`
+	Role      string `json:"role" db:"role"`
+	// set only for place_manager, the role applies to this place only
+	PlaceID   *int64 `json:"place_id,omitempty" db:"place_id"`
+	GrantedBy string `json:"granted_by" db:"granted_by"`
+	CreatedAt string `json:"created_at" db:"created_at"`
+}
+
+type GrantRoleDto struct {
+	Role    string `json:"role" binding:"required,oneof=moderator place_manager admin"`
+	PlaceID *int64 `json:"place_id" binding:"omitempty,min=1"`
+}
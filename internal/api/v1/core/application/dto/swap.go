@@ -0,0 +1,60 @@
+package dto
+
+const (
+	SwapStatusPending   = "pending"
+	SwapStatusAccepted  = "accepted"
+	SwapStatusRejected  = "rejected"
+	SwapStatusCountered = "countered"
+	SwapStatusWithdrawn = "withdrawn"
+	// the offer can't be accepted anymore because one of its posts was
+	// swapped by another offer
+	SwapStatusCancelled = "cancelled"
+)
+
+const (
+	SwapSideOffered   = "offered"
+	SwapSideRequested = "requested"
+)
+
+type SwapPostDto struct {
+	PostID    *int64 `json:"post_id" db:"post_id"`
+	PostTitle string `json:"post_title" db:"post_title"`
+	Side      string `json:"-" db:"side"`
+}
+
+// SwapOfferDto is an offer of the proposer's posts for the recipient's posts.
+type SwapOfferDto struct {
+	ID                int64         `json:"id" db:"id"`
+	ProposerEmail     string        `json:"-" db:"proposer_email"`
+	ProposerUsername  string        `json:"proposer_username" db:"proposer_username"`
+	RecipientEmail    string        `json:"-" db:"recipient_email"`
+	RecipientUsername string        `json:"recipient_username" db:"recipient_username"`
+	ParentID          *int64        `json:"parent_id" db:"parent_id"`
+	Status            string        `json:"status" db:"status" enum:"pending,accepted,rejected,countered,withdrawn,cancelled"`
+	OfferedPosts      []SwapPostDto `json:"offered_posts" db:"-"`
+	RequestedPosts    []SwapPostDto `json:"requested_posts" db:"-"`
+	CreatedAt         string        `json:"created_at" db:"created_at"`
+	UpdatedAt         string        `json:"updated_at" db:"updated_at"`
+}
+
+type SwapOfferToCreateDto struct {
+	ProposerEmail  string        `json:"proposer_email" db:"proposer_email"`
+	RecipientEmail string        `json:"recipient_email" db:"recipient_email"`
+	ParentID       *int64        `json:"parent_id" db:"parent_id"`
+	Status         string        `json:"status" db:"status"`
+	CreatedAt      string        `json:"created_at" db:"created_at"`
+	UpdatedAt      string        `json:"updated_at" db:"updated_at"`
+	Posts          []SwapPostDto `json:"posts" db:"-"`
+}
+
+type CreateSwapOfferDto struct {
+	OfferedPostIDs []int64 `json:"offered_post_ids" binding:"required,min=1,max=5,dive,min=1"`
+}
+
+// CounterSwapOfferDto answers an offer with other posts. OfferedPostIDs are
+// posts of the user making the counter offer, RequestedPostIDs are posts of
+// the author of the original offer.
+type CounterSwapOfferDto struct {
+	OfferedPostIDs   []int64 `json:"offered_post_ids" binding:"required,min=1,max=5,dive,min=1"`
+	RequestedPostIDs []int64 `json:"requested_post_ids" binding:"required,min=1,max=5,dive,min=1"`
+}
@@ -1,5 +1,14 @@
 package dto
 
+// Visibility of a contact field of the user to other users.
+const (
+	VisibilityNobody = "nobody"
+	// users with an active booking on a post of the user or the owners of
+	// posts the user has booked
+	VisibilityCounterparts = "counterparts"
+	VisibilityEveryone     = "everyone"
+)
+
 type BindTokenDto struct {
 	Token string `json:"token" binding:"required"`
 }
@@ -18,32 +27,93 @@ type UserDto struct {
 	CreatedAt        string `json:"created_at" db:"created_at"`
 	UpdatedAt        string `json:"updated_at" db:"updated_at"`
 	TelegramUsername string `json:"telegram_username" db:"telegram_username" binding:"omitempty,max=32,min=4"`
-	IsAdmin          bool   `json:"is_admin" db:"is_admin" binding:"omitempty"`
+	IsAdmin          bool   `json:"is_admin" db:"is_admin" goqu:"skipinsert"`
+	IsVerified       bool   `json:"is_verified" db:"is_verified"`
+	AvatarURL        string `json:"avatar_url" db:"avatar_url"`
+	Bio              string `json:"bio" db:"bio"`
+	Faculty          string `json:"faculty" db:"faculty"`
+	// see the Visibility constants
+	EmailVisibility    string `json:"email_visibility" db:"email_visibility"`
+	TelegramVisibility string `json:"telegram_visibility" db:"telegram_visibility"`
+	// the account is deleted at this time unless the user cancels the deletion
+	DeletionScheduledAt *string `json:"deletion_scheduled_at,omitempty" db:"deletion_scheduled_at"`
+	// bookings reported by their owners as not picked up
+	NoShowCount int `json:"no_show_count" db:"no_show_count" goqu:"skipinsert"`
+	// the user can't book until this time after repeated no-shows
+	BookingSuspendedUntil *string `json:"booking_suspended_until,omitempty" db:"booking_suspended_until" goqu:"skipinsert"`
+}
+
+// ReliabilityDto tells how reliably the user picks up booked books.
+type ReliabilityDto struct {
+	CompletedBookings int64   `json:"completed_bookings"`
+	NoShows           int64   `json:"no_shows"`
+	SuspendedUntil    *string `json:"suspended_until,omitempty"`
 }
 
+// UserWithRatingDto is the profile of a user. Email and telegram username
+// are empty when their visibility settings hide them from the viewer.
 type UserWithRatingDto struct {
 	Username         string  `json:"username" db:"username"`
-	Email            string  `json:"email" db:"email"`
+	Email            string  `json:"email,omitempty" db:"email"`
 	CreatedAt        string  `json:"created_at" db:"created_at"`
 	UpdatedAt        string  `json:"updated_at" db:"updated_at"`
-	TelegramUsername string  `json:"telegram_username" db:"telegram_username" binding:"omitempty,max=32,min=4"`
+	TelegramUsername string  `json:"telegram_username,omitempty" db:"telegram_username" binding:"omitempty,max=32,min=4"`
 	IsAdmin          bool    `json:"is_admin" db:"is_admin" binding:"omitempty"`
+	IsVerified       bool    `json:"is_verified" db:"is_verified"`
+	AvatarURL        string  `json:"avatar_url" db:"avatar_url"`
+	Bio              string  `json:"bio" db:"bio"`
+	Faculty          string  `json:"faculty" db:"faculty"`
 	Rating           float64 `json:"rating"`
+	// shown to the user only
+	EmailVisibility    string `json:"email_visibility,omitempty" db:"email_visibility"`
+	TelegramVisibility string `json:"telegram_visibility,omitempty" db:"telegram_visibility"`
+	// places where the user prefers to pick up books
+	PreferredPlaces []PlaceDto     `json:"preferred_places"`
+	Reliability     ReliabilityDto `json:"reliability"`
 }
 
 type GetUserDto struct {
-	Username         string `json:"username" db:"username"`
-	Email            string `json:"email" db:"email"`
-	CreatedAt        string `json:"created_at" db:"created_at"`
-	UpdatedAt        string `json:"updated_at" db:"updated_at"`
-	TelegramUsername string `json:"telegram_username" db:"telegram_username" binding:"omitempty,max=32,min=4"`
-	IsAdmin          bool   `json:"is_admin" db:"is_admin" binding:"omitempty"`
+	Username            string  `json:"username" db:"username"`
+	Email               string  `json:"email" db:"email"`
+	CreatedAt           string  `json:"created_at" db:"created_at"`
+	UpdatedAt           string  `json:"updated_at" db:"updated_at"`
+	TelegramUsername    string  `json:"telegram_username" db:"telegram_username" binding:"omitempty,max=32,min=4"`
+	IsAdmin             bool    `json:"is_admin" db:"is_admin" binding:"omitempty"`
+	IsVerified          bool    `json:"is_verified" db:"is_verified"`
+	AvatarURL           string  `json:"avatar_url" db:"avatar_url"`
+	Bio                 string  `json:"bio" db:"bio"`
+	Faculty             string  `json:"faculty" db:"faculty"`
+	EmailVisibility     string  `json:"email_visibility" db:"email_visibility"`
+	TelegramVisibility  string  `json:"telegram_visibility" db:"telegram_visibility"`
+	DeletionScheduledAt *string `json:"deletion_scheduled_at,omitempty" db:"deletion_scheduled_at"`
 }
 
 type UpdateUserDto struct {
 	Username string `json:"username,omitempty" db:"username" binding:"omitempty,max=32,min=6"`
 	Password string `json:"password,omitempty" db:"password"`
 	// sets in service automatically
-	UpdatedAt        string `json:"updated_at,omitempty" db:"updated_at"`
-	TelegramUsername string `json:"telegram_username" db:"telegram_username" binding:"omitempty,max=32,min=4"`
+	UpdatedAt        string  `json:"updated_at,omitempty" db:"updated_at"`
+	TelegramUsername string  `json:"telegram_username" db:"telegram_username" binding:"omitempty,max=32,min=4"`
+	Bio              *string `json:"bio,omitempty" db:"bio" binding:"omitempty,max=500"`
+	Faculty          *string `json:"faculty,omitempty" db:"faculty" binding:"omitempty,max=128"`
+	// email can't be shown to everyone
+	EmailVisibility    *string `json:"email_visibility,omitempty" db:"email_visibility" binding:"omitempty,oneof=nobody counterparts"`
+	TelegramVisibility *string `json:"telegram_visibility,omitempty" db:"telegram_visibility" binding:"omitempty,oneof=nobody counterparts everyone"`
+	// replaces the preferred places of the user, an empty list removes them
+	PreferredPlaceIDs *[]int64 `json:"preferred_place_ids,omitempty" db:"-" binding:"omitempty,max=5,dive,min=1"`
+}
+
+type VerifyEmailDto struct {
+	Email string `json:"email" binding:"required,email,max=64,min=6"`
+	Code  string `json:"code" binding:"required,len=6,numeric"`
+}
+
+type ResendVerificationDto struct {
+	Email string `json:"email" binding:"required,email,max=64,min=6"`
+}
+
+// OneTimeCodeDto is a code sent by email, only its hash is stored.
+type OneTimeCodeDto struct {
+	Hash     string `redis:"hash"`
+	Attempts int    `redis:"attempts"`
 }
@@ -9,3 +9,43 @@ var ErrAuthInvalidToken = Error_{
 	StatusCode: 401,
 	Message:    "Token is invalid",
 }
+
+var ErrAuthInvalidRefreshToken = Error_{
+	StatusCode: 401,
+	Message:    "Refresh token is invalid",
+}
+
+var ErrSessionNotFound = Error_{
+	StatusCode: 404,
+	Message:    "Session not found",
+}
+
+var ErrOIDCDisabled = Error_{
+	StatusCode: 404,
+	Message:    "SSO login is not configured",
+}
+
+var ErrOIDCInvalidState = Error_{
+	StatusCode: 400,
+	Message:    "SSO login has expired, start it again",
+}
+
+var ErrOIDCLoginFailed = Error_{
+	StatusCode: 401,
+	Message:    "SSO login failed",
+}
+
+var ErrOIDCEmailMissing = Error_{
+	StatusCode: 400,
+	Message:    "Identity provider didn't share the email",
+}
+
+var ErrOIDCEmailNotVerified = Error_{
+	StatusCode: 409,
+	Message:    "Account with this email already exists, log in with password",
+}
+
+var ErrTooManyAttempts = Error_{
+	StatusCode: 429,
+	Message:    "Too many attempts, try again later",
+}
@@ -7,3 +7,39 @@ var ErrBookingNotFound = Error_{StatusCode: 404, Message: "Booking not found"}
 var ErrUserIsOwner = Error_{StatusCode: 400, Message: "User is the owner of the post"}
 
 var ErrPostIsNotAvailable = Error_{StatusCode: 400, Message: "Post is not available"}
+
+var ErrBookingInvalidTransition = Error_{StatusCode: 409, Message: "Booking status can not be changed"}
+
+var ErrUserIsNotBookingParticipant = Error_{StatusCode: 403, Message: "User is neither the booker nor the owner of the post"}
+
+var ErrAlreadyInWaitlist = Error_{StatusCode: 409, Message: "User is already in the waitlist of the post"}
+
+var ErrWaitlistEntryNotFound = Error_{StatusCode: 404, Message: "User is not in the waitlist of the post"}
+
+var ErrBookingNotConfirmed = Error_{StatusCode: 409, Message: "Booking is not confirmed by the owner"}
+
+var ErrTooManyActiveBookings = Error_{StatusCode: 409, Message: "User has too many active bookings"}
+
+var ErrBookingSuspended = Error_{StatusCode: 403, Message: "Booking is suspended for the user after repeated no-shows"}
+
+var ErrAccountIsBeingDeleted = Error_{StatusCode: 403, Message: "Account is being deleted"}
+
+var ErrNoShowTooEarly = Error_{StatusCode: 409, Message: "No-show can be reported only after the pickup slot starts or the pickup deadline passes"}
+
+var ErrSwapOfferNotFound = Error_{StatusCode: 404, Message: "Swap offer not found"}
+
+var ErrUserIsNotSwapParticipant = Error_{StatusCode: 403, Message: "User is neither the proposer nor the recipient of the swap offer"}
+
+var ErrSwapOfferNotPending = Error_{StatusCode: 409, Message: "Swap offer is no longer pending"}
+
+var ErrInvalidSwapPosts = Error_{StatusCode: 400, Message: "Swap posts must be available giveaway posts of the respective users"}
+
+var ErrSwapPostUnavailable = Error_{StatusCode: 409, Message: "One of the posts of the swap is no longer available"}
+
+var ErrPickupSlotNotAtPlace = Error_{StatusCode: 400, Message: "Pickup slot is not at the place of the post"}
+
+var ErrPickupSlotUnavailable = Error_{StatusCode: 409, Message: "Pickup slot has started or ends after the pickup deadline"}
+
+var ErrPickupSlotFull = Error_{StatusCode: 409, Message: "Pickup slot is full"}
+
+var ErrBookingHasNoPickupSlot = Error_{StatusCode: 404, Message: "No pickup slot is reserved for the booking"}
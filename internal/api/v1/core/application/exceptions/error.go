@@ -4,3 +4,9 @@ type Error_ struct {
 	StatusCode uint   `json:"status_code"`
 	Message    string `json:"message"`
 }
+
+// Error lets Error_ travel through functions returning error, e.g. out of a
+// transaction callback.
+func (e *Error_) Error() string {
+	return e.Message
+}
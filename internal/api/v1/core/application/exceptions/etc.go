@@ -15,7 +15,12 @@ var InternalServerError = Error_{
 	Message:    "Internal server error",
 }
 
-var NotAdminErorr = Error_{
-	StatusCode: 403,
-	Message:    "You are not admin",
+var ErrInvalidCursor = Error_{
+	StatusCode: 400,
+	Message:    "Invalid cursor",
+}
+
+var ErrInvalidLimit = Error_{
+	StatusCode: 400,
+	Message:    "Limit must be from 1 to 100",
 }
@@ -0,0 +1,16 @@
+package exceptions
+
+var ErrExchangeIsNotLoan = Error_{
+	StatusCode: 400,
+	Message:    "Exchange is not a loan",
+}
+
+var ErrUserIsNotLender = Error_{
+	StatusCode: 403,
+	Message:    "Only the lender can confirm the return",
+}
+
+var ErrLoanAlreadyReturned = Error_{
+	StatusCode: 409,
+	Message:    "Loan has already been returned",
+}
@@ -6,3 +6,11 @@ var ErrNotAllFields = Error_{
 	StatusCode: 400,
 	Message:    "Not all fields are filled",
 }
+
+var ErrInvalidOpeningHours = Error_{StatusCode: 400, Message: "Opening hours must close after they open"}
+
+var ErrPickupSlotNotFound = Error_{StatusCode: 404, Message: "Pickup slot not found"}
+
+var ErrInvalidPickupSlot = Error_{StatusCode: 400, Message: "Pickup slot must be in the future and within the opening hours of the place"}
+
+var ErrPickupSlotReserved = Error_{StatusCode: 409, Message: "Pickup slot is reserved by active bookings"}
@@ -13,3 +13,38 @@ var ErrUnsupportedImageType = Error_{
 	StatusCode: 415,
 	Message:    "Unsupported image type.",
 }
+
+var ErrPostStatusCannotBeChanged = Error_{
+	StatusCode: 400,
+	Message:    "Post status is changed by bookings only.",
+}
+
+var ErrPostIsBooked = Error_{
+	StatusCode: 409,
+	Message:    "Post is booked, cancel the booking first.",
+}
+
+var ErrTooManyImages = Error_{
+	StatusCode: 400,
+	Message:    "Post can have at most 5 images.",
+}
+
+var ErrImageNotFound = Error_{
+	StatusCode: 404,
+	Message:    "Image not found.",
+}
+
+var ErrInvalidImageOrder = Error_{
+	StatusCode: 400,
+	Message:    "New order must contain every image of the post exactly once.",
+}
+
+var ErrInvalidPostSort = Error_{
+	StatusCode: 400,
+	Message:    "Sort must be one of newest, oldest, title, rating.",
+}
+
+var ErrInvalidLoanPeriod = Error_{
+	StatusCode: 400,
+	Message:    "Loan period is required for loans and not allowed for giveaways.",
+}
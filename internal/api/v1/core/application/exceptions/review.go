@@ -11,3 +11,33 @@ var ErrInvalidComment = Error_{
 	StatusCode: 400,
 	Message:    "Length comment must be less than 500",
 }
+
+var ErrExchangeNotFound = Error_{
+	StatusCode: 404,
+	Message:    "Exchange not found",
+}
+
+var ErrUserIsNotExchangeParticipant = Error_{
+	StatusCode: 403,
+	Message:    "Only participants of the exchange can review it",
+}
+
+var ErrReviewAlreadyExists = Error_{
+	StatusCode: 409,
+	Message:    "You have already reviewed this exchange",
+}
+
+var ErrReviewNotFound = Error_{
+	StatusCode: 404,
+	Message:    "Review not found",
+}
+
+var ErrUserIsNotReviewAuthor = Error_{
+	StatusCode: 403,
+	Message:    "Only the author can change the review",
+}
+
+var ErrReviewEditWindowExpired = Error_{
+	StatusCode: 403,
+	Message:    "Review can no longer be changed",
+}
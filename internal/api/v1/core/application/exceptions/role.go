@@ -0,0 +1,21 @@
+package exceptions
+
+var ErrPermissionDenied = Error_{
+	StatusCode: 403,
+	Message:    "Permission denied",
+}
+
+var ErrRoleNotFound = Error_{
+	StatusCode: 404,
+	Message:    "Role not found",
+}
+
+var ErrInvalidRoleScope = Error_{
+	StatusCode: 400,
+	Message:    "place_id is required for place_manager and not allowed for other roles",
+}
+
+var ErrRevokeOwnAdminRole = Error_{
+	StatusCode: 400,
+	Message:    "You can't revoke your own admin role",
+}
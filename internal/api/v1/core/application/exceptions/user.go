@@ -8,3 +8,48 @@ var ErrUserNotFound = Error_{
 	StatusCode: 404,
 	Message:    "User not found",
 }
+
+var ErrUserNotVerified = Error_{
+	StatusCode: 403,
+	Message:    "Email is not verified",
+}
+
+var ErrUserAlreadyVerified = Error_{
+	StatusCode: 400,
+	Message:    "Email is already verified",
+}
+
+var ErrInvalidCode = Error_{
+	StatusCode: 400,
+	Message:    "Code is invalid or expired",
+}
+
+var ErrInvalidVerificationLink = Error_{
+	StatusCode: 400,
+	Message:    "Verification link is invalid or expired",
+}
+
+var ErrTooManyCodeRequests = Error_{
+	StatusCode: 429,
+	Message:    "Code was sent recently, try again later",
+}
+
+var ErrTooManyCodeAttempts = Error_{
+	StatusCode: 429,
+	Message:    "Too many attempts, request a new code",
+}
+
+var ErrAccountDeletionNotScheduled = Error_{
+	StatusCode: 404,
+	Message:    "Account deletion is not scheduled",
+}
+
+var ErrAvatarTooLarge = Error_{
+	StatusCode: 413,
+	Message:    "Avatar must be at most 10 MB",
+}
+
+var ErrAvatarDimensionsTooLarge = Error_{
+	StatusCode: 413,
+	Message:    "Avatar must be at most 40 megapixels",
+}
@@ -0,0 +1,193 @@
+package account_service
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"path"
+	"time"
+
+	"example.com/m/internal/api/v1/adapters/repositories"
+	"example.com/m/internal/api/v1/core/application/dto"
+	"example.com/m/internal/api/v1/core/application/exceptions"
+	"example.com/m/internal/api/v1/core/application/services/booking_service"
+	"example.com/m/internal/api/v1/core/application/services/user_service"
+	"example.com/m/internal/config"
+	"go.uber.org/zap"
+)
+
+type AccountService struct {
+	us  *user_service.UserService
+	bs  *booking_service.BookingService
+	ur  *repositories.UserRepository
+	der *repositories.DataExportRepository
+	pr  *repositories.PostRepository
+	sr  *repositories.SessionRepository
+	ptr *repositories.PushTokenRepository
+	mr  *repositories.MailRepository
+	ar  *repositories.AvatarRepository
+
+	logger *zap.Logger
+}
+
+func NewAccountService(
+	us *user_service.UserService, bs *booking_service.BookingService,
+	ur *repositories.UserRepository, der *repositories.DataExportRepository,
+	pr *repositories.PostRepository, sr *repositories.SessionRepository,
+	ptr *repositories.PushTokenRepository, mr *repositories.MailRepository,
+	ar *repositories.AvatarRepository, logger *zap.Logger,
+) *AccountService {
+	return &AccountService{us: us, bs: bs, ur: ur, der: der, pr: pr, sr: sr, ptr: ptr, mr: mr, ar: ar, logger: logger}
+}
+
+// ExportData returns a ZIP archive with a JSON file per kind of the user's
+// data and the images of the user's posts under images/<post id>/.
+func (s *AccountService) ExportData(ctx context.Context, email string) ([]byte, *exceptions.Error_) {
+	if _, exc := s.us.GetUserByEmail(ctx, email); exc != nil {
+		return nil, exc
+	}
+
+	files, err := s.der.Export(ctx, email)
+	if err != nil {
+		return nil, &exceptions.ErrDatabaseError
+	}
+
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	for _, file := range files {
+		w, err := archive.Create(file.Name)
+		if err != nil {
+			return nil, &exceptions.InternalServerError
+		}
+		if _, err := w.Write(file.Data); err != nil {
+			return nil, &exceptions.InternalServerError
+		}
+
+		if file.Name != "posts.json" {
+			continue
+		}
+		var posts []struct {
+			ID     int64    `json:"id"`
+			Images []string `json:"images"`
+		}
+		if err := json.Unmarshal(file.Data, &posts); err != nil {
+			return nil, &exceptions.InternalServerError
+		}
+		for _, post := range posts {
+			for _, image := range post.Images {
+				data, err := s.pr.DownloadImage(ctx, image)
+				if err != nil {
+					return nil, &exceptions.ErrServiceUnavailable
+				}
+				w, err := archive.Create(fmt.Sprintf("images/%d/%s", post.ID, path.Base(image)))
+				if err != nil {
+					return nil, &exceptions.InternalServerError
+				}
+				if _, err := w.Write(data); err != nil {
+					return nil, &exceptions.InternalServerError
+				}
+			}
+		}
+	}
+	if err := archive.Close(); err != nil {
+		return nil, &exceptions.InternalServerError
+	}
+	return buf.Bytes(), nil
+}
+
+// ScheduleDeletion deletes the account after the grace period, until then the
+// user may cancel it. Requesting the deletion again keeps the first date.
+func (s *AccountService) ScheduleDeletion(ctx context.Context, email string) (*dto.AccountDeletionDto, *exceptions.Error_) {
+	user, exc := s.us.GetUserByEmail(ctx, email)
+	if exc != nil {
+		return nil, exc
+	}
+	if user.DeletionScheduledAt != nil {
+		return &dto.AccountDeletionDto{DeletionScheduledAt: *user.DeletionScheduledAt}, nil
+	}
+
+	at := time.Now().UTC().Add(config.Config.AccountDeletionGracePeriod).Format("2006-01-02T15:04:05Z")
+	if err := s.ur.SetDeletionScheduledAt(ctx, email, &at); err != nil {
+		return nil, &exceptions.ErrDatabaseError
+	}
+
+	body := fmt.Sprintf(
+		"Здравствуйте, %s!\n\nВаш аккаунт будет удален %s (UTC). До этого момента удаление можно отменить в настройках профиля.\n\nОтзывы, которые вы оставили, сохранятся без указания автора.",
+		user.Username, at,
+	)
+	// the deletion is scheduled anyway
+	_ = s.mr.Send(ctx, email, "Удаление аккаунта", body)
+
+	return &dto.AccountDeletionDto{DeletionScheduledAt: at}, nil
+}
+
+func (s *AccountService) CancelDeletion(ctx context.Context, email string) *exceptions.Error_ {
+	user, exc := s.us.GetUserByEmail(ctx, email)
+	if exc != nil {
+		return exc
+	}
+	if user.DeletionScheduledAt == nil {
+		return &exceptions.ErrAccountDeletionNotScheduled
+	}
+
+	if err := s.ur.SetDeletionScheduledAt(ctx, email, nil); err != nil {
+		return &exceptions.ErrDatabaseError
+	}
+	return nil
+}
+
+// DeleteScheduledAccounts deletes accounts whose grace period is over. It is
+// run periodically by a background worker. An account which fails to be
+// deleted is logged and retried on the next run, the others are still deleted.
+func (s *AccountService) DeleteScheduledAccounts(ctx context.Context) *exceptions.Error_ {
+	now := time.Now().UTC().Format("2006-01-02T15:04:05Z")
+	emails, err := s.ur.GetScheduledForDeletion(ctx, now)
+	if err != nil {
+		return &exceptions.ErrDatabaseError
+	}
+
+	for _, email := range emails {
+		if exc := s.deleteAccount(ctx, email); exc != nil {
+			s.logger.Error(
+				"Failed to delete scheduled account",
+				zap.String("email", email),
+				zap.String("error", exc.Message),
+			)
+		}
+	}
+	return nil
+}
+
+func (s *AccountService) deleteAccount(ctx context.Context, email string) *exceptions.Error_ {
+	user, exc := s.us.GetUserByEmail(ctx, email)
+	if exc != nil {
+		return exc
+	}
+	images, err := s.pr.GetImagesByUser(ctx, email)
+	if err != nil {
+		return &exceptions.ErrDatabaseError
+	}
+
+	// the bookings, waitlists and swaps of other users don't survive the
+	// cascade of the account deletion, so they are ended properly first
+	if exc := s.bs.ReleaseAccount(ctx, email); exc != nil {
+		return exc
+	}
+	if err := s.ur.Delete(ctx, email); err != nil {
+		return &exceptions.ErrDatabaseError
+	}
+
+	// the account is already gone, leftovers in the storage and Redis are
+	// only logged by the repositories
+	for _, image := range images {
+		_ = s.pr.DeleteImage(ctx, image)
+	}
+	if user.AvatarURL != "" {
+		_ = s.ar.Delete(ctx, user.AvatarURL)
+	}
+	_ = s.sr.DeleteAllByEmail(&ctx, email)
+	_ = s.ptr.DeleteByEmail(&ctx, email)
+	return nil
+}
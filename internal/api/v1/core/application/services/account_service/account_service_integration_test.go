@@ -0,0 +1,152 @@
+//go:build integration
+
+package account_service
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"testing"
+	"time"
+
+	"example.com/m/internal/api/v1/adapters/repositories"
+	"example.com/m/internal/api/v1/core/application/exceptions"
+	"example.com/m/internal/api/v1/core/application/services/booking_service"
+	"example.com/m/internal/api/v1/core/application/services/user_service"
+	"example.com/m/internal/api/v1/infrastructure/mail"
+	"example.com/m/internal/api/v1/testdb"
+	"example.com/m/internal/config"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+func newTestAccountService(db *sql.DB) *AccountService {
+	logger := zap.NewNop()
+	// push tokens are never found, so no notifications are sent
+	rdb := redis.NewClient(&redis.Options{Addr: "127.0.0.1:1", MaxRetries: -1})
+	userRepository := repositories.NewUserRepository(db, logger)
+	pushTokenRepository := repositories.NewPushTokenRepository(rdb, logger)
+	codeRepository := repositories.NewCodeRepository(rdb, logger)
+	mailRepository := repositories.NewMailRepository(mail.NewLogMailer("", logger), logger)
+	avatarRepository := repositories.NewAvatarRepository(logger, nil)
+	userService := user_service.NewUserService(
+		userRepository, pushTokenRepository, codeRepository, mailRepository, avatarRepository,
+	)
+	bookingService := booking_service.NewBookingService(
+		*repositories.NewBookingRepository(db, logger),
+		*repositories.NewWaitlistRepository(db, logger),
+		*userService,
+		repositories.NewUnitOfWork(db, logger),
+		nil,
+		pushTokenRepository,
+		codeRepository,
+		logger,
+	)
+
+	return NewAccountService(
+		userService, bookingService, userRepository,
+		repositories.NewDataExportRepository(db, logger), repositories.NewPostRepository(db, logger, nil),
+		repositories.NewSessionRepository(rdb, logger), pushTokenRepository,
+		mailRepository, avatarRepository, logger,
+	)
+}
+
+// A deleted account takes its posts with it, while the posts it booked go to
+// the next users in the waitlists and its swap partners get their posts back.
+func TestDeleteScheduledAccountsReleasesBookings(t *testing.T) {
+	db := testdb.Open(t)
+	s := newTestAccountService(db)
+	ctx := context.Background()
+	config.Config.BookingApprovalDeadline = time.Hour
+	config.Config.MaxActiveBookings = 10
+
+	suffix := time.Now().UnixNano()
+	deleted := fmt.Sprintf("deleted%d@test.com", suffix)
+	owner := fmt.Sprintf("owner%d@test.com", suffix)
+	waiting := fmt.Sprintf("waiting%d@test.com", suffix)
+	booker := fmt.Sprintf("booker%d@test.com", suffix)
+	partner := fmt.Sprintf("partner%d@test.com", suffix)
+	testdb.CreateUsers(t, db, deleted, owner, waiting, booker, partner)
+
+	bookedPost := testdb.CreatePost(t, db, owner)
+	ownPost := testdb.CreatePost(t, db, deleted)
+	swappedOwnPost := testdb.CreatePost(t, db, deleted)
+	swappedPartnerPost := testdb.CreatePost(t, db, partner)
+
+	for _, b := range []struct {
+		email  string
+		postID int64
+	}{
+		{deleted, bookedPost},
+		{waiting, bookedPost},
+		{booker, ownPost},
+		{deleted, swappedPartnerPost},
+		{partner, swappedOwnPost},
+	} {
+		if _, _, exc := s.bs.BookBook(ctx, b.email, b.postID); exc != nil {
+			t.Fatalf("got error %v", exc.Message)
+		}
+	}
+
+	now := time.Now().UTC().Format("2006-01-02T15:04:05Z")
+	var swapID int64
+	err := db.QueryRow(
+		"INSERT INTO swap_offers (proposer_email, recipient_email, status, created_at, updated_at) VALUES ($1, $2, 'accepted', $3, $3) RETURNING id",
+		deleted, partner, now,
+	).Scan(&swapID)
+	if err != nil {
+		t.Fatalf("got error %v", err)
+	}
+	_, err = db.Exec("UPDATE bookings SET swap_id = $1 WHERE post_id IN ($2, $3)", swapID, swappedOwnPost, swappedPartnerPost)
+	if err != nil {
+		t.Fatalf("got error %v", err)
+	}
+
+	past := time.Now().UTC().Add(-time.Minute).Format("2006-01-02T15:04:05Z")
+	if _, err := db.Exec("UPDATE users SET deletion_scheduled_at = $1 WHERE email = $2", past, deleted); err != nil {
+		t.Fatalf("got error %v", err)
+	}
+	if _, _, exc := s.bs.BookBook(ctx, deleted, swappedPartnerPost); exc != &exceptions.ErrAccountIsBeingDeleted {
+		t.Errorf("got %v want ErrAccountIsBeingDeleted", exc)
+	}
+
+	if exc := s.DeleteScheduledAccounts(ctx); exc != nil {
+		t.Fatalf("got error %v", exc.Message)
+	}
+
+	var users int
+	if err := db.QueryRow("SELECT COUNT(*) FROM users WHERE email = $1", deleted).Scan(&users); err != nil {
+		t.Fatalf("got error %v", err)
+	}
+	if users != 0 {
+		t.Errorf("the account is not deleted")
+	}
+
+	var posts int
+	if err := db.QueryRow("SELECT COUNT(*) FROM posts WHERE id IN ($1, $2)", ownPost, swappedOwnPost).Scan(&posts); err != nil {
+		t.Fatalf("got error %v", err)
+	}
+	if posts != 0 {
+		t.Errorf("got %d posts of the account want 0", posts)
+	}
+
+	var status string
+	if err := db.QueryRow("SELECT status FROM posts WHERE id = $1", swappedPartnerPost).Scan(&status); err != nil {
+		t.Fatalf("got error %v", err)
+	}
+	if status != "available" {
+		t.Errorf("got status %s of the swapped post want available", status)
+	}
+
+	var bookedBy string
+	err = db.QueryRow(
+		"SELECT user_email FROM bookings WHERE post_id = $1 AND status IN ('pending', 'confirmed')",
+		bookedPost,
+	).Scan(&bookedBy)
+	if err != nil {
+		t.Fatalf("got error %v", err)
+	}
+	if bookedBy != waiting {
+		t.Errorf("got booking of %s want %s", bookedBy, waiting)
+	}
+}
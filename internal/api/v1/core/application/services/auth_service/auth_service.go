@@ -2,6 +2,12 @@ package auth_service
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"sort"
+	"strings"
 	"time"
 
 	"example.com/m/internal/api/v1/adapters/repositories"
@@ -11,25 +17,46 @@ import (
 	"example.com/m/internal/api/v1/utils"
 	"example.com/m/internal/config"
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
 	"golang.org/x/crypto/bcrypt"
 )
 
+const (
+	// sessionTouchInterval is how often the last activity of a session is saved.
+	sessionTouchInterval = time.Minute
+	// oidcLoginTTL is how long the user has to log in at the identity provider.
+	oidcLoginTTL = 10 * time.Minute
+)
+
 type AuthService struct {
 	us user_service.UserService
-	tr repositories.TokenRepository
+	sr repositories.SessionRepository
+	ir *repositories.IdentityRepository
+	or *repositories.OIDCRepository
+
+	logger *zap.Logger
 }
 
-func NewAuthService(us *user_service.UserService, tr *repositories.TokenRepository) *AuthService {
+func NewAuthService(
+	us *user_service.UserService, sr *repositories.SessionRepository,
+	ir *repositories.IdentityRepository, or *repositories.OIDCRepository,
+	logger *zap.Logger,
+) *AuthService {
 	return &AuthService{
-		us: *us,
-		tr: *tr,
+		us:     *us,
+		sr:     *sr,
+		ir:     ir,
+		or:     or,
+		logger: logger,
 	}
 }
 
-func generateAndSignToken(email string, username string) (*string, error) {
+func generateAndSignToken(email string, username string, sessionID string) (*string, error) {
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
 		"email":    email,
 		"username": username,
+		"sid":      sessionID,
 		"exp":      time.Now().UTC().Add(config.Config.JWTExpiration).Unix(),
 		"iat":      time.Now().UTC().Unix(),
 	})
@@ -42,7 +69,48 @@ func generateAndSignToken(email string, username string) (*string, error) {
 	return &tokenString, nil
 }
 
-func (s *AuthService) Authorize(ctx context.Context, email string, password string) (*string, *exceptions.Error_) {
+func generateRandomString() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// generateRefreshSecret returns a random secret and its hash, only the hash is
+// stored in the session.
+func generateRefreshSecret() (string, string, error) {
+	secret, err := generateRandomString()
+	if err != nil {
+		return "", "", err
+	}
+	return secret, utils.HashCode(secret), nil
+}
+
+// A refresh token is "<session id>.<secret>".
+func parseRefreshToken(token string) (string, string, bool) {
+	sessionID, secret, ok := strings.Cut(token, ".")
+	if !ok || sessionID == "" || secret == "" {
+		return "", "", false
+	}
+	return sessionID, secret, true
+}
+
+func (s *AuthService) issueTokens(user *dto.UserDto, sessionID string, secret string) (*dto.TokensDto, *exceptions.Error_) {
+	token, err := generateAndSignToken(user.Email, user.Username, sessionID)
+	if err != nil {
+		return nil, &exceptions.ErrServiceUnavailable
+	}
+
+	return &dto.TokensDto{
+		Token:        *token,
+		RefreshToken: sessionID + "." + secret,
+		ExpiresIn:    int64(config.Config.JWTExpiration.Seconds()),
+	}, nil
+}
+
+// Authorize starts a new session, sessions on other devices stay active.
+func (s *AuthService) Authorize(ctx context.Context, email string, password string, meta dto.SessionMetaDto) (*dto.TokensDto, *exceptions.Error_) {
 	user, exception := s.us.GetUserByEmail(ctx, email)
 	if exception != nil {
 		if exception.StatusCode == 404 {
@@ -55,30 +123,337 @@ func (s *AuthService) Authorize(ctx context.Context, email string, password stri
 		return nil, &exceptions.ErrAuthWrongCredentials
 	}
 
-	token, err := generateAndSignToken(user.Email, user.Username)
+	return s.startSession(ctx, user, meta)
+}
+
+func (s *AuthService) startSession(ctx context.Context, user *dto.UserDto, meta dto.SessionMetaDto) (*dto.TokensDto, *exceptions.Error_) {
+	secret, hash, err := generateRefreshSecret()
 	if err != nil {
 		return nil, &exceptions.ErrServiceUnavailable
 	}
 
-	err = s.tr.Set(&ctx, email, *token)
+	now := time.Now().UTC().Format("2006-01-02T15:04:05Z")
+	session := &dto.SessionDto{
+		ID:               uuid.NewString(),
+		Email:            user.Email,
+		RefreshTokenHash: hash,
+		DeviceName:       meta.DeviceName,
+		IP:               meta.IP,
+		UserAgent:        meta.UserAgent,
+		CreatedAt:        now,
+		LastSeenAt:       now,
+	}
+	if err := s.sr.Create(&ctx, session); err != nil {
+		return nil, &exceptions.ErrServiceUnavailable
+	}
+
+	return s.issueTokens(user, session.ID, secret)
+}
+
+// StartOIDCLogin returns the address of the identity provider's login page.
+// The PKCE verifier and the nonce are kept until the provider redirects the
+// user back to FinishOIDCLogin with the state.
+func (s *AuthService) StartOIDCLogin(ctx context.Context, deviceName string) (string, *exceptions.Error_) {
+	if !s.or.Enabled() {
+		return "", &exceptions.ErrOIDCDisabled
+	}
+
+	var values [3]string
+	for i := range values {
+		value, err := generateRandomString()
+		if err != nil {
+			return "", &exceptions.InternalServerError
+		}
+		values[i] = value
+	}
+	state, nonce, verifier := values[0], values[1], values[2]
+
+	login := &dto.OIDCLoginDto{Verifier: verifier, Nonce: nonce, DeviceName: deviceName}
+	if err := s.or.SaveLogin(&ctx, state, login, oidcLoginTTL); err != nil {
+		return "", &exceptions.ErrServiceUnavailable
+	}
+
+	challenge := sha256.Sum256([]byte(verifier))
+	url, err := s.or.AuthCodeURL(ctx, state, nonce, base64.RawURLEncoding.EncodeToString(challenge[:]))
+	if err != nil {
+		return "", &exceptions.ErrServiceUnavailable
+	}
+	return url, nil
+}
+
+// FinishOIDCLogin redeems the authorization code and starts a session of the
+// user linked to the identity, the same way Authorize does.
+func (s *AuthService) FinishOIDCLogin(ctx context.Context, state string, code string, meta dto.SessionMetaDto) (*dto.TokensDto, *exceptions.Error_) {
+	if !s.or.Enabled() {
+		return nil, &exceptions.ErrOIDCDisabled
+	}
+
+	login, err := s.or.TakeLogin(&ctx, state)
 	if err != nil {
 		return nil, &exceptions.ErrServiceUnavailable
 	}
+	if login == nil {
+		return nil, &exceptions.ErrOIDCInvalidState
+	}
+
+	claims, err := s.or.Exchange(ctx, code, login.Verifier, login.Nonce)
+	if err != nil {
+		return nil, &exceptions.ErrOIDCLoginFailed
+	}
+
+	user, exception := s.userByIdentity(ctx, claims)
+	if exception != nil {
+		return nil, exception
+	}
+
+	meta.DeviceName = login.DeviceName
+	return s.startSession(ctx, user, meta)
+}
+
+// userByIdentity finds the user linked to the identity. On first login the
+// identity is linked to the account with the same email if the provider has
+// verified it, otherwise a new account is created. An unverified account may
+// have been registered by someone else to take it over later, so it loses its
+// password and sessions before it is linked.
+func (s *AuthService) userByIdentity(ctx context.Context, claims *dto.OIDCClaimsDto) (*dto.UserDto, *exceptions.Error_) {
+	email, err := s.ir.GetUserEmail(ctx, claims.Issuer, claims.Subject)
+	if err != nil {
+		return nil, &exceptions.ErrDatabaseError
+	}
+	if email != nil {
+		return s.us.GetUserByEmail(ctx, *email)
+	}
+
+	if claims.Email == "" {
+		return nil, &exceptions.ErrOIDCEmailMissing
+	}
+
+	user, exception := s.us.GetUserByEmail(ctx, claims.Email)
+	if exception != nil && exception.StatusCode != 404 {
+		return nil, exception
+	}
+	if user == nil {
+		user, exception = s.us.ProvisionUser(ctx, claims.Email, claims.PreferredUsername, claims.EmailVerified)
+		if exception != nil {
+			return nil, exception
+		}
+	} else {
+		if !claims.EmailVerified {
+			return nil, &exceptions.ErrOIDCEmailNotVerified
+		}
+		if !user.IsVerified {
+			if exception := s.us.ClaimUnverified(ctx, user.Email); exception != nil {
+				return nil, exception
+			}
+			user.IsVerified = true
+			user.Password = ""
+		}
+		// sessions started with the removed password are ended too. This is
+		// repeated on the next login if it fails after the account was claimed.
+		if user.Password == "" {
+			if err := s.sr.DeleteAllByEmail(&ctx, user.Email); err != nil {
+				return nil, &exceptions.ErrServiceUnavailable
+			}
+		}
+	}
 
-	return token, nil
+	err = s.ir.Create(ctx, &dto.UserIdentityDto{
+		Issuer:    claims.Issuer,
+		Subject:   claims.Subject,
+		UserEmail: user.Email,
+		CreatedAt: time.Now().UTC().Format("2006-01-02T15:04:05Z"),
+	})
+	if err != nil {
+		return nil, &exceptions.ErrDatabaseError
+	}
+	return user, nil
 }
 
-func (s *AuthService) CheckTokenExistance(ctx context.Context, email string, token string) *exceptions.Error_ {
-	foundToken, err := s.tr.GetByEmail(&ctx, email)
+// Refresh exchanges a refresh token for a new pair of tokens. A refresh token
+// can be used once: presenting an already rotated one means it was stolen, so
+// the whole session is revoked.
+func (s *AuthService) Refresh(ctx context.Context, refreshToken string) (*dto.TokensDto, *exceptions.Error_) {
+	sessionID, secret, ok := parseRefreshToken(refreshToken)
+	if !ok {
+		return nil, &exceptions.ErrAuthInvalidRefreshToken
+	}
+
+	session, err := s.sr.Get(&ctx, sessionID)
+	if err != nil {
+		return nil, &exceptions.ErrServiceUnavailable
+	}
+	if session == nil {
+		return nil, &exceptions.ErrAuthInvalidRefreshToken
+	}
+
+	hash := utils.HashCode(secret)
+	if subtle.ConstantTimeCompare([]byte(hash), []byte(session.RefreshTokenHash)) != 1 {
+		if err := s.sr.Delete(&ctx, session.Email, session.ID); err != nil {
+			return nil, &exceptions.ErrServiceUnavailable
+		}
+		return nil, &exceptions.ErrAuthInvalidRefreshToken
+	}
+
+	user, exception := s.us.GetUserByEmail(ctx, session.Email)
+	if exception != nil {
+		if exception.StatusCode == 404 {
+			return nil, &exceptions.ErrAuthInvalidRefreshToken
+		}
+		return nil, exception
+	}
+
+	newSecret, newHash, err := generateRefreshSecret()
+	if err != nil {
+		return nil, &exceptions.ErrServiceUnavailable
+	}
+	rotated, err := s.sr.Rotate(&ctx, session, hash, newHash)
+	if err != nil {
+		return nil, &exceptions.ErrServiceUnavailable
+	}
+	if !rotated {
+		return nil, &exceptions.ErrAuthInvalidRefreshToken
+	}
+
+	return s.issueTokens(user, session.ID, newSecret)
+}
+
+// CheckSession makes sure the session of an access token is still active.
+func (s *AuthService) CheckSession(ctx context.Context, email string, sessionID string) (*dto.SessionDto, *exceptions.Error_) {
+	if sessionID == "" {
+		return nil, &exceptions.ErrAuthInvalidToken
+	}
+
+	session, err := s.sr.Get(&ctx, sessionID)
+	if err != nil {
+		return nil, &exceptions.ErrServiceUnavailable
+	}
+
+	if session == nil || session.Email != email {
+		return nil, &exceptions.ErrAuthInvalidToken
+	}
+	return session, nil
+}
+
+// TouchSession records the last activity of the session. To spare Redis a write
+// on every request it is done at most once per sessionTouchInterval unless the
+// client has changed.
+func (s *AuthService) TouchSession(ctx context.Context, session *dto.SessionDto, meta dto.SessionMetaDto) {
+	now := time.Now().UTC()
+	lastSeenAt, err := time.Parse("2006-01-02T15:04:05Z", session.LastSeenAt)
+	if err == nil && now.Sub(lastSeenAt) < sessionTouchInterval &&
+		session.IP == meta.IP && session.UserAgent == meta.UserAgent {
+		return
+	}
+
+	// the request is served even if the activity wasn't saved
+	_ = s.sr.Touch(&ctx, session.ID, now.Format("2006-01-02T15:04:05Z"), meta.IP, meta.UserAgent)
+}
+
+// GetSessions returns active sessions of the user, recently used first.
+func (s *AuthService) GetSessions(ctx context.Context, email string, currentSessionID string) ([]dto.SessionDto, *exceptions.Error_) {
+	sessions, err := s.sr.GetAllByEmail(&ctx, email)
+	if err != nil {
+		return nil, &exceptions.ErrServiceUnavailable
+	}
+
+	for i := range sessions {
+		sessions[i].Current = sessions[i].ID == currentSessionID
+	}
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].LastSeenAt > sessions[j].LastSeenAt
+	})
+	return sessions, nil
+}
+
+// RevokeSession ends one session of the user, e.g. on a lost device.
+func (s *AuthService) RevokeSession(ctx context.Context, email string, sessionID string) *exceptions.Error_ {
+	session, err := s.sr.Get(&ctx, sessionID)
 	if err != nil {
 		return &exceptions.ErrServiceUnavailable
 	}
+	if session == nil || session.Email != email {
+		return &exceptions.ErrSessionNotFound
+	}
 
-	if foundToken == nil || *foundToken != token {
-		return &exceptions.ErrAuthInvalidToken
+	if err := s.sr.Delete(&ctx, email, sessionID); err != nil {
+		return &exceptions.ErrServiceUnavailable
 	}
 	return nil
 }
+
+// RevokeOtherSessions ends all sessions of the user except the current one.
+func (s *AuthService) RevokeOtherSessions(ctx context.Context, email string, currentSessionID string) *exceptions.Error_ {
+	sessions, err := s.sr.GetAllByEmail(&ctx, email)
+	if err != nil {
+		return &exceptions.ErrServiceUnavailable
+	}
+
+	for _, session := range sessions {
+		if session.ID == currentSessionID {
+			continue
+		}
+		if err := s.sr.Delete(&ctx, email, session.ID); err != nil {
+			return &exceptions.ErrServiceUnavailable
+		}
+	}
+	return nil
+}
+
+func (s *AuthService) Logout(ctx context.Context, email string, sessionID string) *exceptions.Error_ {
+	if err := s.sr.Delete(&ctx, email, sessionID); err != nil {
+		return &exceptions.ErrServiceUnavailable
+	}
+	return nil
+}
+
+// LogoutEverywhere ends all sessions of the user, including the current one.
+func (s *AuthService) LogoutEverywhere(ctx context.Context, email string) *exceptions.Error_ {
+	if err := s.sr.DeleteAllByEmail(&ctx, email); err != nil {
+		return &exceptions.ErrServiceUnavailable
+	}
+	return nil
+}
+
+// ForgotPassword emails a password reset code. It doesn't report failures:
+// an unknown email, a throttled request and a failed delivery all look the
+// same to the caller, so the response doesn't disclose whether the email is
+// registered. Delivery failures are logged.
+func (s *AuthService) ForgotPassword(ctx context.Context, email string) {
+	exception := s.us.SendPasswordReset(ctx, email)
+	if exception != nil && exception.StatusCode != 404 && exception.StatusCode != 429 {
+		s.logger.Error(
+			"Failed to send password reset code",
+			zap.Int("status", int(exception.StatusCode)),
+			zap.String("error", exception.Message),
+		)
+	}
+}
+
+// ResetPassword sets a new password using the code sent by ForgotPassword and
+// ends all sessions of the user.
+func (s *AuthService) ResetPassword(ctx context.Context, email string, code string, newPassword string) *exceptions.Error_ {
+	if exception := s.us.ConsumeCode(ctx, repositories.CodePurposePasswordReset, email, code); exception != nil {
+		return exception
+	}
+
+	newHashedPassword, err := utils.HashPassword(newPassword)
+	if err != nil {
+		return &exceptions.ErrServiceUnavailable
+	}
+
+	_, exception := s.us.UpdateUserByEmail(ctx, email, dto.UpdateUserDto{
+		Password: newHashedPassword,
+	})
+	if exception != nil {
+		return exception
+	}
+
+	if err := s.sr.DeleteAllByEmail(&ctx, email); err != nil {
+		return &exceptions.ErrServiceUnavailable
+	}
+	return nil
+}
+
 func (s *AuthService) ChangePassword(ctx context.Context, email string, oldPassword string, newPassword string) *exceptions.Error_ {
 	if oldPassword == newPassword {
 		return &exceptions.ErrAuthWrongCredentials
@@ -108,7 +483,7 @@ func (s *AuthService) ChangePassword(ctx context.Context, email string, oldPassw
 		return exception
 	}
 
-	err = s.tr.DeleteByEmail(&ctx, email)
+	err = s.sr.DeleteAllByEmail(&ctx, email)
 	if err != nil {
 		return &exceptions.ErrServiceUnavailable
 	}
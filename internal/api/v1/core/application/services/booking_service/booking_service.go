@@ -2,169 +2,1167 @@ package booking_service
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
 	"time"
 
 	"example.com/m/internal/api/v1/adapters/repositories"
 	"example.com/m/internal/api/v1/core/application/dto"
 	"example.com/m/internal/api/v1/core/application/exceptions"
-	"example.com/m/internal/api/v1/core/application/services/post_service"
 	"example.com/m/internal/api/v1/core/application/services/user_service"
+	"example.com/m/internal/api/v1/utils"
+	"example.com/m/internal/config"
+	"go.uber.org/zap"
 )
 
+const handoverCodeLength = 6
+
+// how many pickups are reminded about per check
+const pickupReminderBatchSize = 100
+
+// bookingTransitions lists the statuses a booking may move to from each status.
+// Rejected, expired, cancelled, completed and no-show bookings are final.
+var bookingTransitions = map[string][]string{
+	dto.BookingStatusPending: {
+		dto.BookingStatusConfirmed,
+		dto.BookingStatusRejected,
+		dto.BookingStatusExpired,
+		dto.BookingStatusCancelled,
+	},
+	dto.BookingStatusConfirmed: {
+		dto.BookingStatusExpired,
+		dto.BookingStatusCancelled,
+		dto.BookingStatusCompleted,
+		dto.BookingStatusNoShow,
+	},
+}
+
+func canTransition(from string, to string) bool {
+	for _, status := range bookingTransitions[from] {
+		if status == to {
+			return true
+		}
+	}
+	return false
+}
+
 type BookingService struct {
 	br  repositories.BookingRepository
+	wr  repositories.WaitlistRepository
 	us  user_service.UserService
-	ps  post_service.PostService
+	uow *repositories.UnitOfWork
 	fr  *repositories.FcmRepository
 	ptr *repositories.PushTokenRepository
+	cr  *repositories.CodeRepository
+
+	logger *zap.Logger
 }
 
-func NewBookingService(br repositories.BookingRepository, us user_service.UserService, ps post_service.PostService, fr *repositories.FcmRepository, ptr *repositories.PushTokenRepository) *BookingService {
+func NewBookingService(br repositories.BookingRepository, wr repositories.WaitlistRepository, us user_service.UserService, uow *repositories.UnitOfWork, fr *repositories.FcmRepository, ptr *repositories.PushTokenRepository, cr *repositories.CodeRepository, logger *zap.Logger) *BookingService {
 	return &BookingService{
-		br:  br,
-		us:  us,
-		ps:  ps,
-		fr:  fr,
-		ptr: ptr,
+		br:     br,
+		wr:     wr,
+		us:     us,
+		uow:    uow,
+		fr:     fr,
+		ptr:    ptr,
+		cr:     cr,
+		logger: logger,
+	}
+}
+
+func (bs *BookingService) notify(ctx context.Context, email string, notification *dto.NotificationDto) {
+	token, err := bs.ptr.GetByEmail(&ctx, email)
+	if err != nil || token == nil {
+		return
+	}
+
+	bs.fr.SendByToken(ctx, *token, notification)
+}
+
+// inTx runs fn in a transaction. fn may return *exceptions.Error_ to roll the
+// transaction back with that error; any other error becomes a database error.
+func (bs *BookingService) inTx(ctx context.Context, fn func(tx *repositories.Tx) error) *exceptions.Error_ {
+	err := bs.uow.Do(ctx, fn)
+	if err == nil {
+		return nil
 	}
+
+	var exc *exceptions.Error_
+	if errors.As(err, &exc) {
+		return exc
+	}
+	return &exceptions.ErrDatabaseError
 }
 
-func (bs *BookingService) BookBook(ctx context.Context, userEmail string, postID int64) *exceptions.Error_ {
+// lockPost locks the post until the end of the transaction, so that bookings
+// of the same post are processed one at a time.
+func lockPost(ctx context.Context, tx *repositories.Tx, postID int64) (*dto.PostDto, error) {
+	post, err := tx.Posts.GetForUpdate(ctx, postID)
+	if err != nil {
+		return nil, err
+	}
+	if post == nil {
+		return nil, &exceptions.PostNotFoudErr
+	}
+	return post, nil
+}
+
+// changeStatus moves the booking to the given status. When postStatus is not
+// empty the post status is updated as well.
+func changeStatus(ctx context.Context, tx *repositories.Tx, booking *dto.BookingDto, status string, expiresAt string, postStatus string) error {
+	if !canTransition(booking.Status, status) {
+		return &exceptions.ErrBookingInvalidTransition
+	}
+
+	update := &dto.UpdateBookingDto{
+		Status:    status,
+		ExpiresAt: expiresAt,
+		UpdatedAt: time.Now().UTC().Format("2006-01-02T15:04:05Z"),
+	}
+	if err := tx.Bookings.Update(ctx, booking.ID, update); err != nil {
+		return err
+	}
+	if postStatus != "" {
+		if err := tx.Posts.Update(ctx, booking.PostID, &dto.UpdatePostDto{Status: postStatus}); err != nil {
+			return err
+		}
+	}
+
+	booking.Status = status
+	booking.UpdatedAt = update.UpdatedAt
+	if expiresAt != "" {
+		booking.ExpiresAt = expiresAt
+	}
+	return nil
+}
+
+// BookBook books the post for the user. When the post is already booked by
+// someone else the user joins the waitlist of the post instead, in which case
+// the waitlist entry is returned rather than a booking. Users suspended for
+// no-shows or holding too many active bookings can do neither.
+func (bs *BookingService) BookBook(ctx context.Context, userEmail string, postID int64) (*dto.BookingDto, *dto.WaitlistEntryDto, *exceptions.Error_) {
 	// Check if user exists
-	_, exc := bs.us.GetUserByEmail(ctx, userEmail)
+	user, exc := bs.us.GetUserByEmail(ctx, userEmail)
 	if exc != nil {
-		return exc
+		return nil, nil, exc
 	}
+	if !user.IsVerified {
+		return nil, nil, &exceptions.ErrUserNotVerified
+	}
+
+	var post *dto.PostDto
+	var booking *dto.BookingDto
+	var entry *dto.WaitlistEntryDto
+	exc = bs.inTx(ctx, func(tx *repositories.Tx) error {
+		var err error
+		post, err = lockPost(ctx, tx, postID)
+		if err != nil {
+			return err
+		}
+
+		if post.UserEmail == userEmail {
+			return &exceptions.ErrUserIsOwner
+		}
+
+		// Check if this post has been booked
+		existingBooking, err := tx.Bookings.GetByPostID(ctx, postID)
+		if err != nil {
+			return err
+		}
+
+		if existingBooking != nil {
+			if existingBooking.UserEmail == userEmail {
+				return &exceptions.ErrBookingAlreadyExists
+			}
+			entry, err = joinWaitlist(ctx, tx, userEmail, postID)
+			return err
+		}
+
+		if post.Status != "available" {
+			return &exceptions.ErrPostIsNotAvailable
+		}
 
-	// Check if post exists
-	post, exc := bs.ps.GetPost(ctx, postID, userEmail)
+		booking, err = createPendingBooking(ctx, tx, userEmail, post)
+		return err
+	})
 	if exc != nil {
-		return exc
+		return nil, nil, exc
 	}
 
-	if post.Status != "available" {
-		return &exceptions.ErrPostIsNotAvailable
+	if booking != nil {
+		bs.notifyOwnerAboutBooking(ctx, post)
 	}
+	return booking, entry, nil
+}
+
+func (bs *BookingService) notifyOwnerAboutBooking(ctx context.Context, post *dto.PostDto) {
+	bs.notify(ctx, post.UserEmail, &dto.NotificationDto{
+		Title:   "Новая бронь!",
+		Content: "Книгу \"" + post.Title + "\" хотят забронировать. Подтвердите или отклоните бронь.",
+	})
+}
 
-	if post.UserEmail == userEmail {
-		return &exceptions.ErrUserIsOwner
+// bookingRestriction tells why a user suspended until suspendedUntil, whose
+// account is to be deleted at deletionScheduledAt and who holds active
+// bookings, can't get newBookings more at now, or returns nil.
+func bookingRestriction(suspendedUntil *string, deletionScheduledAt *string, active int, newBookings int, now string) *exceptions.Error_ {
+	// the bookings of the account are being ended before it is deleted
+	if deletionScheduledAt != nil && *deletionScheduledAt <= now {
+		return &exceptions.ErrAccountIsBeingDeleted
+	}
+	if suspendedUntil != nil && *suspendedUntil > now {
+		return &exceptions.ErrBookingSuspended
+	}
+	if active+newBookings > config.Config.MaxActiveBookings {
+		return &exceptions.ErrTooManyActiveBookings
 	}
+	return nil
+}
 
-	// Check if this post has been booked
-	existingBooking, err := bs.br.GetByPostID(ctx, postID)
+// CheckBookingAllowed fails with an exception when the user is suspended for
+// no-shows, when the account is due to be deleted or when the user would hold
+// more than MaxActiveBookings active bookings with newBookings more. Every way
+// of booking a post goes through it.
+func CheckBookingAllowed(ctx context.Context, tx *repositories.Tx, userEmail string, newBookings int) error {
+	suspendedUntil, deletionScheduledAt, err := tx.NoShows.GetBookingRestrictions(ctx, userEmail)
 	if err != nil {
-		return &exceptions.ErrDatabaseError
+		return err
+	}
+	active, err := tx.Bookings.CountActiveByUser(ctx, userEmail)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC().Format("2006-01-02T15:04:05Z")
+	if exc := bookingRestriction(suspendedUntil, deletionScheduledAt, active, newBookings, now); exc != nil {
+		return exc
 	}
+	return nil
+}
 
-	if existingBooking != nil {
-		return &exceptions.ErrBookingAlreadyExists
+// createPendingBooking books the post for the user. The owner has to confirm
+// the booking before the approval deadline.
+func createPendingBooking(ctx context.Context, tx *repositories.Tx, userEmail string, post *dto.PostDto) (*dto.BookingDto, error) {
+	if err := CheckBookingAllowed(ctx, tx, userEmail, 1); err != nil {
+		return nil, err
 	}
 
-	// Create a booking
+	now := time.Now().UTC()
 	booking := &dto.BookingToCreateDto{
 		UserEmail: userEmail,
-		PostID:    postID,
-		CreatedAt: time.Now().UTC().Format("2006-01-02T15:04:05Z"),
+		PostID:    post.ID,
+		CreatedAt: now.Format("2006-01-02T15:04:05Z"),
+		Status:    dto.BookingStatusPending,
+		ExpiresAt: now.Add(config.Config.BookingApprovalDeadline).Format("2006-01-02T15:04:05Z"),
+		UpdatedAt: now.Format("2006-01-02T15:04:05Z"),
 	}
 
-	err = bs.br.Create(ctx, booking)
+	id, err := tx.Bookings.Create(ctx, booking)
 	if err != nil {
-		return &exceptions.ErrDatabaseError
+		return nil, err
 	}
 
 	// changing post status to booked
-	_, exc = bs.ps.UpdatePost(ctx, post.UserEmail, postID, &dto.UpdatePostDto{
-		Status: "booked",
+	if err := tx.Posts.Update(ctx, post.ID, &dto.UpdatePostDto{Status: "booked"}); err != nil {
+		return nil, err
+	}
+
+	return tx.Bookings.Get(ctx, *id)
+}
+
+func joinWaitlist(ctx context.Context, tx *repositories.Tx, userEmail string, postID int64) (*dto.WaitlistEntryDto, error) {
+	entry, err := tx.Waitlist.Get(ctx, postID, userEmail)
+	if err != nil {
+		return nil, err
+	}
+	if entry != nil {
+		return nil, &exceptions.ErrAlreadyInWaitlist
+	}
+	// the user would get a booking when their turn comes
+	if err := CheckBookingAllowed(ctx, tx, userEmail, 1); err != nil {
+		return nil, err
+	}
+
+	_, err = tx.Waitlist.Add(ctx, &dto.WaitlistEntryToCreateDto{
+		PostID:    postID,
+		UserEmail: userEmail,
+		CreatedAt: time.Now().UTC().Format("2006-01-02T15:04:05Z"),
 	})
-	if exc != nil {
-		return exc
+	if err != nil {
+		return nil, err
 	}
 
-	token, err := bs.ptr.GetByEmail(&ctx, post.UserEmail)
-	if err != nil || token == nil {
-		return nil
+	return tx.Waitlist.Get(ctx, postID, userEmail)
+}
+
+// finishBooking moves the booking to a final status and hands the post over to
+// the next user in the waitlist, or makes it available when nobody is waiting.
+// Users who can't book anymore are skipped and lose their place. The booking
+// of the next user is returned, if any.
+func finishBooking(ctx context.Context, tx *repositories.Tx, booking *dto.BookingDto, status string, post *dto.PostDto) (*dto.BookingDto, error) {
+	if err := changeStatus(ctx, tx, booking, status, "", ""); err != nil {
+		return nil, err
 	}
 
-	bs.fr.SendByToken(
-		ctx,
-		*token,
-		&dto.NotificationDto{
-			Title:   "Новая бронь!",
-			Content: "Книгу \"" + post.Title + "\" забронировали.",
-		},
-	)
+	for {
+		next, err := tx.Waitlist.PopFirst(ctx, post.ID)
+		if err != nil {
+			return nil, err
+		}
+		if next == nil {
+			return nil, tx.Posts.Update(ctx, post.ID, &dto.UpdatePostDto{Status: "available"})
+		}
 
-	return nil
+		promoted, err := createPendingBooking(ctx, tx, next.UserEmail, post)
+		var restriction *exceptions.Error_
+		if errors.As(err, &restriction) {
+			continue
+		}
+		return promoted, err
+	}
 }
 
-func (bs *BookingService) DeleteBooking(ctx context.Context, userEmail string, postID int64) *exceptions.Error_ {
-	// Check if user has already booked this post
-	existingBooking, err := bs.br.GetByPostID(ctx, postID)
+type swapPartner struct {
+	booking  *dto.BookingDto
+	post     *dto.PostDto
+	promoted *dto.BookingDto
+}
+
+// cancelSwapPartners cancels the other active bookings made by the same swap
+// as the booking, since a swap only happens as a whole.
+func cancelSwapPartners(ctx context.Context, tx *repositories.Tx, booking *dto.BookingDto) ([]swapPartner, error) {
+	if booking.SwapID == nil {
+		return nil, nil
+	}
+
+	bookings, err := tx.Bookings.GetActiveBySwap(ctx, *booking.SwapID)
 	if err != nil {
-		return &exceptions.ErrDatabaseError
+		return nil, err
 	}
-	if existingBooking == nil {
-		return &exceptions.ErrBookingNotFound
+
+	var partners []swapPartner
+	for i := range bookings {
+		partner := swapPartner{booking: &bookings[i]}
+		partner.post, err = lockPost(ctx, tx, partner.booking.PostID)
+		if err != nil {
+			return nil, err
+		}
+		partner.promoted, err = finishBooking(ctx, tx, partner.booking, dto.BookingStatusCancelled, partner.post)
+		if err != nil {
+			return nil, err
+		}
+		partners = append(partners, partner)
+	}
+	return partners, nil
+}
+
+// notifyPromoted tells the user taken from the waitlist and the owner about
+// the new booking made by finishBooking.
+func (bs *BookingService) notifyPromoted(ctx context.Context, promoted *dto.BookingDto, post *dto.PostDto) {
+	if promoted == nil {
+		return
+	}
+
+	bs.notify(ctx, promoted.UserEmail, &dto.NotificationDto{
+		Title:   "Подошла ваша очередь!",
+		Content: "Книга \"" + post.Title + "\" забронирована за вами. Ожидайте подтверждения владельца.",
+	})
+	bs.notifyOwnerAboutBooking(ctx, post)
+}
+
+// notifySwapPartners tells both sides of the bookings cancelled by
+// cancelSwapPartners about it, except the user who ended the swap.
+func (bs *BookingService) notifySwapPartners(ctx context.Context, partners []swapPartner, exceptEmail string) {
+	for _, partner := range partners {
+		notification := &dto.NotificationDto{
+			Title:   "Обмен отменён",
+			Content: "Бронь книги \"" + partner.post.Title + "\" отменена, потому что обмен отменён.",
+		}
+		for _, email := range []string{partner.booking.UserEmail, partner.post.UserEmail} {
+			if email != exceptEmail {
+				bs.notify(ctx, email, notification)
+			}
+		}
+		bs.notifyPromoted(ctx, partner.promoted, partner.post)
 	}
+}
 
+// DeleteBooking cancels the active booking of the post. Both the booker and
+// the owner of the post may cancel it.
+func (bs *BookingService) DeleteBooking(ctx context.Context, userEmail string, postID int64) *exceptions.Error_ {
 	// Check if user exists
 	_, exc := bs.us.GetUserByEmail(ctx, userEmail)
 	if exc != nil {
 		return exc
 	}
 
-	post, exc := bs.ps.GetPost(ctx, postID, userEmail)
+	var post *dto.PostDto
+	var existingBooking *dto.BookingDto
+	var promoted *dto.BookingDto
+	var partners []swapPartner
+	exc = bs.inTx(ctx, func(tx *repositories.Tx) error {
+		var err error
+		post, err = lockPost(ctx, tx, postID)
+		if err != nil {
+			return err
+		}
+
+		// Check if user has already booked this post
+		existingBooking, err = tx.Bookings.GetByPostID(ctx, postID)
+		if err != nil {
+			return err
+		}
+		if existingBooking == nil {
+			return &exceptions.ErrBookingNotFound
+		}
+
+		if existingBooking.UserEmail != userEmail && post.UserEmail != userEmail {
+			return &exceptions.ErrUserIsNotBookingParticipant
+		}
+
+		// Cancel the booking and pass the post to the next user in the waitlist
+		promoted, err = finishBooking(ctx, tx, existingBooking, dto.BookingStatusCancelled, post)
+		if err != nil {
+			return err
+		}
+
+		partners, err = cancelSwapPartners(ctx, tx, existingBooking)
+		return err
+	})
 	if exc != nil {
 		return exc
 	}
 
-	// changing post status to taken
-	_, exc = bs.ps.UpdatePost(ctx, post.UserEmail, postID, &dto.UpdatePostDto{
-		Status: "available",
+	notification := &dto.NotificationDto{
+		Title:   "Бронь отменена",
+		Content: "Бронь книги \"" + post.Title + "\" отменена.",
+	}
+	if userEmail == post.UserEmail {
+		bs.notify(ctx, existingBooking.UserEmail, notification)
+	} else {
+		bs.notify(ctx, post.UserEmail, notification)
+	}
+	bs.notifyPromoted(ctx, promoted, post)
+	bs.notifySwapPartners(ctx, partners, userEmail)
+
+	return nil
+}
+
+// CreateHandoverCode issues a one-time code for the confirmed booking of the
+// user. The booker shows it to the owner, who confirms the handover with it.
+// A new code replaces the previous one.
+func (bs *BookingService) CreateHandoverCode(ctx context.Context, userEmail string, postID int64) (*dto.HandoverCodeDto, *exceptions.Error_) {
+	booking, err := bs.br.GetByPostID(ctx, postID)
+	if err != nil {
+		return nil, &exceptions.ErrDatabaseError
+	}
+	if booking == nil {
+		return nil, &exceptions.ErrBookingNotFound
+	}
+	if booking.UserEmail != userEmail {
+		return nil, &exceptions.ErrUserIsNotBookingParticipant
+	}
+	if booking.Status != dto.BookingStatusConfirmed {
+		return nil, &exceptions.ErrBookingNotConfirmed
+	}
+
+	code, err := utils.GenerateCode(handoverCodeLength)
+	if err != nil {
+		return nil, &exceptions.InternalServerError
+	}
+	ttl := config.Config.HandoverCodeTTL
+	key := strconv.FormatInt(booking.ID, 10)
+	if err := bs.cr.Set(&ctx, repositories.CodePurposeHandover, key, utils.HashCode(code), ttl); err != nil {
+		return nil, &exceptions.ErrServiceUnavailable
+	}
+
+	return &dto.HandoverCodeDto{
+		Code:      code,
+		QRPayload: fmt.Sprintf("bookcrossing:handover:%d:%s", postID, code),
+		ExpiresAt: time.Now().Add(ttl).UTC().Format("2006-01-02T15:04:05Z"),
+	}, nil
+}
+
+// ConfirmHandover is called by the owner with the code shown by the booker.
+// The booking is completed, the post becomes taken and the exchange is
+// recorded. A loan is due after the loan period of the post. The code is
+// deleted only after the transaction is committed, so a rolled back handover
+// can be confirmed again with the same code.
+func (bs *BookingService) ConfirmHandover(ctx context.Context, ownerEmail string, postID int64, code string) *exceptions.Error_ {
+	var post *dto.PostDto
+	var booking *dto.BookingDto
+	var waiting []string
+	var dueAt string
+	exc := bs.inTx(ctx, func(tx *repositories.Tx) error {
+		var err error
+		post, err = lockPost(ctx, tx, postID)
+		if err != nil {
+			return err
+		}
+		if post.UserEmail != ownerEmail {
+			return &exceptions.ErrUserIsNotOwner
+		}
+
+		booking, err = tx.Bookings.GetByPostID(ctx, postID)
+		if err != nil {
+			return err
+		}
+		if booking == nil {
+			return &exceptions.ErrBookingNotFound
+		}
+		if booking.Status != dto.BookingStatusConfirmed {
+			return &exceptions.ErrBookingNotConfirmed
+		}
+
+		// the code is bound to the booking, a code of an earlier booking of
+		// the post doesn't match. The post is locked, so the code can't be
+		// used twice before it is deleted.
+		if exc := bs.us.CheckCode(ctx, repositories.CodePurposeHandover, strconv.FormatInt(booking.ID, 10), code); exc != nil {
+			return exc
+		}
+
+		if err := changeStatus(ctx, tx, booking, dto.BookingStatusCompleted, "", "taken"); err != nil {
+			return err
+		}
+
+		// keeping the record of who got the book
+		now := time.Now().UTC()
+		exchange := &dto.ExchangeToCreateDto{
+			PostID:         post.ID,
+			PostTitle:      post.Title,
+			PlaceID:        post.PlaceID,
+			OwnerEmail:     post.UserEmail,
+			RecipientEmail: booking.UserEmail,
+			BookingID:      &booking.ID,
+			BookedAt:       &booking.CreatedAt,
+			TakenAt:        now.Format("2006-01-02T15:04:05Z"),
+		}
+		if post.ListingType == dto.ListingTypeLoan && post.LoanPeriodDays != nil {
+			dueAt = now.AddDate(0, 0, *post.LoanPeriodDays).Format("2006-01-02T15:04:05Z")
+			exchange.DueAt = &dueAt
+		}
+		if _, err := tx.Exchanges.Create(ctx, exchange); err != nil {
+			return err
+		}
+
+		// nobody can get this book anymore
+		waiting, err = tx.Waitlist.RemoveAll(ctx, postID)
+		return err
 	})
 	if exc != nil {
 		return exc
 	}
 
-	// Delete the booking
-	err = bs.br.Delete(ctx, existingBooking.ID)
+	// the booking is completed, so the code can't confirm anything anymore
+	if _, err := bs.cr.Delete(&ctx, repositories.CodePurposeHandover, strconv.FormatInt(booking.ID, 10)); err != nil {
+		bs.logger.Warn(
+			"Failed to delete handover code",
+			zap.Int64("booking_id", booking.ID),
+			zap.String("error", err.Error()),
+		)
+	}
+
+	content := "Владелец подтвердил передачу книги \"" + post.Title + "\"."
+	if dueAt != "" {
+		content += " Верните её до " + dueAt[:len("2006-01-02")] + "."
+	}
+	bs.notify(ctx, booking.UserEmail, &dto.NotificationDto{
+		Title:   "Книга у вас",
+		Content: content,
+	})
+	for _, email := range waiting {
+		bs.notify(ctx, email, &dto.NotificationDto{
+			Title:   "Книгу забрали",
+			Content: "Книгу \"" + post.Title + "\" забрали, очередь на неё закрыта.",
+		})
+	}
+
+	return nil
+}
+
+// noShowRestriction tells why a no-show can't be reported for the booking yet.
+// The booker had no chance to come before the reserved pickup slot starts or,
+// without a slot, before the pickup deadline passes. slotStartsAt is nil when
+// no slot is reserved.
+func noShowRestriction(booking *dto.BookingDto, slotStartsAt *string, now string) *exceptions.Error_ {
+	if booking.Status != dto.BookingStatusConfirmed {
+		return &exceptions.ErrBookingNotConfirmed
+	}
+	if slotStartsAt != nil {
+		if now < *slotStartsAt {
+			return &exceptions.ErrNoShowTooEarly
+		}
+		return nil
+	}
+	if now < booking.ExpiresAt {
+		return &exceptions.ErrNoShowTooEarly
+	}
+	return nil
+}
+
+// ReportNoShow is called by the owner when the booker of the confirmed booking
+// didn't come for the book, once the reserved pickup slot has started or the
+// pickup deadline has passed. The booking ends, the post goes to the next user
+// in the waitlist and the booker's no-show counter grows. Bookings of the
+// booker are suspended after NoShowSuspensionThreshold no-shows within
+// NoShowWindow. The other bookings of a swap are cancelled with it.
+func (bs *BookingService) ReportNoShow(ctx context.Context, ownerEmail string, postID int64) (*dto.BookingDto, *exceptions.Error_) {
+	var booking *dto.BookingDto
+	var post *dto.PostDto
+	var promoted *dto.BookingDto
+	var partners []swapPartner
+	var suspendedUntil string
+	exc := bs.inTx(ctx, func(tx *repositories.Tx) error {
+		var err error
+		booking, post, err = getActiveBookingOfOwner(ctx, tx, ownerEmail, postID)
+		if err != nil {
+			return err
+		}
+
+		var slotStartsAt *string
+		if booking.SlotID != nil {
+			slot, err := tx.Slots.Get(ctx, *booking.SlotID)
+			if err != nil {
+				return err
+			}
+			if slot != nil {
+				slotStartsAt = &slot.StartsAt
+			}
+		}
+		now := time.Now().UTC()
+		if exc := noShowRestriction(booking, slotStartsAt, now.Format("2006-01-02T15:04:05Z")); exc != nil {
+			return exc
+		}
+
+		promoted, err = finishBooking(ctx, tx, booking, dto.BookingStatusNoShow, post)
+		if err != nil {
+			return err
+		}
+		partners, err = cancelSwapPartners(ctx, tx, booking)
+		if err != nil {
+			return err
+		}
+
+		err = tx.NoShows.Create(ctx, &dto.NoShowReportToCreateDto{
+			BookingID:     booking.ID,
+			ReporterEmail: ownerEmail,
+			UserEmail:     booking.UserEmail,
+			CreatedAt:     now.Format("2006-01-02T15:04:05Z"),
+		})
+		if err != nil {
+			return err
+		}
+
+		since := now.Add(-config.Config.NoShowWindow).Format("2006-01-02T15:04:05Z")
+		count, err := tx.NoShows.CountSince(ctx, booking.UserEmail, since)
+		if err != nil {
+			return err
+		}
+		if count < config.Config.NoShowSuspensionThreshold {
+			return nil
+		}
+		suspendedUntil = now.Add(config.Config.BookingSuspension).Format("2006-01-02T15:04:05Z")
+		return tx.NoShows.SuspendBooking(ctx, booking.UserEmail, suspendedUntil)
+	})
+	if exc != nil {
+		return nil, exc
+	}
+
+	content := "Владелец сообщил, что вы не забрали книгу \"" + post.Title + "\". Бронь снята."
+	if suspendedUntil != "" {
+		content += " Из-за повторных неявок бронирование недоступно до " + suspendedUntil + "."
+	}
+	bs.notify(ctx, booking.UserEmail, &dto.NotificationDto{
+		Title:   "Неявка за книгой",
+		Content: content,
+	})
+	bs.notifyPromoted(ctx, promoted, post)
+	bs.notifySwapPartners(ctx, partners, "")
+
+	return booking, nil
+}
+
+// ReservePickupSlot reserves the pickup slot for the confirmed booking of the
+// user. The slot must be at the place of the post and end before the pickup
+// deadline. A slot reserved earlier is released.
+func (bs *BookingService) ReservePickupSlot(ctx context.Context, userEmail string, postID int64, slotID int64) (*dto.BookingDto, *exceptions.Error_) {
+	var booking *dto.BookingDto
+	var post *dto.PostDto
+	var slot *dto.PickupSlotDto
+	exc := bs.inTx(ctx, func(tx *repositories.Tx) error {
+		var err error
+		post, err = lockPost(ctx, tx, postID)
+		if err != nil {
+			return err
+		}
+
+		booking, err = tx.Bookings.GetByPostID(ctx, postID)
+		if err != nil {
+			return err
+		}
+		if booking == nil {
+			return &exceptions.ErrBookingNotFound
+		}
+		if booking.UserEmail != userEmail {
+			return &exceptions.ErrUserIsNotBookingParticipant
+		}
+		if booking.Status != dto.BookingStatusConfirmed {
+			return &exceptions.ErrBookingNotConfirmed
+		}
+
+		slot, err = tx.Slots.GetForUpdate(ctx, slotID)
+		if err != nil {
+			return err
+		}
+		if slot == nil {
+			return &exceptions.ErrPickupSlotNotFound
+		}
+		if slot.PlaceID != post.PlaceID {
+			return &exceptions.ErrPickupSlotNotAtPlace
+		}
+		now := time.Now().UTC().Format("2006-01-02T15:04:05Z")
+		if slot.StartsAt <= now || slot.EndsAt > booking.ExpiresAt {
+			return &exceptions.ErrPickupSlotUnavailable
+		}
+
+		reserved, err := tx.Slots.CountReserved(ctx, slotID, booking.ID)
+		if err != nil {
+			return err
+		}
+		if reserved >= slot.Capacity {
+			return &exceptions.ErrPickupSlotFull
+		}
+
+		if err := tx.Bookings.SetSlot(ctx, booking.ID, slotID, now); err != nil {
+			return err
+		}
+		booking.SlotID = &slotID
+		booking.UpdatedAt = now
+		return nil
+	})
+	if exc != nil {
+		return nil, exc
+	}
+
+	bs.notify(ctx, post.UserEmail, &dto.NotificationDto{
+		Title:   "Время получения выбрано",
+		Content: "Книгу \"" + post.Title + "\" заберут " + slot.StartsAt + ".",
+	})
+	return booking, nil
+}
+
+// GetPickupCalendar returns the pickup appointment of the active booking of
+// the post as an iCalendar file. Both the booker and the owner may export it.
+func (bs *BookingService) GetPickupCalendar(ctx context.Context, userEmail string, postID int64) (string, *exceptions.Error_) {
+	booking, err := bs.br.GetByPostID(ctx, postID)
+	if err != nil {
+		return "", &exceptions.ErrDatabaseError
+	}
+	if booking == nil {
+		return "", &exceptions.ErrBookingNotFound
+	}
+	if booking.SlotID == nil {
+		return "", &exceptions.ErrBookingHasNoPickupSlot
+	}
+
+	pickup, err := bs.br.GetPickup(ctx, booking.ID)
+	if err != nil {
+		return "", &exceptions.ErrDatabaseError
+	}
+	if pickup == nil {
+		return "", &exceptions.ErrBookingHasNoPickupSlot
+	}
+	if pickup.BookerEmail != userEmail && pickup.OwnerEmail != userEmail {
+		return "", &exceptions.ErrUserIsNotBookingParticipant
+	}
+
+	event := &dto.CalendarEventDto{
+		UID:         fmt.Sprintf("booking-%d-slot-%d@bookcrossing", pickup.BookingID, pickup.SlotID),
+		Summary:     "Забрать книгу \"" + pickup.PostTitle + "\"",
+		Description: "Покажите владельцу код передачи из приложения.",
+		Location:    pickup.PlaceName + ", " + pickup.PlaceAddress + ", " + pickup.PlaceCity,
+		StartsAt:    pickup.StartsAt,
+		EndsAt:      pickup.EndsAt,
+	}
+	if userEmail == pickup.OwnerEmail {
+		event.Summary = "Передать книгу \"" + pickup.PostTitle + "\""
+		event.Description = "Подтвердите передачу кодом забронировавшего."
+	}
+
+	ics, err := utils.BuildICS(event, time.Now())
+	if err != nil {
+		return "", &exceptions.InternalServerError
+	}
+	return ics, nil
+}
+
+// SendPickupReminders reminds the booker and the owner about pickups starting
+// within PickupReminderLeadTime.
+func (bs *BookingService) SendPickupReminders(ctx context.Context) *exceptions.Error_ {
+	now := time.Now().UTC()
+	pickups, err := bs.br.GetPickupsToRemind(ctx,
+		now.Format("2006-01-02T15:04:05Z"),
+		now.Add(config.Config.PickupReminderLeadTime).Format("2006-01-02T15:04:05Z"),
+		pickupReminderBatchSize,
+	)
 	if err != nil {
 		return &exceptions.ErrDatabaseError
 	}
 
+	for _, pickup := range pickups {
+		if err := bs.br.SetSlotReminderSent(ctx, pickup.BookingID, now.Format("2006-01-02T15:04:05Z")); err != nil {
+			return &exceptions.ErrDatabaseError
+		}
+		bs.notify(ctx, pickup.BookerEmail, &dto.NotificationDto{
+			Title:   "Скоро получение книги",
+			Content: "Заберите книгу \"" + pickup.PostTitle + "\" в " + pickup.PlaceName + " " + pickup.StartsAt + ".",
+		})
+		bs.notify(ctx, pickup.OwnerEmail, &dto.NotificationDto{
+			Title:   "Скоро передача книги",
+			Content: "Книгу \"" + pickup.PostTitle + "\" заберут в " + pickup.PlaceName + " " + pickup.StartsAt + ".",
+		})
+	}
 	return nil
 }
 
-func (bs *BookingService) MarkAsTaken(ctx context.Context, userEmail string, postID int64) *exceptions.Error_ {
-	// Check if user exists
-	_, exc := bs.us.GetUserByEmail(ctx, userEmail)
+// ExpireStaleBookings expires every active booking whose deadline has passed,
+// returns its post to "available" and notifies both sides. The other bookings
+// of a swap are cancelled with it. A booking which fails to expire is logged
+// and left for the next run, so it doesn't hold back the others.
+func (bs *BookingService) ExpireStaleBookings(ctx context.Context) *exceptions.Error_ {
+	now := time.Now().UTC().Format("2006-01-02T15:04:05Z")
+	bookings, err := bs.br.GetExpired(ctx, now)
+	if err != nil {
+		return &exceptions.ErrDatabaseError
+	}
+
+	for _, stale := range *bookings {
+		var post *dto.PostDto
+		var booking *dto.BookingDto
+		var promoted *dto.BookingDto
+		var partners []swapPartner
+		exc := bs.inTx(ctx, func(tx *repositories.Tx) error {
+			var err error
+			post, err = lockPost(ctx, tx, stale.PostID)
+			if err != nil {
+				return err
+			}
+
+			// the booking may have been accepted or cancelled since it was read
+			booking, err = tx.Bookings.Get(ctx, stale.ID)
+			if err != nil {
+				return err
+			}
+			if booking == nil || !canTransition(booking.Status, dto.BookingStatusExpired) || booking.ExpiresAt >= now {
+				booking = nil
+				return nil
+			}
+
+			promoted, err = finishBooking(ctx, tx, booking, dto.BookingStatusExpired, post)
+			if err != nil {
+				return err
+			}
+
+			partners, err = cancelSwapPartners(ctx, tx, booking)
+			return err
+		})
+		if exc != nil {
+			bs.logger.Error(
+				"Failed to expire booking",
+				zap.Int64("booking_id", stale.ID),
+				zap.String("error", exc.Message),
+			)
+			continue
+		}
+		if booking == nil {
+			continue
+		}
+
+		ownerContent := "Книгу \"" + post.Title + "\" не забрали вовремя."
+		if stale.Status == dto.BookingStatusPending {
+			ownerContent = "Бронь книги \"" + post.Title + "\" не была подтверждена вовремя."
+		}
+		bs.notify(ctx, booking.UserEmail, &dto.NotificationDto{
+			Title:   "Бронь истекла",
+			Content: "Срок брони книги \"" + post.Title + "\" истёк.",
+		})
+		bs.notify(ctx, post.UserEmail, &dto.NotificationDto{
+			Title:   "Бронь истекла",
+			Content: ownerContent,
+		})
+		bs.notifyPromoted(ctx, promoted, post)
+		bs.notifySwapPartners(ctx, partners, "")
+	}
+
+	return nil
+}
+
+// ownedPost is a post of a deleted account together with the booking and the
+// waitlist it had.
+type ownedPost struct {
+	post    *dto.PostDto
+	booking *dto.BookingDto
+	waiting []string
+}
+
+// ReleaseAccount ends everything which ties other users to the account before
+// it is deleted. The bookings of the user are cancelled and their posts go to
+// the next users in the waitlists, the posts of the user are deleted together
+// with their bookings and waitlists, and swaps with the user are cancelled.
+// New bookings of the user are refused by CheckBookingAllowed once the
+// deletion is due.
+func (bs *BookingService) ReleaseAccount(ctx context.Context, email string) *exceptions.Error_ {
+	var owned []ownedPost
+	var booked []swapPartner
+	var partners []swapPartner
+	var offers []dto.SwapOfferDto
+	exc := bs.inTx(ctx, func(tx *repositories.Tx) error {
+		// the user must not be promoted by the bookings cancelled below
+		if err := tx.Waitlist.RemoveByUser(ctx, email); err != nil {
+			return err
+		}
+
+		postIDs, err := tx.Posts.GetIDsByUser(ctx, email)
+		if err != nil {
+			return err
+		}
+		bookings, err := tx.Bookings.GetActiveByUser(ctx, email)
+		if err != nil {
+			return err
+		}
+
+		// the posts are locked in the order of their ids, so that the swap
+		// partners cancelled below are already locked as well
+		ids := append([]int64(nil), postIDs...)
+		for _, booking := range bookings {
+			ids = append(ids, booking.PostID)
+		}
+		sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+		posts := make(map[int64]*dto.PostDto, len(ids))
+		for _, id := range ids {
+			if _, ok := posts[id]; ok {
+				continue
+			}
+			posts[id], err = lockPost(ctx, tx, id)
+			if err != nil {
+				return err
+			}
+		}
+
+		for _, id := range postIDs {
+			item := ownedPost{post: posts[id]}
+			item.booking, err = tx.Bookings.GetByPostID(ctx, id)
+			if err != nil {
+				return err
+			}
+			if item.booking != nil {
+				// the post is deleted, so nobody is promoted
+				if err := changeStatus(ctx, tx, item.booking, dto.BookingStatusCancelled, "", ""); err != nil {
+					return err
+				}
+				cancelled, err := cancelSwapPartners(ctx, tx, item.booking)
+				if err != nil {
+					return err
+				}
+				partners = append(partners, cancelled...)
+			}
+
+			item.waiting, err = tx.Waitlist.RemoveAll(ctx, id)
+			if err != nil {
+				return err
+			}
+			if err := tx.Posts.Delete(ctx, id); err != nil {
+				return err
+			}
+			owned = append(owned, item)
+		}
+
+		for _, stale := range bookings {
+			// the booking may have been cancelled with a swap above
+			booking, err := tx.Bookings.Get(ctx, stale.ID)
+			if err != nil {
+				return err
+			}
+			if booking == nil || !canTransition(booking.Status, dto.BookingStatusCancelled) {
+				continue
+			}
+
+			item := swapPartner{booking: booking, post: posts[booking.PostID]}
+			item.promoted, err = finishBooking(ctx, tx, booking, dto.BookingStatusCancelled, item.post)
+			if err != nil {
+				return err
+			}
+			booked = append(booked, item)
+
+			cancelled, err := cancelSwapPartners(ctx, tx, booking)
+			if err != nil {
+				return err
+			}
+			partners = append(partners, cancelled...)
+		}
+
+		offers, err = tx.Swaps.CancelPendingOfUser(ctx, email, time.Now().UTC().Format("2006-01-02T15:04:05Z"))
+		return err
+	})
 	if exc != nil {
 		return exc
 	}
 
-	// Check if post exists
-	post, exc := bs.ps.GetPost(ctx, postID, userEmail)
+	for _, item := range owned {
+		if item.booking != nil {
+			bs.notify(ctx, item.booking.UserEmail, &dto.NotificationDto{
+				Title:   "Бронь отменена",
+				Content: "Бронь книги \"" + item.post.Title + "\" отменена, потому что владелец удалил аккаунт.",
+			})
+		}
+		for _, waiting := range item.waiting {
+			bs.notify(ctx, waiting, &dto.NotificationDto{
+				Title:   "Книга снята",
+				Content: "Владелец книги \"" + item.post.Title + "\" удалил аккаунт, очередь на неё закрыта.",
+			})
+		}
+	}
+	for _, item := range booked {
+		bs.notify(ctx, item.post.UserEmail, &dto.NotificationDto{
+			Title:   "Бронь отменена",
+			Content: "Бронь книги \"" + item.post.Title + "\" отменена.",
+		})
+		bs.notifyPromoted(ctx, item.promoted, item.post)
+	}
+	bs.notifySwapPartners(ctx, partners, email)
+	for _, offer := range offers {
+		other := offer.ProposerEmail
+		if other == email {
+			other = offer.RecipientEmail
+		}
+		bs.notify(ctx, other, &dto.NotificationDto{
+			Title:   "Обмен отменён",
+			Content: "Предложение обмена отменено, потому что пользователь удалил аккаунт.",
+		})
+	}
+
+	return nil
+}
+
+func getActiveBookingOfOwner(ctx context.Context, tx *repositories.Tx, ownerEmail string, postID int64) (*dto.BookingDto, *dto.PostDto, error) {
+	post, err := lockPost(ctx, tx, postID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if post.UserEmail != ownerEmail {
+		return nil, nil, &exceptions.ErrUserIsNotOwner
+	}
+
+	booking, err := tx.Bookings.GetByPostID(ctx, postID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if booking == nil {
+		return nil, nil, &exceptions.ErrBookingNotFound
+	}
+
+	return booking, post, nil
+}
+
+// AcceptBooking confirms the pending booking of the owner's post and starts
+// the pickup deadline.
+func (bs *BookingService) AcceptBooking(ctx context.Context, ownerEmail string, postID int64) (*dto.BookingDto, *exceptions.Error_) {
+	var booking *dto.BookingDto
+	var post *dto.PostDto
+	exc := bs.inTx(ctx, func(tx *repositories.Tx) error {
+		var err error
+		booking, post, err = getActiveBookingOfOwner(ctx, tx, ownerEmail, postID)
+		if err != nil {
+			return err
+		}
+
+		expiresAt := time.Now().UTC().Add(config.Config.BookingPickupDeadline).Format("2006-01-02T15:04:05Z")
+		return changeStatus(ctx, tx, booking, dto.BookingStatusConfirmed, expiresAt, "booked")
+	})
 	if exc != nil {
-		return exc
+		return nil, exc
 	}
 
-	if post.UserEmail == userEmail {
-		return &exceptions.ErrUserIsOwner
+	bs.notify(ctx, booking.UserEmail, &dto.NotificationDto{
+		Title:   "Бронь подтверждена",
+		Content: "Владелец подтвердил бронь книги \"" + post.Title + "\". Заберите её до " + booking.ExpiresAt + ".",
+	})
+
+	return booking, nil
+}
+
+// RejectBooking rejects the pending booking of the owner's post and passes the
+// post to the next user in the waitlist.
+func (bs *BookingService) RejectBooking(ctx context.Context, ownerEmail string, postID int64) (*dto.BookingDto, *exceptions.Error_) {
+	var booking *dto.BookingDto
+	var post *dto.PostDto
+	var promoted *dto.BookingDto
+	exc := bs.inTx(ctx, func(tx *repositories.Tx) error {
+		var err error
+		booking, post, err = getActiveBookingOfOwner(ctx, tx, ownerEmail, postID)
+		if err != nil {
+			return err
+		}
+
+		promoted, err = finishBooking(ctx, tx, booking, dto.BookingStatusRejected, post)
+		return err
+	})
+	if exc != nil {
+		return nil, exc
 	}
 
-	// changing post status to taken
-	_, exc = bs.ps.UpdatePost(ctx, post.UserEmail, postID, &dto.UpdatePostDto{
-		Status: "taken",
+	bs.notify(ctx, booking.UserEmail, &dto.NotificationDto{
+		Title:   "Бронь отклонена",
+		Content: "Владелец отклонил бронь книги \"" + post.Title + "\".",
 	})
+	bs.notifyPromoted(ctx, promoted, post)
+
+	return booking, nil
+}
+
+func (bs *BookingService) GetIncomingBookings(ctx context.Context, ownerEmail string, page dto.PageRequest) (*dto.Page[dto.IncomingBookingDto], *exceptions.Error_) {
+	_, exc := bs.us.GetUserByEmail(ctx, ownerEmail)
 	if exc != nil {
-		return exc
+		return nil, exc
 	}
 
-	// removing all bookings for this post
-	err := bs.br.RemoveAll(ctx, postID)
+	bookings, err := bs.br.GetIncoming(ctx, ownerEmail, dto.BookingStatusPending, page)
 	if err != nil {
-		return &exceptions.ErrDatabaseError
+		return nil, &exceptions.ErrDatabaseError
+	}
+	return bookings, nil
+}
+
+func (bs *BookingService) GetWaitlistPosition(ctx context.Context, userEmail string, postID int64) (*dto.WaitlistEntryDto, *exceptions.Error_) {
+	entry, err := bs.wr.Get(ctx, postID, userEmail)
+	if err != nil {
+		return nil, &exceptions.ErrDatabaseError
+	}
+	if entry == nil {
+		return nil, &exceptions.ErrWaitlistEntryNotFound
+	}
+	return entry, nil
+}
+
+func (bs *BookingService) LeaveWaitlist(ctx context.Context, userEmail string, postID int64) *exceptions.Error_ {
+	if _, exc := bs.GetWaitlistPosition(ctx, userEmail, postID); exc != nil {
+		return exc
 	}
 
+	if err := bs.wr.Remove(ctx, postID, userEmail); err != nil {
+		return &exceptions.ErrDatabaseError
+	}
 	return nil
 }
+
+func (bs *BookingService) GetMyWaitlist(ctx context.Context, userEmail string) (*[]dto.WaitlistEntryDto, *exceptions.Error_) {
+	entries, err := bs.wr.GetByUser(ctx, userEmail)
+	if err != nil {
+		return nil, &exceptions.ErrDatabaseError
+	}
+	return entries, nil
+}
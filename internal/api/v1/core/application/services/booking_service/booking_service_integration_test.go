@@ -0,0 +1,208 @@
+//go:build integration
+
+package booking_service
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"example.com/m/internal/api/v1/adapters/repositories"
+	"example.com/m/internal/api/v1/core/application/dto"
+	"example.com/m/internal/api/v1/core/application/exceptions"
+	"example.com/m/internal/api/v1/core/application/services/user_service"
+	"example.com/m/internal/api/v1/infrastructure/mail"
+	"example.com/m/internal/api/v1/testdb"
+	"example.com/m/internal/config"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+func newTestBookingService(db *sql.DB) *BookingService {
+	logger := zap.NewNop()
+	// push tokens are never found, so no notifications are sent
+	rdb := redis.NewClient(&redis.Options{Addr: "127.0.0.1:1", MaxRetries: -1})
+	pushTokenRepository := repositories.NewPushTokenRepository(rdb, logger)
+	codeRepository := repositories.NewCodeRepository(rdb, logger)
+	userService := user_service.NewUserService(
+		repositories.NewUserRepository(db, logger), pushTokenRepository,
+		codeRepository, repositories.NewMailRepository(mail.NewLogMailer("", logger), logger),
+		repositories.NewAvatarRepository(logger, nil),
+	)
+
+	return NewBookingService(
+		*repositories.NewBookingRepository(db, logger),
+		*repositories.NewWaitlistRepository(db, logger),
+		*userService,
+		repositories.NewUnitOfWork(db, logger),
+		nil,
+		pushTokenRepository,
+		codeRepository,
+		logger,
+	)
+}
+
+func TestBookBookConcurrently(t *testing.T) {
+	db := testdb.Open(t)
+	bs := newTestBookingService(db)
+	config.Config.BookingApprovalDeadline = time.Hour
+	config.Config.MaxActiveBookings = 1
+
+	const bookers = 10
+	suffix := time.Now().UnixNano()
+
+	owner := fmt.Sprintf("owner%d@test.com", suffix)
+	emails := []string{owner}
+	for i := 0; i < bookers; i++ {
+		emails = append(emails, fmt.Sprintf("booker%d_%d@test.com", i, suffix))
+	}
+	testdb.CreateUsers(t, db, emails...)
+	postID := testdb.CreatePost(t, db, owner)
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	start := make(chan struct{})
+	var bookings []*dto.BookingDto
+	var entries []*dto.WaitlistEntryDto
+	for _, email := range emails[1:] {
+		wg.Add(1)
+		go func(email string) {
+			defer wg.Done()
+			<-start
+			booking, entry, exc := bs.BookBook(context.Background(), email, postID)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if exc != nil {
+				t.Errorf("got error %v", exc.Message)
+				return
+			}
+			if booking != nil {
+				bookings = append(bookings, booking)
+			}
+			if entry != nil {
+				entries = append(entries, entry)
+			}
+		}(email)
+	}
+	close(start)
+	wg.Wait()
+
+	if len(bookings) != 1 {
+		t.Fatalf("got %d bookings want 1", len(bookings))
+	}
+	if len(entries) != bookers-1 {
+		t.Errorf("got %d waitlist entries want %d", len(entries), bookers-1)
+	}
+
+	var active int
+	err := db.QueryRow(
+		"SELECT COUNT(*) FROM bookings WHERE post_id = $1 AND status IN ('pending', 'confirmed')",
+		postID,
+	).Scan(&active)
+	if err != nil {
+		t.Fatalf("got error %v", err)
+	}
+	if active != 1 {
+		t.Errorf("got %d active bookings want 1", active)
+	}
+
+	positions := map[int64]bool{}
+	for _, entry := range entries {
+		positions[entry.Position] = true
+	}
+	for i := int64(1); i < bookers; i++ {
+		if !positions[i] {
+			t.Errorf("waitlist position %d is missing", i)
+		}
+	}
+}
+
+func TestBookBookRestrictions(t *testing.T) {
+	db := testdb.Open(t)
+	bs := newTestBookingService(db)
+	config.Config.BookingApprovalDeadline = time.Hour
+	config.Config.MaxActiveBookings = 1
+
+	suffix := time.Now().UnixNano()
+	owner := fmt.Sprintf("owner%d@test.com", suffix)
+	booker := fmt.Sprintf("booker%d@test.com", suffix)
+	suspended := fmt.Sprintf("suspended%d@test.com", suffix)
+	testdb.CreateUsers(t, db, owner, booker, suspended)
+	first := testdb.CreatePost(t, db, owner)
+	second := testdb.CreatePost(t, db, owner)
+
+	_, err := db.Exec(
+		"UPDATE users SET booking_suspended_until = $1 WHERE email = $2",
+		time.Now().UTC().Add(time.Hour).Format("2006-01-02T15:04:05Z"), suspended,
+	)
+	if err != nil {
+		t.Fatalf("got error %v", err)
+	}
+
+	if _, _, exc := bs.BookBook(context.Background(), booker, first); exc != nil {
+		t.Fatalf("got error %v", exc.Message)
+	}
+	if _, _, exc := bs.BookBook(context.Background(), booker, second); exc != &exceptions.ErrTooManyActiveBookings {
+		t.Errorf("got %v want ErrTooManyActiveBookings", exc)
+	}
+	// the first post is booked, so this would be a waitlist entry
+	if _, _, exc := bs.BookBook(context.Background(), suspended, first); exc != &exceptions.ErrBookingSuspended {
+		t.Errorf("got %v want ErrBookingSuspended", exc)
+	}
+	if _, _, exc := bs.BookBook(context.Background(), suspended, second); exc != &exceptions.ErrBookingSuspended {
+		t.Errorf("got %v want ErrBookingSuspended", exc)
+	}
+}
+
+func TestWaitlistSkipsRestrictedUsers(t *testing.T) {
+	db := testdb.Open(t)
+	bs := newTestBookingService(db)
+	config.Config.BookingApprovalDeadline = time.Hour
+	config.Config.MaxActiveBookings = 1
+
+	suffix := time.Now().UnixNano()
+	owner := fmt.Sprintf("owner%d@test.com", suffix)
+	booker := fmt.Sprintf("booker%d@test.com", suffix)
+	suspended := fmt.Sprintf("suspended%d@test.com", suffix)
+	waiting := fmt.Sprintf("waiting%d@test.com", suffix)
+	testdb.CreateUsers(t, db, owner, booker, suspended, waiting)
+	postID := testdb.CreatePost(t, db, owner)
+
+	for _, email := range []string{booker, suspended, waiting} {
+		if _, _, exc := bs.BookBook(context.Background(), email, postID); exc != nil {
+			t.Fatalf("got error %v", exc.Message)
+		}
+	}
+	// suspended after joining the waitlist
+	_, err := db.Exec(
+		"UPDATE users SET booking_suspended_until = $1 WHERE email = $2",
+		time.Now().UTC().Add(time.Hour).Format("2006-01-02T15:04:05Z"), suspended,
+	)
+	if err != nil {
+		t.Fatalf("got error %v", err)
+	}
+
+	if exc := bs.DeleteBooking(context.Background(), booker, postID); exc != nil {
+		t.Fatalf("got error %v", exc.Message)
+	}
+
+	booking, err := bs.br.GetByPostID(context.Background(), postID)
+	if err != nil {
+		t.Fatalf("got error %v", err)
+	}
+	if booking == nil || booking.UserEmail != waiting {
+		t.Fatalf("got booking %+v want a booking of %s", booking, waiting)
+	}
+
+	var left int
+	if err := db.QueryRow("SELECT COUNT(*) FROM booking_waitlist WHERE post_id = $1", postID).Scan(&left); err != nil {
+		t.Fatalf("got error %v", err)
+	}
+	if left != 0 {
+		t.Errorf("got %d waitlist entries want 0", left)
+	}
+}
@@ -0,0 +1,96 @@
+package booking_service
+
+import (
+	"testing"
+
+	"example.com/m/internal/api/v1/core/application/dto"
+	"example.com/m/internal/api/v1/core/application/exceptions"
+	"example.com/m/internal/config"
+)
+
+func TestCanTransition(t *testing.T) {
+	tests := []struct {
+		from string
+		to   string
+		want bool
+	}{
+		{dto.BookingStatusPending, dto.BookingStatusConfirmed, true},
+		{dto.BookingStatusPending, dto.BookingStatusRejected, true},
+		{dto.BookingStatusPending, dto.BookingStatusCompleted, false},
+		{dto.BookingStatusPending, dto.BookingStatusNoShow, false},
+		{dto.BookingStatusConfirmed, dto.BookingStatusCompleted, true},
+		{dto.BookingStatusConfirmed, dto.BookingStatusNoShow, true},
+		{dto.BookingStatusConfirmed, dto.BookingStatusRejected, false},
+		{dto.BookingStatusConfirmed, dto.BookingStatusPending, false},
+		{dto.BookingStatusCompleted, dto.BookingStatusCancelled, false},
+		{dto.BookingStatusExpired, dto.BookingStatusConfirmed, false},
+	}
+	for _, tt := range tests {
+		if got := canTransition(tt.from, tt.to); got != tt.want {
+			t.Errorf("%s -> %s: got %v want %v", tt.from, tt.to, got, tt.want)
+		}
+	}
+}
+
+func TestBookingRestriction(t *testing.T) {
+	config.Config.MaxActiveBookings = 3
+	now := "2025-03-20T10:00:00Z"
+	past := "2025-03-19T10:00:00Z"
+	future := "2025-03-21T10:00:00Z"
+
+	tests := []struct {
+		name                string
+		suspendedUntil      *string
+		deletionScheduledAt *string
+		active              int
+		newBookings         int
+		want                *exceptions.Error_
+	}{
+		{"allowed", nil, nil, 0, 1, nil},
+		{"last free booking", nil, nil, 2, 1, nil},
+		{"limit reached", nil, nil, 3, 1, &exceptions.ErrTooManyActiveBookings},
+		{"swap over the limit", nil, nil, 1, 3, &exceptions.ErrTooManyActiveBookings},
+		{"suspension is over", &past, nil, 0, 1, nil},
+		{"suspended", &future, nil, 0, 1, &exceptions.ErrBookingSuspended},
+		{"deletion in grace period", nil, &future, 0, 1, nil},
+		{"deletion is due", nil, &past, 0, 1, &exceptions.ErrAccountIsBeingDeleted},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := bookingRestriction(tt.suspendedUntil, tt.deletionScheduledAt, tt.active, tt.newBookings, now)
+			if got != tt.want {
+				t.Errorf("got %v want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNoShowRestriction(t *testing.T) {
+	now := "2025-03-20T10:00:00Z"
+	past := "2025-03-19T10:00:00Z"
+	future := "2025-03-21T10:00:00Z"
+
+	tests := []struct {
+		name         string
+		status       string
+		expiresAt    string
+		slotStartsAt *string
+		want         *exceptions.Error_
+	}{
+		{"pending", dto.BookingStatusPending, past, nil, &exceptions.ErrBookingNotConfirmed},
+		{"before the deadline", dto.BookingStatusConfirmed, future, nil, &exceptions.ErrNoShowTooEarly},
+		{"after the deadline", dto.BookingStatusConfirmed, past, nil, nil},
+		{"before the slot", dto.BookingStatusConfirmed, future, &future, &exceptions.ErrNoShowTooEarly},
+		{"after the slot started", dto.BookingStatusConfirmed, future, &past, nil},
+		{"when the slot starts", dto.BookingStatusConfirmed, future, &now, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			booking := &dto.BookingDto{Status: tt.status, ExpiresAt: tt.expiresAt}
+			got := noShowRestriction(booking, tt.slotStartsAt, now)
+			if got != tt.want {
+				t.Errorf("got %v want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package exchange_service
+
+import (
+	"context"
+
+	"example.com/m/internal/api/v1/adapters/repositories"
+	"example.com/m/internal/api/v1/core/application/dto"
+	"example.com/m/internal/api/v1/core/application/exceptions"
+	"example.com/m/internal/api/v1/core/application/services/user_service"
+)
+
+type ExchangeService struct {
+	er repositories.ExchangeRepository
+	us user_service.UserService
+}
+
+func NewExchangeService(er *repositories.ExchangeRepository, us *user_service.UserService) *ExchangeService {
+	return &ExchangeService{er: *er, us: *us}
+}
+
+// GetGivenExchanges returns books the user gave away, newest first.
+func (es *ExchangeService) GetGivenExchanges(ctx context.Context, email string, page dto.PageRequest) (*dto.Page[dto.ExchangeDto], *exceptions.Error_) {
+	if _, exc := es.us.GetUserByEmail(ctx, email); exc != nil {
+		return nil, exc
+	}
+
+	exchanges, err := es.er.GetGiven(ctx, email, page)
+	if err != nil {
+		return nil, &exceptions.ErrDatabaseError
+	}
+	return exchanges, nil
+}
+
+// GetReceivedExchanges returns books the user received, newest first.
+func (es *ExchangeService) GetReceivedExchanges(ctx context.Context, email string, page dto.PageRequest) (*dto.Page[dto.ExchangeDto], *exceptions.Error_) {
+	if _, exc := es.us.GetUserByEmail(ctx, email); exc != nil {
+		return nil, exc
+	}
+
+	exchanges, err := es.er.GetReceived(ctx, email, page)
+	if err != nil {
+		return nil, &exceptions.ErrDatabaseError
+	}
+	return exchanges, nil
+}
+
+func (es *ExchangeService) GetAllExchanges(ctx context.Context, page dto.PageRequest) (*dto.Page[dto.ExchangeDto], *exceptions.Error_) {
+	exchanges, err := es.er.GetAll(ctx, page)
+	if err != nil {
+		return nil, &exceptions.ErrDatabaseError
+	}
+	return exchanges, nil
+}
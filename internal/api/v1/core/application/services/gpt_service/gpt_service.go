@@ -222,12 +222,12 @@ func (s *GPTService) GetAnswer(ctx context.Context, email string, message *dto.C
 	message.CreatedAt = time.Now().Format("02.01.2006")
 
 	fmt.Println(1)
-	lastMessages, err := s.cr.GetChatByUserEmail(ctx, email, 0, 20)
+	lastPage, err := s.cr.GetChatByUserEmail(ctx, email, dto.PageRequest{Limit: 20})
 	if err != nil {
 		return nil, &exceptions.InternalServerError
 	}
 	fmt.Println(2)
-	lastMessages = append(lastMessages, *message)
+	lastMessages := append(lastPage.Items, *message)
 	if err := s.cr.AddNewMessageToChatByEmail(ctx, email, message); err != nil {
 		return nil, &exceptions.InternalServerError
 	}
@@ -249,10 +249,10 @@ func (s *GPTService) GetAnswer(ctx context.Context, email string, message *dto.C
 	return &answer[len(answer)-1], nil
 }
 
-func (s *GPTService) GetChatByEmail(ctx context.Context, email string, offset int, limit int) ([]dto.ChatMessage, *exceptions.Error_) {
-	res, err := s.cr.GetChatByUserEmail(ctx, email, offset, limit)
+func (s *GPTService) GetChatByEmail(ctx context.Context, email string, page dto.PageRequest) (*dto.Page[dto.ChatMessage], *exceptions.Error_) {
+	res, err := s.cr.GetChatByUserEmail(ctx, email, page)
 	if err != nil {
-		return []dto.ChatMessage{}, &exceptions.InternalServerError
+		return nil, &exceptions.InternalServerError
 	}
 
 	return res, nil
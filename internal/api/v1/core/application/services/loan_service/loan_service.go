@@ -0,0 +1,163 @@
+package loan_service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"example.com/m/internal/api/v1/adapters/repositories"
+	"example.com/m/internal/api/v1/core/application/dto"
+	"example.com/m/internal/api/v1/core/application/exceptions"
+	"example.com/m/internal/api/v1/core/application/services/user_service"
+	"example.com/m/internal/config"
+)
+
+// how many loans of each kind are reminded about per check
+const reminderBatchSize = 100
+
+type LoanService struct {
+	er  *repositories.ExchangeRepository
+	us  *user_service.UserService
+	uow *repositories.UnitOfWork
+	fr  *repositories.FcmRepository
+	ptr *repositories.PushTokenRepository
+}
+
+func NewLoanService(
+	er *repositories.ExchangeRepository, us *user_service.UserService, uow *repositories.UnitOfWork,
+	fr *repositories.FcmRepository, ptr *repositories.PushTokenRepository,
+) *LoanService {
+	return &LoanService{er: er, us: us, uow: uow, fr: fr, ptr: ptr}
+}
+
+func (s *LoanService) notify(ctx context.Context, email string, notification *dto.NotificationDto) {
+	token, err := s.ptr.GetByEmail(&ctx, email)
+	if err != nil || token == nil {
+		return
+	}
+
+	s.fr.SendByToken(ctx, *token, notification)
+}
+
+// GetOverdueLoans returns loans the user lent or borrowed which are not
+// returned after the due date, newest first.
+func (s *LoanService) GetOverdueLoans(ctx context.Context, email string, page dto.PageRequest) (*dto.Page[dto.ExchangeDto], *exceptions.Error_) {
+	if _, exc := s.us.GetUserByEmail(ctx, email); exc != nil {
+		return nil, exc
+	}
+
+	now := time.Now().UTC().Format("2006-01-02T15:04:05Z")
+	loans, err := s.er.GetOverdueLoans(ctx, email, now, page)
+	if err != nil {
+		return nil, &exceptions.ErrDatabaseError
+	}
+	return loans, nil
+}
+
+// ConfirmReturn is called by the lender when the book is back. The post
+// becomes available again.
+func (s *LoanService) ConfirmReturn(ctx context.Context, ownerEmail string, exchangeID int64) (*dto.ExchangeDto, *exceptions.Error_) {
+	var loan *dto.ExchangeDto
+	err := s.uow.Do(ctx, func(tx *repositories.Tx) error {
+		var err error
+		loan, err = tx.Exchanges.Get(ctx, exchangeID)
+		if err != nil {
+			return err
+		}
+		if loan == nil {
+			return &exceptions.ErrExchangeNotFound
+		}
+		if loan.OwnerEmail != ownerEmail {
+			return &exceptions.ErrUserIsNotLender
+		}
+		if loan.DueAt == nil {
+			return &exceptions.ErrExchangeIsNotLoan
+		}
+
+		returnedAt := time.Now().UTC().Format("2006-01-02T15:04:05Z")
+		returned, err := tx.Exchanges.MarkReturned(ctx, exchangeID, returnedAt)
+		if err != nil {
+			return err
+		}
+		if !returned {
+			return &exceptions.ErrLoanAlreadyReturned
+		}
+		loan.ReturnedAt = &returnedAt
+
+		// the post may have been deleted while the book was lent
+		if loan.PostID == nil {
+			return nil
+		}
+		post, err := tx.Posts.GetForUpdate(ctx, *loan.PostID)
+		if err != nil {
+			return err
+		}
+		if post != nil && post.Status == "taken" {
+			return tx.Posts.Update(ctx, post.ID, &dto.UpdatePostDto{Status: "available"})
+		}
+		return nil
+	})
+	if err != nil {
+		var exc *exceptions.Error_
+		if errors.As(err, &exc) {
+			return nil, exc
+		}
+		return nil, &exceptions.ErrDatabaseError
+	}
+
+	if loan.RecipientEmail != "" {
+		s.notify(ctx, loan.RecipientEmail, &dto.NotificationDto{
+			Title:   "Книга возвращена",
+			Content: "Владелец подтвердил возврат книги \"" + loan.PostTitle + "\".",
+		})
+	}
+	return loan, nil
+}
+
+// SendReminders reminds borrowers about loans which are due soon and, once
+// per LoanOverdueReminderInterval, about overdue loans. The lender is told
+// when the loan becomes overdue.
+func (s *LoanService) SendReminders(ctx context.Context) *exceptions.Error_ {
+	now := time.Now().UTC()
+	nowFormatted := now.Format("2006-01-02T15:04:05Z")
+
+	dueSoon, err := s.er.GetLoansDueSoon(
+		ctx, nowFormatted, now.Add(config.Config.LoanDueReminder).Format("2006-01-02T15:04:05Z"), reminderBatchSize,
+	)
+	if err != nil {
+		return &exceptions.ErrDatabaseError
+	}
+	for _, loan := range dueSoon {
+		if err := s.er.SetReminderSent(ctx, loan.ID, "due", nowFormatted); err != nil {
+			return &exceptions.ErrDatabaseError
+		}
+		s.notify(ctx, loan.RecipientEmail, &dto.NotificationDto{
+			Title:   "Скоро вернуть книгу",
+			Content: "Книгу \"" + loan.PostTitle + "\" нужно вернуть до " + (*loan.DueAt)[:len("2006-01-02")] + ".",
+		})
+	}
+
+	overdue, err := s.er.GetOverdueLoansToRemind(
+		ctx, nowFormatted, now.Add(-config.Config.LoanOverdueReminderInterval).Format("2006-01-02T15:04:05Z"), reminderBatchSize,
+	)
+	if err != nil {
+		return &exceptions.ErrDatabaseError
+	}
+	for _, loan := range overdue {
+		if err := s.er.SetReminderSent(ctx, loan.ID, "overdue", nowFormatted); err != nil {
+			return &exceptions.ErrDatabaseError
+		}
+		s.notify(ctx, loan.RecipientEmail, &dto.NotificationDto{
+			Title:   "Книга просрочена",
+			Content: "Срок возврата книги \"" + loan.PostTitle + "\" истёк, верните её владельцу.",
+		})
+		if loan.OverdueReminderSentAt == nil {
+			s.notify(ctx, loan.OwnerEmail, &dto.NotificationDto{
+				Title:   "Книгу не вернули",
+				Content: "Книгу \"" + loan.PostTitle + "\" не вернули вовремя.",
+			})
+		}
+	}
+
+	return nil
+}
@@ -2,13 +2,19 @@ package place_service
 
 import (
 	"context"
+	"time"
 
+	"example.com/m/internal/api/v1/adapters/repositories"
 	"example.com/m/internal/api/v1/core/application/dto"
 	"example.com/m/internal/api/v1/core/application/exceptions"
 )
 
+// longest period of pickup slots returned at once
+const maxSlotsPeriod = time.Hour * 24 * 31
+
 type PlaceService struct {
-	pr IPlaceRepository
+	pr  IPlaceRepository
+	psr *repositories.PickupSlotRepository
 }
 
 type IPlaceRepository interface {
@@ -16,10 +22,12 @@ type IPlaceRepository interface {
 	Get(ctx context.Context, id int64) (*dto.PlaceDto, error)
 	Create(ctx context.Context, p *dto.CreatePlaceDto) (*dto.PlaceDto, error)
 	Delete(ctx context.Context, id int64) error
+	GetOpeningHours(ctx context.Context, placeID int64) ([]dto.OpeningHoursDto, error)
+	SetOpeningHours(ctx context.Context, placeID int64, hours []dto.OpeningHoursDto) error
 }
 
-func NewPlaceService(pr IPlaceRepository) *PlaceService {
-	return &PlaceService{pr: pr}
+func NewPlaceService(pr IPlaceRepository, psr *repositories.PickupSlotRepository) *PlaceService {
+	return &PlaceService{pr: pr, psr: psr}
 }
 
 func (ps *PlaceService) GetPlaces(ctx context.Context) (*[]dto.PlaceDto, *exceptions.Error_) {
@@ -62,3 +70,146 @@ func (ps *PlaceService) DeletePlace(ctx context.Context, id int64) *exceptions.E
 	}
 	return nil
 }
+
+func (ps *PlaceService) getPlace(ctx context.Context, id int64) *exceptions.Error_ {
+	place, err := ps.pr.Get(ctx, id)
+	if err != nil {
+		return &exceptions.ErrDatabaseError
+	}
+	if place == nil {
+		return &exceptions.ErrPlaceNotFound
+	}
+	return nil
+}
+
+func (ps *PlaceService) GetOpeningHours(ctx context.Context, placeID int64) ([]dto.OpeningHoursDto, *exceptions.Error_) {
+	if exc := ps.getPlace(ctx, placeID); exc != nil {
+		return nil, exc
+	}
+
+	hours, err := ps.pr.GetOpeningHours(ctx, placeID)
+	if err != nil {
+		return nil, &exceptions.ErrDatabaseError
+	}
+	return hours, nil
+}
+
+// SetOpeningHours replaces the opening hours of the place. Existing pickup
+// slots are kept.
+func (ps *PlaceService) SetOpeningHours(ctx context.Context, placeID int64, hours []dto.OpeningHoursDto) ([]dto.OpeningHoursDto, *exceptions.Error_) {
+	if exc := ps.getPlace(ctx, placeID); exc != nil {
+		return nil, exc
+	}
+
+	for _, h := range hours {
+		// "HH:MM" strings compare as times
+		if h.OpensAt >= h.ClosesAt {
+			return nil, &exceptions.ErrInvalidOpeningHours
+		}
+	}
+
+	if err := ps.pr.SetOpeningHours(ctx, placeID, hours); err != nil {
+		return nil, &exceptions.ErrDatabaseError
+	}
+	return ps.GetOpeningHours(ctx, placeID)
+}
+
+// withinOpeningHours tells whether the slot lies within one of the opening
+// intervals of its day.
+func withinOpeningHours(hours []dto.OpeningHoursDto, startsAt time.Time, endsAt time.Time) bool {
+	if startsAt.YearDay() != endsAt.YearDay() || startsAt.Year() != endsAt.Year() {
+		return false
+	}
+
+	start, end := startsAt.Format("15:04"), endsAt.Format("15:04")
+	for _, h := range hours {
+		if h.Weekday == int(startsAt.Weekday()) && h.OpensAt <= start && end <= h.ClosesAt {
+			return true
+		}
+	}
+	return false
+}
+
+// CreatePickupSlot adds a slot in which up to Capacity bookers may pick up
+// their books at the place.
+func (ps *PlaceService) CreatePickupSlot(ctx context.Context, placeID int64, s *dto.CreatePickupSlotDto) (*dto.PickupSlotDto, *exceptions.Error_) {
+	hours, exc := ps.GetOpeningHours(ctx, placeID)
+	if exc != nil {
+		return nil, exc
+	}
+
+	startsAt, err := time.Parse(time.RFC3339, s.StartsAt)
+	if err != nil {
+		return nil, &exceptions.ErrInvalidPickupSlot
+	}
+	endsAt, err := time.Parse(time.RFC3339, s.EndsAt)
+	if err != nil {
+		return nil, &exceptions.ErrInvalidPickupSlot
+	}
+	startsAt, endsAt = startsAt.UTC(), endsAt.UTC()
+	if !startsAt.After(time.Now()) || !endsAt.After(startsAt) || !withinOpeningHours(hours, startsAt, endsAt) {
+		return nil, &exceptions.ErrInvalidPickupSlot
+	}
+
+	id, err := ps.psr.Create(ctx, &dto.PickupSlotToCreateDto{
+		PlaceID:   placeID,
+		StartsAt:  startsAt.Format("2006-01-02T15:04:05Z"),
+		EndsAt:    endsAt.Format("2006-01-02T15:04:05Z"),
+		Capacity:  s.Capacity,
+		CreatedAt: time.Now().UTC().Format("2006-01-02T15:04:05Z"),
+	})
+	if err != nil {
+		return nil, &exceptions.ErrDatabaseError
+	}
+
+	slot, err := ps.psr.Get(ctx, *id)
+	if err != nil {
+		return nil, &exceptions.ErrDatabaseError
+	}
+	return slot, nil
+}
+
+// GetPickupSlots returns slots of the place starting within [from, to). From
+// defaults to now and the period to a week, at most maxSlotsPeriod is
+// returned.
+func (ps *PlaceService) GetPickupSlots(ctx context.Context, placeID int64, from time.Time, to time.Time) ([]dto.PickupSlotDto, *exceptions.Error_) {
+	if exc := ps.getPlace(ctx, placeID); exc != nil {
+		return nil, exc
+	}
+
+	if from.IsZero() {
+		from = time.Now()
+	}
+	if to.IsZero() {
+		to = from.Add(time.Hour * 24 * 7)
+	}
+	if to.Sub(from) > maxSlotsPeriod {
+		to = from.Add(maxSlotsPeriod)
+	}
+
+	slots, err := ps.psr.GetByPlace(ctx, placeID,
+		from.UTC().Format("2006-01-02T15:04:05Z"), to.UTC().Format("2006-01-02T15:04:05Z"))
+	if err != nil {
+		return nil, &exceptions.ErrDatabaseError
+	}
+	return slots, nil
+}
+
+// DeletePickupSlot deletes the slot unless active bookings reserved it.
+func (ps *PlaceService) DeletePickupSlot(ctx context.Context, placeID int64, slotID int64) *exceptions.Error_ {
+	slot, err := ps.psr.Get(ctx, slotID)
+	if err != nil {
+		return &exceptions.ErrDatabaseError
+	}
+	if slot == nil || slot.PlaceID != placeID {
+		return &exceptions.ErrPickupSlotNotFound
+	}
+	if slot.Reserved > 0 {
+		return &exceptions.ErrPickupSlotReserved
+	}
+
+	if err := ps.psr.Delete(ctx, slotID); err != nil {
+		return &exceptions.ErrDatabaseError
+	}
+	return nil
+}
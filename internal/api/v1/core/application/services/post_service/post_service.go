@@ -2,8 +2,10 @@ package post_service
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"mime/multipart"
+	"slices"
 	"strings"
 	"time"
 
@@ -13,29 +15,49 @@ import (
 	"example.com/m/internal/api/v1/core/application/services/gpt_service"
 	"example.com/m/internal/api/v1/core/application/services/place_service"
 	"example.com/m/internal/api/v1/core/application/services/user_service"
+	"go.uber.org/zap"
 )
 
+const maxPostImages = 5
+
 type PostService struct {
 	pr repositories.PostRepository
 	ps place_service.PlaceService
 	us user_service.UserService
 	gs gpt_service.GPTService
+
+	uow    *repositories.UnitOfWork
+	logger *zap.Logger
 }
 
 func NewPostService(
 	pr *repositories.PostRepository, ps *place_service.PlaceService,
 	us *user_service.UserService, gs *gpt_service.GPTService,
+	uow *repositories.UnitOfWork, logger *zap.Logger,
 ) *PostService {
-	return &PostService{pr: *pr, ps: *ps, us: *us, gs: *gs}
+	return &PostService{pr: *pr, ps: *ps, us: *us, gs: *gs, uow: uow, logger: logger}
+}
+
+// deleteImage removes an image which is no longer referenced by any post
+// from the storage. A failure leaves an orphaned file, so it is only logged.
+func (ps *PostService) deleteImage(ctx context.Context, postID int64, imageURL string) {
+	if err := ps.pr.DeleteImage(ctx, imageURL); err != nil {
+		ps.logger.Warn(
+			"Failed to delete post image",
+			zap.Int64("post_id", postID),
+			zap.String("image", imageURL),
+			zap.String("error", err.Error()),
+		)
+	}
 }
 
 func (ps *PostService) CreatePost(ctx context.Context, userEmail string, p *dto.CreatePostDto) (*dto.PostDto, *exceptions.Error_) {
-	userExists, exc := ps.us.IsUserExist(ctx, userEmail, "")
+	user, exc := ps.us.GetUserByEmail(ctx, userEmail)
 	if exc != nil {
-		return nil, &exceptions.ErrDatabaseError
+		return nil, exc
 	}
-	if !*userExists {
-		return nil, &exceptions.ErrUserNotFound
+	if !user.IsVerified {
+		return nil, &exceptions.ErrUserNotVerified
 	}
 
 	placeExists, exc := ps.ps.PlaceIsExists(ctx, p.PlaceID)
@@ -46,6 +68,15 @@ func (ps *PostService) CreatePost(ctx context.Context, userEmail string, p *dto.
 		return nil, &exceptions.ErrPlaceNotFound
 	}
 
+	listingType := p.ListingType
+	if listingType == "" {
+		listingType = dto.ListingTypeGiveaway
+	}
+	// only loans have a period and every loan has one
+	if (listingType == dto.ListingTypeLoan) != (p.LoanPeriodDays != nil) {
+		return nil, &exceptions.ErrInvalidLoanPeriod
+	}
+
 	postToCreate := dto.PostDtoWithoutId{
 		Images:          p.Images,
 		UserEmail:       userEmail,
@@ -61,6 +92,8 @@ func (ps *PostService) CreatePost(ctx context.Context, userEmail string, p *dto.
 		CreatedAt:       time.Now().UTC().Format("2006-01-02T15:04:05Z"),
 		Cover:           p.Cover,
 		PagesCount:      p.PagesCount,
+		ListingType:     listingType,
+		LoanPeriodDays:  p.LoanPeriodDays,
 	}
 
 	id, err := ps.pr.Create(ctx, &postToCreate)
@@ -103,7 +136,7 @@ func (ps *PostService) SetPostQuote(ctx context.Context, postID int64, author st
 	return nil
 }
 
-func (ps *PostService) GetMyPosts(ctx context.Context, userEmail string, status string, limit, offset uint) (*[]dto.PostDto, *exceptions.Error_) {
+func (ps *PostService) GetMyPosts(ctx context.Context, userEmail string, status string, page dto.PageRequest) (*dto.Page[dto.PostDto], *exceptions.Error_) {
 	userExists, exc := ps.us.IsUserExist(ctx, userEmail, "")
 	if exc != nil {
 		return nil, exc
@@ -112,7 +145,7 @@ func (ps *PostService) GetMyPosts(ctx context.Context, userEmail string, status
 		return nil, &exceptions.ErrUserNotFound
 	}
 
-	posts, err := ps.pr.GetAllMyPosted(ctx, userEmail, status, limit, offset)
+	posts, err := ps.pr.GetAllMyPosted(ctx, userEmail, status, page)
 	if err != nil {
 		return nil, &exceptions.ErrDatabaseError
 	}
@@ -142,35 +175,98 @@ func (ps *PostService) UserIsPostOwner(ctx context.Context, userEmail string, id
 	return &state, nil
 }
 
+// getOwnPost returns the post if the user is its owner.
+func (ps *PostService) getOwnPost(ctx context.Context, userEmail string, id int64) (*dto.PostDto, *exceptions.Error_) {
+	post, exc := ps.GetPost(ctx, id, userEmail)
+	if exc != nil {
+		return nil, exc
+	}
+	if post.UserEmail != userEmail {
+		return nil, &exceptions.ErrUserIsNotOwner
+	}
+	return post, nil
+}
+
+// DeletePost deletes the post together with its images. A booked post can't
+// be deleted until the booking is finished. The status is checked with the
+// post row locked, so a booking made meanwhile isn't deleted with the post.
 func (ps *PostService) DeletePost(ctx context.Context, userEmail string, id int64) *exceptions.Error_ {
-	if userIsOwner, err := ps.UserIsPostOwner(ctx, userEmail, id); err != nil {
-		return &exceptions.ErrDatabaseError
-	} else if !*userIsOwner {
-		return &exceptions.ErrUserIsNotOwner
+	post, exc := ps.getOwnPost(ctx, userEmail, id)
+	if exc != nil {
+		return exc
 	}
 
-	err := ps.pr.Delete(ctx, id)
+	err := ps.uow.Do(ctx, func(tx *repositories.Tx) error {
+		locked, err := tx.Posts.GetForUpdate(ctx, id)
+		if err != nil {
+			return err
+		}
+		if locked == nil {
+			return &exceptions.PostNotFoudErr
+		}
+		if locked.Status == "booked" {
+			return &exceptions.ErrPostIsBooked
+		}
+		return tx.Posts.Delete(ctx, id)
+	})
 	if err != nil {
+		var exc *exceptions.Error_
+		if errors.As(err, &exc) {
+			return exc
+		}
 		return &exceptions.ErrDatabaseError
 	}
+
+	for _, image := range post.Images {
+		ps.deleteImage(ctx, id, image)
+	}
 	return nil
 }
 
-func (ps *PostService) GetAllAvailablePosts(ctx context.Context, userEmail string, options repositories.PostFilterOptions, limit, offset uint) (*[]dto.PostDto, *exceptions.Error_) {
-	posts, err := ps.pr.GetAllAvailable(ctx, userEmail, options, limit, offset)
+// GetAllAvailablePosts returns a page of available posts. The first page also
+// has facet counts for the filters.
+func (ps *PostService) GetAllAvailablePosts(ctx context.Context, userEmail string, options repositories.PostFilterOptions, page dto.PageRequest) (*dto.AvailablePostsDto, *exceptions.Error_) {
+	switch options.Sort {
+	case "", repositories.PostSortNewest, repositories.PostSortOldest, repositories.PostSortTitle, repositories.PostSortRating:
+	default:
+		return nil, &exceptions.ErrInvalidPostSort
+	}
+
+	posts, err := ps.pr.GetAllAvailable(ctx, userEmail, options, page)
 	if err != nil {
 		fmt.Println(err)
 		return nil, &exceptions.ErrDatabaseError
 	}
 
-	return posts, nil
+	result := dto.AvailablePostsDto{Page: *posts}
+	if page.Cursor == nil {
+		if result.Facets, err = ps.pr.GetAvailableFacets(ctx, userEmail, options); err != nil {
+			return nil, &exceptions.ErrDatabaseError
+		}
+	}
+
+	return &result, nil
 }
 
+// UpdatePost changes the listing fields of the post. The status is managed by
+// bookings and can't be changed here.
 func (ps *PostService) UpdatePost(ctx context.Context, userEmail string, id int64, p *dto.UpdatePostDto) (*dto.PostDto, *exceptions.Error_) {
-	if userIsOwner, err := ps.UserIsPostOwner(ctx, userEmail, id); err != nil {
-		return nil, &exceptions.ErrDatabaseError
-	} else if !*userIsOwner {
-		return nil, &exceptions.ErrUserIsNotOwner
+	if p.Status != "" {
+		return nil, &exceptions.ErrPostStatusCannotBeChanged
+	}
+
+	if _, exc := ps.getOwnPost(ctx, userEmail, id); exc != nil {
+		return nil, exc
+	}
+
+	if p.PlaceID != 0 {
+		placeExists, exc := ps.ps.PlaceIsExists(ctx, p.PlaceID)
+		if exc != nil {
+			return nil, exc
+		}
+		if !*placeExists {
+			return nil, &exceptions.ErrPlaceNotFound
+		}
 	}
 
 	err := ps.pr.Update(ctx, id, p)
@@ -186,7 +282,7 @@ func (ps *PostService) UpdatePost(ctx context.Context, userEmail string, id int6
 	return post, nil
 }
 
-func (ps *PostService) GetAllMyPosts(ctx context.Context, userEmail string, status string, limit, offset uint) (*[]dto.PostDto, *exceptions.Error_) {
+func (ps *PostService) GetAllMyPosts(ctx context.Context, userEmail string, status string, page dto.PageRequest) (*dto.Page[dto.PostDto], *exceptions.Error_) {
 	userExists, exc := ps.us.IsUserExist(ctx, userEmail, "")
 	if exc != nil {
 		return nil, exc
@@ -195,7 +291,7 @@ func (ps *PostService) GetAllMyPosts(ctx context.Context, userEmail string, stat
 		return nil, &exceptions.ErrUserNotFound
 	}
 
-	posts, err := ps.pr.GetAllMyPosted(ctx, userEmail, status, limit, offset)
+	posts, err := ps.pr.GetAllMyPosted(ctx, userEmail, status, page)
 	if err != nil {
 		return nil, &exceptions.ErrDatabaseError
 	}
@@ -247,7 +343,7 @@ func (ps *PostService) AddFavorite(ctx context.Context, userEmail string, postID
 	return nil
 }
 
-func (ps *PostService) GetAllFavourites(ctx context.Context, userEmail string, limit, offset uint) (*[]dto.PostDto, *exceptions.Error_) {
+func (ps *PostService) GetAllFavourites(ctx context.Context, userEmail string, page dto.PageRequest) (*dto.Page[dto.PostDto], *exceptions.Error_) {
 	userExists, exc := ps.us.IsUserExist(ctx, userEmail, "")
 	if exc != nil {
 		return nil, exc
@@ -255,22 +351,22 @@ func (ps *PostService) GetAllFavourites(ctx context.Context, userEmail string, l
 	if !*userExists {
 		return nil, &exceptions.ErrUserNotFound
 	}
-	posts, err := ps.pr.GetAllFavourite(ctx, userEmail, limit, offset)
+	posts, err := ps.pr.GetAllFavourite(ctx, userEmail, page)
 	if err != nil {
 		return nil, &exceptions.ErrDatabaseError
 	}
 	return posts, nil
 }
 
-func (ps *PostService) SearchByTitleOrAuthorOrGenre(ctx context.Context, query string, limit, offset uint, userEmail string) (*[]dto.PostDto, *exceptions.Error_) {
-	posts, err := ps.pr.SearchByTitleOrAuthorOrGenre(ctx, query, limit, offset, userEmail)
+func (ps *PostService) SearchByTitleOrAuthorOrGenre(ctx context.Context, query string, page dto.PageRequest, userEmail string) (*dto.Page[dto.PostDto], *exceptions.Error_) {
+	posts, err := ps.pr.SearchByTitleOrAuthorOrGenre(ctx, query, page, userEmail)
 	if err != nil {
 		return nil, &exceptions.ErrDatabaseError
 	}
 	return posts, nil
 }
 
-func (ps *PostService) GetAllMyBooked(ctx context.Context, userEmail string, limit, offset uint) (*[]dto.PostDto, *exceptions.Error_) {
+func (ps *PostService) GetAllMyBooked(ctx context.Context, userEmail string, page dto.PageRequest) (*dto.Page[dto.PostDto], *exceptions.Error_) {
 	userExists, exc := ps.us.IsUserExist(ctx, userEmail, "")
 	if exc != nil {
 		return nil, exc
@@ -278,7 +374,7 @@ func (ps *PostService) GetAllMyBooked(ctx context.Context, userEmail string, lim
 	if !*userExists {
 		return nil, &exceptions.ErrUserNotFound
 	}
-	posts, err := ps.pr.GetAllMyBooked(ctx, userEmail, limit, offset)
+	posts, err := ps.pr.GetAllMyBooked(ctx, userEmail, page)
 	if err != nil {
 		return nil, &exceptions.ErrDatabaseError
 	}
@@ -293,12 +389,12 @@ func (s *PostService) getImageType(image *multipart.FileHeader) string {
 func (s *PostService) AddImage(
 	ctx context.Context, postID int64, header *multipart.FileHeader, image multipart.File, userEmail string,
 ) (string, *exceptions.Error_) {
-	post, err := s.pr.Get(ctx, postID, userEmail)
-	if err != nil {
-		return "", &exceptions.ErrDatabaseError
+	post, exc := s.getOwnPost(ctx, userEmail, postID)
+	if exc != nil {
+		return "", exc
 	}
-	if post == nil {
-		return "", &exceptions.PostNotFoudErr
+	if len(post.Images) >= maxPostImages {
+		return "", &exceptions.ErrTooManyImages
 	}
 
 	imageType := s.getImageType(header)
@@ -316,6 +412,78 @@ func (s *PostService) AddImage(
 	return uri, nil
 }
 
+// ReorderImages sets a new order of the post images, the first image becomes
+// the primary one.
+func (s *PostService) ReorderImages(ctx context.Context, userEmail string, postID int64, images []string) (*dto.PostDto, *exceptions.Error_) {
+	post, exc := s.getOwnPost(ctx, userEmail, postID)
+	if exc != nil {
+		return nil, exc
+	}
+
+	if len(images) != len(post.Images) {
+		return nil, &exceptions.ErrInvalidImageOrder
+	}
+	left := map[string]int{}
+	for _, image := range post.Images {
+		left[image]++
+	}
+	for _, image := range images {
+		if left[image] == 0 {
+			return nil, &exceptions.ErrInvalidImageOrder
+		}
+		left[image]--
+	}
+
+	if err := s.pr.SetImages(ctx, postID, images); err != nil {
+		return nil, &exceptions.ErrDatabaseError
+	}
+	post.Images = images
+	return post, nil
+}
+
+// SetPrimaryImage moves the image to the first place.
+func (s *PostService) SetPrimaryImage(ctx context.Context, userEmail string, postID int64, imageURL string) (*dto.PostDto, *exceptions.Error_) {
+	post, exc := s.getOwnPost(ctx, userEmail, postID)
+	if exc != nil {
+		return nil, exc
+	}
+
+	index := slices.Index(post.Images, imageURL)
+	if index == -1 {
+		return nil, &exceptions.ErrImageNotFound
+	}
+
+	images := append([]string{imageURL}, slices.Delete(slices.Clone(post.Images), index, index+1)...)
+	if err := s.pr.SetImages(ctx, postID, images); err != nil {
+		return nil, &exceptions.ErrDatabaseError
+	}
+	post.Images = images
+	return post, nil
+}
+
+// RemoveImage removes the image from the post and from the storage. Once the
+// post no longer references the image, a failure to delete the file doesn't
+// fail the request.
+func (s *PostService) RemoveImage(ctx context.Context, userEmail string, postID int64, imageURL string) (*dto.PostDto, *exceptions.Error_) {
+	post, exc := s.getOwnPost(ctx, userEmail, postID)
+	if exc != nil {
+		return nil, exc
+	}
+
+	index := slices.Index(post.Images, imageURL)
+	if index == -1 {
+		return nil, &exceptions.ErrImageNotFound
+	}
+
+	images := slices.Delete(slices.Clone(post.Images), index, index+1)
+	if err := s.pr.SetImages(ctx, postID, images); err != nil {
+		return nil, &exceptions.ErrDatabaseError
+	}
+	s.deleteImage(ctx, postID, imageURL)
+	post.Images = images
+	return post, nil
+}
+
 func (s *PostService) GenerateBriefContent(ctx context.Context, postID int64, userEmail string) (string, *exceptions.Error_) {
 	post, err := s.GetPost(ctx, postID, userEmail)
 	if err != nil {
@@ -8,40 +8,74 @@ import (
 	"example.com/m/internal/api/v1/core/application/dto"
 	"example.com/m/internal/api/v1/core/application/exceptions"
 	"example.com/m/internal/api/v1/core/application/services/user_service"
+	"example.com/m/internal/config"
 )
 
 type ReviewService struct {
 	rr repositories.ReviewRepository
+	er repositories.ExchangeRepository
 	us user_service.UserService
 }
 
-func NewReviewService(rr *repositories.ReviewRepository, us *user_service.UserService) *ReviewService {
-	return &ReviewService{rr: *rr, us: *us}
+func NewReviewService(rr *repositories.ReviewRepository, er *repositories.ExchangeRepository, us *user_service.UserService) *ReviewService {
+	return &ReviewService{rr: *rr, er: *er, us: *us}
 }
 
+// CreateReview reviews the other side of the exchange. Each participant can
+// review an exchange once.
 func (rs *ReviewService) CreateReview(ctx context.Context, reviewerEmail string, review *dto.ReviewToCreateDto) *exceptions.Error_ {
-	if userExists, exc := rs.us.IsUserExist(ctx, review.TargetUserEmail, ""); !*userExists {
-		return &exceptions.ErrUserNotFound
-	} else if exc != nil {
+	if _, exc := rs.us.GetUserByEmail(ctx, reviewerEmail); exc != nil {
 		return exc
 	}
 
-	if userExists, exc := rs.us.IsUserExist(ctx, reviewerEmail, ""); !*userExists {
-		return &exceptions.ErrUserNotFound
-	} else if exc != nil {
-		return exc
+	exchange, err := rs.er.Get(ctx, review.ExchangeID)
+	if err != nil {
+		return &exceptions.ErrDatabaseError
+	}
+	if exchange == nil {
+		return &exceptions.ErrExchangeNotFound
 	}
 
-	if review.TargetUserEmail == reviewerEmail {
+	var targetEmail string
+	switch reviewerEmail {
+	case exchange.OwnerEmail:
+		targetEmail = exchange.RecipientEmail
+	case exchange.RecipientEmail:
+		targetEmail = exchange.OwnerEmail
+	default:
+		return &exceptions.ErrUserIsNotExchangeParticipant
+	}
+
+	if targetEmail == reviewerEmail {
 		return &exceptions.ErrUserIsTryingToReviewHimself
 	}
 
+	exists, err := rs.rr.ExistsForExchange(ctx, exchange.ID, reviewerEmail)
+	if err != nil {
+		return &exceptions.ErrDatabaseError
+	}
+	if exists {
+		return &exceptions.ErrReviewAlreadyExists
+	}
+
+	var rating int
+	if review.Rating != nil {
+		rating = *review.Rating
+	}
+	var comment string
+	if review.Comment != nil {
+		comment = *review.Comment
+	}
+
+	now := time.Now().UTC().Format("2006-01-02T15:04:05Z")
 	if exc := rs.rr.Create(ctx, &dto.ReviewWithoutIDDto{
-		TargetUserEmail:   review.TargetUserEmail,
+		TargetUserEmail:   targetEmail,
 		ReviewerUserEmail: reviewerEmail,
-		Rating:            *review.Rating,
-		Comment:           *review.Comment,
-		CreatedAt:         time.Now().UTC().Format("2006-01-02T15:04:05Z"),
+		Rating:            rating,
+		Comment:           comment,
+		CreatedAt:         now,
+		ExchangeID:        exchange.ID,
+		UpdatedAt:         now,
 	}); exc != nil {
 		return &exceptions.ErrDatabaseError
 	}
@@ -49,12 +83,84 @@ func (rs *ReviewService) CreateReview(ctx context.Context, reviewerEmail string,
 	return nil
 }
 
-func (rs *ReviewService) GetReviewsForUser(ctx context.Context, targetUsername string, limit, offset uint) (*[]dto.ReviewToGetDto, *exceptions.Error_) {
+// getOwnReview returns the review if the user is its author and the edit
+// window is still open.
+func (rs *ReviewService) getOwnReview(ctx context.Context, email string, id int64) (*dto.ReviewDto, *exceptions.Error_) {
+	review, err := rs.rr.Get(ctx, id)
+	if err != nil {
+		return nil, &exceptions.ErrDatabaseError
+	}
+	if review == nil {
+		return nil, &exceptions.ErrReviewNotFound
+	}
+
+	if review.ReviewerUserEmail != email {
+		return nil, &exceptions.ErrUserIsNotReviewAuthor
+	}
+
+	createdAt, err := time.Parse(time.RFC3339, review.CreatedAt)
+	if err != nil {
+		return nil, &exceptions.InternalServerError
+	}
+	if time.Now().UTC().After(createdAt.Add(config.Config.ReviewEditWindow)) {
+		return nil, &exceptions.ErrReviewEditWindowExpired
+	}
+
+	return review, nil
+}
+
+func (rs *ReviewService) UpdateReview(ctx context.Context, email string, id int64, review *dto.ReviewToUpdateDto) *exceptions.Error_ {
+	if _, exc := rs.getOwnReview(ctx, email, id); exc != nil {
+		return exc
+	}
+
+	if err := rs.rr.Update(ctx, id, &dto.UpdateReviewDto{
+		Rating:    review.Rating,
+		Comment:   review.Comment,
+		UpdatedAt: time.Now().UTC().Format("2006-01-02T15:04:05Z"),
+	}); err != nil {
+		return &exceptions.ErrDatabaseError
+	}
+
+	return nil
+}
+
+func (rs *ReviewService) DeleteReview(ctx context.Context, email string, id int64) *exceptions.Error_ {
+	if _, exc := rs.getOwnReview(ctx, email, id); exc != nil {
+		return exc
+	}
+
+	if err := rs.rr.Delete(ctx, id); err != nil {
+		return &exceptions.ErrDatabaseError
+	}
+
+	return nil
+}
+
+// SetReviewHidden hides the review from the profile of its target and from
+// the rating, or shows it again.
+func (rs *ReviewService) SetReviewHidden(ctx context.Context, id int64, hidden bool) *exceptions.Error_ {
+	review, err := rs.rr.Get(ctx, id)
+	if err != nil {
+		return &exceptions.ErrDatabaseError
+	}
+	if review == nil {
+		return &exceptions.ErrReviewNotFound
+	}
+
+	if err := rs.rr.SetHidden(ctx, id, hidden); err != nil {
+		return &exceptions.ErrDatabaseError
+	}
+
+	return nil
+}
+
+func (rs *ReviewService) GetReviewsForUser(ctx context.Context, targetUsername string, page dto.PageRequest) (*dto.Page[dto.ReviewToGetDto], *exceptions.Error_) {
 	user, exc := rs.us.GetUserByUsername(ctx, targetUsername)
 	if exc != nil {
 		return nil, exc
 	}
-	reviews, err := rs.rr.GetReviewsForUser(ctx, user.Email, limit, offset)
+	reviews, err := rs.rr.GetReviewsForUser(ctx, user.Email, page)
 	if err != nil {
 		return nil, &exceptions.ErrDatabaseError
 	}
@@ -0,0 +1,111 @@
+package role_service
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"example.com/m/internal/api/v1/adapters/repositories"
+	"example.com/m/internal/api/v1/core/application/dto"
+	"example.com/m/internal/api/v1/core/application/exceptions"
+	"example.com/m/internal/api/v1/core/application/services/place_service"
+	"example.com/m/internal/api/v1/core/application/services/user_service"
+)
+
+type RoleService struct {
+	rr *repositories.RoleRepository
+	us *user_service.UserService
+	ps *place_service.PlaceService
+}
+
+func NewRoleService(rr *repositories.RoleRepository, us *user_service.UserService, ps *place_service.PlaceService) *RoleService {
+	return &RoleService{rr: rr, us: us, ps: ps}
+}
+
+// HasPermission reports whether one of the user's roles grants the permission.
+// A role scoped to a place grants it only for that place, so placeID must be
+// given for such permissions.
+func (s *RoleService) HasPermission(ctx context.Context, email string, permission string, placeID *int64) (bool, *exceptions.Error_) {
+	roles, err := s.rr.GetByEmail(ctx, email)
+	if err != nil {
+		return false, &exceptions.ErrDatabaseError
+	}
+
+	for _, role := range roles {
+		if !slices.Contains(dto.RolePermissions[role.Role], permission) {
+			continue
+		}
+		if role.PlaceID == nil || (placeID != nil && *role.PlaceID == *placeID) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (s *RoleService) GetRoles(ctx context.Context, email string) ([]dto.RoleDto, *exceptions.Error_) {
+	roles, err := s.rr.GetByEmail(ctx, email)
+	if err != nil {
+		return nil, &exceptions.ErrDatabaseError
+	}
+	return roles, nil
+}
+
+func (s *RoleService) GetRolesByUsername(ctx context.Context, username string) ([]dto.RoleDto, *exceptions.Error_) {
+	user, exception := s.us.GetUserByUsername(ctx, username)
+	if exception != nil {
+		return nil, exception
+	}
+	return s.GetRoles(ctx, user.Email)
+}
+
+func (s *RoleService) GrantRole(ctx context.Context, granterEmail string, username string, r dto.GrantRoleDto) ([]dto.RoleDto, *exceptions.Error_) {
+	if (r.Role == dto.RolePlaceManager) != (r.PlaceID != nil) {
+		return nil, &exceptions.ErrInvalidRoleScope
+	}
+	if r.PlaceID != nil {
+		exists, exception := s.ps.PlaceIsExists(ctx, *r.PlaceID)
+		if exception != nil {
+			return nil, exception
+		}
+		if !*exists {
+			return nil, &exceptions.ErrPlaceNotFound
+		}
+	}
+
+	user, exception := s.us.GetUserByUsername(ctx, username)
+	if exception != nil {
+		return nil, exception
+	}
+
+	err := s.rr.Create(ctx, &dto.RoleDto{
+		UserEmail: user.Email,
+		Role:      r.Role,
+		PlaceID:   r.PlaceID,
+		GrantedBy: granterEmail,
+		CreatedAt: time.Now().UTC().Format("2006-01-02T15:04:05Z"),
+	})
+	if err != nil {
+		return nil, &exceptions.ErrDatabaseError
+	}
+	return s.GetRoles(ctx, user.Email)
+}
+
+func (s *RoleService) RevokeRole(ctx context.Context, revokerEmail string, username string, role string, placeID *int64) *exceptions.Error_ {
+	user, exception := s.us.GetUserByUsername(ctx, username)
+	if exception != nil {
+		return exception
+	}
+	// otherwise the last admin could leave nobody able to grant roles
+	if user.Email == revokerEmail && role == dto.RoleAdmin {
+		return &exceptions.ErrRevokeOwnAdminRole
+	}
+
+	deleted, err := s.rr.Delete(ctx, user.Email, role, placeID)
+	if err != nil {
+		return &exceptions.ErrDatabaseError
+	}
+	if !deleted {
+		return &exceptions.ErrRoleNotFound
+	}
+	return nil
+}
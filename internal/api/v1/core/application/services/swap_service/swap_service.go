@@ -0,0 +1,501 @@
+package swap_service
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	"example.com/m/internal/api/v1/adapters/repositories"
+	"example.com/m/internal/api/v1/core/application/dto"
+	"example.com/m/internal/api/v1/core/application/exceptions"
+	"example.com/m/internal/api/v1/core/application/services/booking_service"
+	"example.com/m/internal/api/v1/core/application/services/user_service"
+	"example.com/m/internal/config"
+)
+
+type SwapService struct {
+	sr  *repositories.SwapRepository
+	us  *user_service.UserService
+	uow *repositories.UnitOfWork
+	fr  *repositories.FcmRepository
+	ptr *repositories.PushTokenRepository
+}
+
+func NewSwapService(
+	sr *repositories.SwapRepository, us *user_service.UserService, uow *repositories.UnitOfWork,
+	fr *repositories.FcmRepository, ptr *repositories.PushTokenRepository,
+) *SwapService {
+	return &SwapService{sr: sr, us: us, uow: uow, fr: fr, ptr: ptr}
+}
+
+func (ss *SwapService) notify(ctx context.Context, email string, notification *dto.NotificationDto) {
+	token, err := ss.ptr.GetByEmail(&ctx, email)
+	if err != nil || token == nil {
+		return
+	}
+
+	ss.fr.SendByToken(ctx, *token, notification)
+}
+
+// inTx runs fn in a transaction. fn may return *exceptions.Error_ to roll the
+// transaction back with that error; any other error becomes a database error.
+func (ss *SwapService) inTx(ctx context.Context, fn func(tx *repositories.Tx) error) *exceptions.Error_ {
+	err := ss.uow.Do(ctx, fn)
+	if err == nil {
+		return nil
+	}
+
+	var exc *exceptions.Error_
+	if errors.As(err, &exc) {
+		return exc
+	}
+	return &exceptions.ErrDatabaseError
+}
+
+func (ss *SwapService) getVerifiedUser(ctx context.Context, email string) *exceptions.Error_ {
+	user, exc := ss.us.GetUserByEmail(ctx, email)
+	if exc != nil {
+		return exc
+	}
+	if !user.IsVerified {
+		return &exceptions.ErrUserNotVerified
+	}
+	// a swap books the posts as well
+	if user.BookingSuspendedUntil != nil && *user.BookingSuspendedUntil > time.Now().UTC().Format("2006-01-02T15:04:05Z") {
+		return &exceptions.ErrBookingSuspended
+	}
+	return nil
+}
+
+// lockPosts locks the posts in the order of their ids, so that concurrent
+// swaps of the same posts can't deadlock. Every post of a swap has to be
+// locked in a single call. Missing posts are returned as nil.
+func lockPosts(ctx context.Context, tx *repositories.Tx, ids []int64) (map[int64]*dto.PostDto, error) {
+	sorted := append([]int64(nil), ids...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	posts := make(map[int64]*dto.PostDto, len(sorted))
+	for _, id := range sorted {
+		if _, ok := posts[id]; ok {
+			continue
+		}
+		post, err := tx.Posts.GetForUpdate(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		posts[id] = post
+	}
+	return posts, nil
+}
+
+// swappable tells whether the post can be swapped by its owner right now.
+func swappable(ctx context.Context, tx *repositories.Tx, post *dto.PostDto, ownerEmail string) (bool, error) {
+	if post == nil || post.UserEmail != ownerEmail || post.Status != "available" || post.ListingType != dto.ListingTypeGiveaway {
+		return false, nil
+	}
+
+	booking, err := tx.Bookings.GetByPostID(ctx, post.ID)
+	if err != nil {
+		return false, err
+	}
+	return booking == nil, nil
+}
+
+// collectPosts checks that the offered posts belong to the proposer, the
+// requested posts belong to the recipient and all of them can be swapped. The
+// posts must be locked with lockPosts.
+func collectPosts(ctx context.Context, tx *repositories.Tx, posts map[int64]*dto.PostDto, proposerEmail string, offeredIDs []int64, recipientEmail string, requestedIDs []int64) ([]dto.SwapPostDto, error) {
+	if len(posts) != len(offeredIDs)+len(requestedIDs) {
+		// the same post is listed twice
+		return nil, &exceptions.ErrInvalidSwapPosts
+	}
+
+	var items []dto.SwapPostDto
+	add := func(ids []int64, ownerEmail string, side string) error {
+		for _, id := range ids {
+			ok, err := swappable(ctx, tx, posts[id], ownerEmail)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				return &exceptions.ErrInvalidSwapPosts
+			}
+			post := posts[id]
+			items = append(items, dto.SwapPostDto{PostID: &post.ID, PostTitle: post.Title, Side: side})
+		}
+		return nil
+	}
+	if err := add(offeredIDs, proposerEmail, dto.SwapSideOffered); err != nil {
+		return nil, err
+	}
+	if err := add(requestedIDs, recipientEmail, dto.SwapSideRequested); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+func createOffer(ctx context.Context, tx *repositories.Tx, proposerEmail string, recipientEmail string, parentID *int64, posts []dto.SwapPostDto) (*int64, error) {
+	now := time.Now().UTC().Format("2006-01-02T15:04:05Z")
+	return tx.Swaps.Create(ctx, &dto.SwapOfferToCreateDto{
+		ProposerEmail:  proposerEmail,
+		RecipientEmail: recipientEmail,
+		ParentID:       parentID,
+		Status:         dto.SwapStatusPending,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+		Posts:          posts,
+	})
+}
+
+// ProposeSwap offers the user's posts in exchange for the post of another
+// user.
+func (ss *SwapService) ProposeSwap(ctx context.Context, userEmail string, postID int64, offeredIDs []int64) (*dto.SwapOfferDto, *exceptions.Error_) {
+	if exc := ss.getVerifiedUser(ctx, userEmail); exc != nil {
+		return nil, exc
+	}
+
+	var id *int64
+	var recipientEmail string
+	exc := ss.inTx(ctx, func(tx *repositories.Tx) error {
+		locked, err := lockPosts(ctx, tx, append(append([]int64(nil), offeredIDs...), postID))
+		if err != nil {
+			return err
+		}
+		post := locked[postID]
+		if post == nil {
+			return &exceptions.PostNotFoudErr
+		}
+		if post.UserEmail == userEmail {
+			return &exceptions.ErrUserIsOwner
+		}
+		recipientEmail = post.UserEmail
+
+		posts, err := collectPosts(ctx, tx, locked, userEmail, offeredIDs, recipientEmail, []int64{postID})
+		if err != nil {
+			return err
+		}
+
+		id, err = createOffer(ctx, tx, userEmail, recipientEmail, nil, posts)
+		return err
+	})
+	if exc != nil {
+		return nil, exc
+	}
+
+	offer, exc := ss.getOffer(ctx, *id)
+	if exc != nil {
+		return nil, exc
+	}
+	ss.notify(ctx, recipientEmail, &dto.NotificationDto{
+		Title:   "Предложение обмена",
+		Content: offer.ProposerUsername + " предлагает обмен на вашу книгу \"" + offer.RequestedPosts[0].PostTitle + "\".",
+	})
+	return offer, nil
+}
+
+func (ss *SwapService) getOffer(ctx context.Context, id int64) (*dto.SwapOfferDto, *exceptions.Error_) {
+	offer, err := ss.sr.Get(ctx, id)
+	if err != nil {
+		return nil, &exceptions.ErrDatabaseError
+	}
+	if offer == nil {
+		return nil, &exceptions.ErrSwapOfferNotFound
+	}
+	return offer, nil
+}
+
+// GetSwapOffer returns the offer to its proposer or recipient.
+func (ss *SwapService) GetSwapOffer(ctx context.Context, userEmail string, id int64) (*dto.SwapOfferDto, *exceptions.Error_) {
+	offer, exc := ss.getOffer(ctx, id)
+	if exc != nil {
+		return nil, exc
+	}
+	if offer.ProposerEmail != userEmail && offer.RecipientEmail != userEmail {
+		return nil, &exceptions.ErrUserIsNotSwapParticipant
+	}
+	return offer, nil
+}
+
+// getPendingOffer returns the pending offer, locked when forUpdate is set. The
+// user must be its recipient, or its proposer when byProposer is set.
+//
+// Offers are locked after the posts: accepting an offer cancels the other
+// pending offers with its posts while holding the post locks, so locking an
+// offer first and then its posts could deadlock with it.
+func getPendingOffer(ctx context.Context, tx *repositories.Tx, userEmail string, id int64, byProposer bool, forUpdate bool) (*dto.SwapOfferDto, error) {
+	get := tx.Swaps.Get
+	if forUpdate {
+		get = tx.Swaps.GetForUpdate
+	}
+	offer, err := get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if offer == nil {
+		return nil, &exceptions.ErrSwapOfferNotFound
+	}
+
+	participant := offer.RecipientEmail
+	if byProposer {
+		participant = offer.ProposerEmail
+	}
+	if participant != userEmail {
+		return nil, &exceptions.ErrUserIsNotSwapParticipant
+	}
+
+	if offer.Status != dto.SwapStatusPending {
+		return nil, &exceptions.ErrSwapOfferNotPending
+	}
+	return offer, nil
+}
+
+// AcceptSwap accepts the offer made to the user. Every post of the swap gets a
+// confirmed booking for the other side at once, so both books are handed over
+// with the usual handover codes. Other pending offers with these posts are
+// cancelled.
+func (ss *SwapService) AcceptSwap(ctx context.Context, userEmail string, id int64) (*dto.SwapOfferDto, *exceptions.Error_) {
+	if exc := ss.getVerifiedUser(ctx, userEmail); exc != nil {
+		return nil, exc
+	}
+
+	var offer *dto.SwapOfferDto
+	var cancelled []dto.SwapOfferDto
+	exc := ss.inTx(ctx, func(tx *repositories.Tx) error {
+		// the posts of an offer don't change, so they can be locked before
+		// the offer
+		var err error
+		offer, err = getPendingOffer(ctx, tx, userEmail, id, false, false)
+		if err != nil {
+			return err
+		}
+
+		var ids []int64
+		for _, post := range append(append([]dto.SwapPostDto(nil), offer.OfferedPosts...), offer.RequestedPosts...) {
+			if post.PostID == nil {
+				// the post was deleted
+				return &exceptions.ErrSwapPostUnavailable
+			}
+			ids = append(ids, *post.PostID)
+		}
+		posts, err := lockPosts(ctx, tx, ids)
+		if err != nil {
+			return err
+		}
+
+		// a concurrent swap of one of the posts makes it unavailable and
+		// cancels this offer
+		check := func(items []dto.SwapPostDto, ownerEmail string) error {
+			for _, item := range items {
+				ok, err := swappable(ctx, tx, posts[*item.PostID], ownerEmail)
+				if err != nil {
+					return err
+				}
+				if !ok {
+					return &exceptions.ErrSwapPostUnavailable
+				}
+			}
+			return nil
+		}
+		if err := check(offer.OfferedPosts, offer.ProposerEmail); err != nil {
+			return err
+		}
+		if err := check(offer.RequestedPosts, offer.RecipientEmail); err != nil {
+			return err
+		}
+
+		offer, err = getPendingOffer(ctx, tx, userEmail, id, false, true)
+		if err != nil {
+			return err
+		}
+
+		// both sides get bookings, so both must be allowed to book
+		if err := booking_service.CheckBookingAllowed(ctx, tx, offer.RecipientEmail, len(offer.OfferedPosts)); err != nil {
+			return err
+		}
+		if err := booking_service.CheckBookingAllowed(ctx, tx, offer.ProposerEmail, len(offer.RequestedPosts)); err != nil {
+			return err
+		}
+
+		now := time.Now().UTC()
+		expiresAt := now.Add(config.Config.BookingPickupDeadline).Format("2006-01-02T15:04:05Z")
+		book := func(items []dto.SwapPostDto, bookerEmail string) error {
+			for _, item := range items {
+				post := posts[*item.PostID]
+				_, err := tx.Bookings.Create(ctx, &dto.BookingToCreateDto{
+					UserEmail: bookerEmail,
+					PostID:    post.ID,
+					CreatedAt: now.Format("2006-01-02T15:04:05Z"),
+					Status:    dto.BookingStatusConfirmed,
+					ExpiresAt: expiresAt,
+					UpdatedAt: now.Format("2006-01-02T15:04:05Z"),
+					SwapID:    &offer.ID,
+				})
+				if err != nil {
+					return err
+				}
+				if err := tx.Posts.Update(ctx, post.ID, &dto.UpdatePostDto{Status: "booked"}); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+		if err := book(offer.OfferedPosts, offer.RecipientEmail); err != nil {
+			return err
+		}
+		if err := book(offer.RequestedPosts, offer.ProposerEmail); err != nil {
+			return err
+		}
+
+		offer.Status = dto.SwapStatusAccepted
+		offer.UpdatedAt = now.Format("2006-01-02T15:04:05Z")
+		if err := tx.Swaps.UpdateStatus(ctx, offer.ID, offer.Status, offer.UpdatedAt); err != nil {
+			return err
+		}
+
+		cancelled, err = tx.Swaps.CancelPendingWithPosts(ctx, ids, offer.ID, offer.UpdatedAt)
+		return err
+	})
+	if exc != nil {
+		return nil, exc
+	}
+
+	ss.notify(ctx, offer.ProposerEmail, &dto.NotificationDto{
+		Title:   "Обмен принят",
+		Content: offer.RecipientUsername + " принял(а) ваше предложение обмена. Книги забронированы за вами обоими.",
+	})
+	for _, other := range cancelled {
+		notification := &dto.NotificationDto{
+			Title:   "Обмен отменён",
+			Content: "Одна из книг вашего предложения обмена уже обменяна.",
+		}
+		ss.notify(ctx, other.ProposerEmail, notification)
+		ss.notify(ctx, other.RecipientEmail, notification)
+	}
+	return offer, nil
+}
+
+// RejectSwap rejects the offer made to the user.
+func (ss *SwapService) RejectSwap(ctx context.Context, userEmail string, id int64) (*dto.SwapOfferDto, *exceptions.Error_) {
+	offer, exc := ss.finishOffer(ctx, userEmail, id, false, dto.SwapStatusRejected)
+	if exc != nil {
+		return nil, exc
+	}
+
+	ss.notify(ctx, offer.ProposerEmail, &dto.NotificationDto{
+		Title:   "Обмен отклонён",
+		Content: offer.RecipientUsername + " отклонил(а) ваше предложение обмена.",
+	})
+	return offer, nil
+}
+
+// WithdrawSwap withdraws the offer made by the user.
+func (ss *SwapService) WithdrawSwap(ctx context.Context, userEmail string, id int64) (*dto.SwapOfferDto, *exceptions.Error_) {
+	offer, exc := ss.finishOffer(ctx, userEmail, id, true, dto.SwapStatusWithdrawn)
+	if exc != nil {
+		return nil, exc
+	}
+
+	ss.notify(ctx, offer.RecipientEmail, &dto.NotificationDto{
+		Title:   "Обмен отозван",
+		Content: offer.ProposerUsername + " отозвал(а) предложение обмена.",
+	})
+	return offer, nil
+}
+
+func (ss *SwapService) finishOffer(ctx context.Context, userEmail string, id int64, byProposer bool, status string) (*dto.SwapOfferDto, *exceptions.Error_) {
+	var offer *dto.SwapOfferDto
+	exc := ss.inTx(ctx, func(tx *repositories.Tx) error {
+		var err error
+		offer, err = getPendingOffer(ctx, tx, userEmail, id, byProposer, true)
+		if err != nil {
+			return err
+		}
+
+		offer.Status = status
+		offer.UpdatedAt = time.Now().UTC().Format("2006-01-02T15:04:05Z")
+		return tx.Swaps.UpdateStatus(ctx, offer.ID, offer.Status, offer.UpdatedAt)
+	})
+	if exc != nil {
+		return nil, exc
+	}
+	return offer, nil
+}
+
+// CounterSwap answers the offer made to the user with other posts. The offer
+// becomes countered and a new offer goes back to its proposer.
+func (ss *SwapService) CounterSwap(ctx context.Context, userEmail string, id int64, counter *dto.CounterSwapOfferDto) (*dto.SwapOfferDto, *exceptions.Error_) {
+	if exc := ss.getVerifiedUser(ctx, userEmail); exc != nil {
+		return nil, exc
+	}
+
+	var counterID *int64
+	exc := ss.inTx(ctx, func(tx *repositories.Tx) error {
+		// the proposer of an offer doesn't change, so the posts can be
+		// locked before the offer
+		offer, err := getPendingOffer(ctx, tx, userEmail, id, false, false)
+		if err != nil {
+			return err
+		}
+
+		locked, err := lockPosts(ctx, tx, append(append([]int64(nil), counter.OfferedPostIDs...), counter.RequestedPostIDs...))
+		if err != nil {
+			return err
+		}
+		posts, err := collectPosts(ctx, tx, locked, userEmail, counter.OfferedPostIDs, offer.ProposerEmail, counter.RequestedPostIDs)
+		if err != nil {
+			return err
+		}
+
+		if offer, err = getPendingOffer(ctx, tx, userEmail, id, false, true); err != nil {
+			return err
+		}
+
+		now := time.Now().UTC().Format("2006-01-02T15:04:05Z")
+		if err := tx.Swaps.UpdateStatus(ctx, offer.ID, dto.SwapStatusCountered, now); err != nil {
+			return err
+		}
+
+		counterID, err = createOffer(ctx, tx, userEmail, offer.ProposerEmail, &offer.ID, posts)
+		return err
+	})
+	if exc != nil {
+		return nil, exc
+	}
+
+	offer, exc := ss.getOffer(ctx, *counterID)
+	if exc != nil {
+		return nil, exc
+	}
+	ss.notify(ctx, offer.RecipientEmail, &dto.NotificationDto{
+		Title:   "Встречное предложение",
+		Content: offer.ProposerUsername + " предлагает другие условия обмена.",
+	})
+	return offer, nil
+}
+
+// GetSentOffers returns swap offers made by the user, newest first.
+func (ss *SwapService) GetSentOffers(ctx context.Context, email string, page dto.PageRequest) (*dto.Page[dto.SwapOfferDto], *exceptions.Error_) {
+	if _, exc := ss.us.GetUserByEmail(ctx, email); exc != nil {
+		return nil, exc
+	}
+
+	offers, err := ss.sr.GetSent(ctx, email, page)
+	if err != nil {
+		return nil, &exceptions.ErrDatabaseError
+	}
+	return offers, nil
+}
+
+// GetReceivedOffers returns swap offers made to the user, newest first.
+func (ss *SwapService) GetReceivedOffers(ctx context.Context, email string, page dto.PageRequest) (*dto.Page[dto.SwapOfferDto], *exceptions.Error_) {
+	if _, exc := ss.us.GetUserByEmail(ctx, email); exc != nil {
+		return nil, exc
+	}
+
+	offers, err := ss.sr.GetReceived(ctx, email, page)
+	if err != nil {
+		return nil, &exceptions.ErrDatabaseError
+	}
+	return offers, nil
+}
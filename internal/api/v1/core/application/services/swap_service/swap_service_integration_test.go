@@ -0,0 +1,120 @@
+//go:build integration
+
+package swap_service
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"example.com/m/internal/api/v1/adapters/repositories"
+	"example.com/m/internal/api/v1/core/application/exceptions"
+	"example.com/m/internal/api/v1/core/application/services/user_service"
+	"example.com/m/internal/api/v1/infrastructure/mail"
+	"example.com/m/internal/api/v1/testdb"
+	"example.com/m/internal/config"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+func newTestSwapService(db *sql.DB) *SwapService {
+	logger := zap.NewNop()
+	// push tokens are never found, so no notifications are sent
+	rdb := redis.NewClient(&redis.Options{Addr: "127.0.0.1:1", MaxRetries: -1})
+	pushTokenRepository := repositories.NewPushTokenRepository(rdb, logger)
+	userService := user_service.NewUserService(
+		repositories.NewUserRepository(db, logger), pushTokenRepository,
+		repositories.NewCodeRepository(rdb, logger), repositories.NewMailRepository(mail.NewLogMailer("", logger), logger),
+		repositories.NewAvatarRepository(logger, nil),
+	)
+
+	return NewSwapService(
+		repositories.NewSwapRepository(db, logger),
+		userService,
+		repositories.NewUnitOfWork(db, logger),
+		nil,
+		pushTokenRepository,
+	)
+}
+
+// Offers for the same post are accepted at once. One swap wins, the others
+// fail cleanly instead of deadlocking on the offers cancelled by the winner.
+func TestAcceptSwapConcurrently(t *testing.T) {
+	db := testdb.Open(t)
+	ss := newTestSwapService(db)
+	config.Config.BookingPickupDeadline = time.Hour
+	config.Config.MaxActiveBookings = 10
+
+	const proposers = 5
+	suffix := time.Now().UnixNano()
+
+	recipient := fmt.Sprintf("recipient%d@test.com", suffix)
+	emails := []string{recipient}
+	for i := 0; i < proposers; i++ {
+		emails = append(emails, fmt.Sprintf("proposer%d_%d@test.com", i, suffix))
+	}
+	testdb.CreateUsers(t, db, emails...)
+	postID := testdb.CreatePost(t, db, recipient)
+
+	var offerIDs []int64
+	for _, email := range emails[1:] {
+		offered := testdb.CreatePost(t, db, email)
+		offer, exc := ss.ProposeSwap(context.Background(), email, postID, []int64{offered})
+		if exc != nil {
+			t.Fatalf("got error %v", exc.Message)
+		}
+		offerIDs = append(offerIDs, offer.ID)
+	}
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	start := make(chan struct{})
+	var accepted int
+	for _, id := range offerIDs {
+		wg.Add(1)
+		go func(id int64) {
+			defer wg.Done()
+			<-start
+			_, exc := ss.AcceptSwap(context.Background(), recipient, id)
+
+			mu.Lock()
+			defer mu.Unlock()
+			switch exc {
+			case nil:
+				accepted++
+			case &exceptions.ErrSwapPostUnavailable, &exceptions.ErrSwapOfferNotPending:
+			default:
+				t.Errorf("got error %v", exc.Message)
+			}
+		}(id)
+	}
+	close(start)
+	wg.Wait()
+
+	if accepted != 1 {
+		t.Fatalf("got %d accepted offers want 1", accepted)
+	}
+
+	var active int
+	err := db.QueryRow(
+		"SELECT COUNT(*) FROM bookings WHERE post_id = $1 AND status IN ('pending', 'confirmed')",
+		postID,
+	).Scan(&active)
+	if err != nil {
+		t.Fatalf("got error %v", err)
+	}
+	if active != 1 {
+		t.Errorf("got %d active bookings want 1", active)
+	}
+
+	var pending int
+	if err := db.QueryRow("SELECT COUNT(*) FROM swap_offers WHERE recipient_email = $1 AND status = 'pending'", recipient).Scan(&pending); err != nil {
+		t.Fatalf("got error %v", err)
+	}
+	if pending != 0 {
+		t.Errorf("got %d pending offers want 0", pending)
+	}
+}
@@ -0,0 +1,149 @@
+package throttle_service
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"example.com/m/internal/api/v1/adapters/repositories"
+	"example.com/m/internal/api/v1/core/application/exceptions"
+	"example.com/m/internal/config"
+)
+
+const (
+	ScopeAccount = "account"
+	ScopeIP      = "ip"
+)
+
+// Policy describes how attempts of an action are limited. A zero limit turns
+// off the limit of that scope.
+type Policy struct {
+	Action       string
+	AccountLimit int64
+	IPLimit      int64
+	// the account is taken from the JWT instead of the email in the body, the
+	// route must be authenticated
+	AccountFromToken bool
+	// whether the response with the status is a failed attempt
+	IsFailure func(status int) bool
+}
+
+func LoginPolicy() Policy {
+	return Policy{
+		Action:       "login",
+		AccountLimit: int64(config.Config.ThrottleAccountAttempts),
+		IPLimit:      int64(config.Config.ThrottleIPAttempts),
+		IsFailure:    func(status int) bool { return status == 401 },
+	}
+}
+
+// RegistrationPolicy counts every registration, successful or not.
+func RegistrationPolicy() Policy {
+	return Policy{
+		Action:    "registration",
+		IPLimit:   int64(config.Config.ThrottleRegistrations),
+		IsFailure: func(status int) bool { return status != 429 },
+	}
+}
+
+func PasswordChangePolicy() Policy {
+	return Policy{
+		Action:           "password_change",
+		AccountLimit:     int64(config.Config.ThrottleAccountAttempts),
+		IPLimit:          int64(config.Config.ThrottleIPAttempts),
+		AccountFromToken: true,
+		IsFailure:        func(status int) bool { return status == 401 },
+	}
+}
+
+// Subject is who makes the attempt, Email is empty when it isn't known.
+type Subject struct {
+	Email string
+	IP    string
+}
+
+// Lockout is returned by RecordFailure when the attempt locked a scope.
+type Lockout struct {
+	Scope    string
+	Duration time.Duration
+}
+
+type ThrottleService struct {
+	tr *repositories.ThrottleRepository
+}
+
+func NewThrottleService(tr *repositories.ThrottleRepository) *ThrottleService {
+	return &ThrottleService{tr: tr}
+}
+
+func throttleKey(p Policy, scope string, value string) string {
+	return p.Action + ":" + scope + ":" + strings.ToLower(value)
+}
+
+func (p Policy) keys(s Subject) map[string]string {
+	keys := map[string]string{}
+	if p.AccountLimit > 0 && s.Email != "" {
+		keys[ScopeAccount] = throttleKey(p, ScopeAccount, s.Email)
+	}
+	if p.IPLimit > 0 && s.IP != "" {
+		keys[ScopeIP] = throttleKey(p, ScopeIP, s.IP)
+	}
+	return keys
+}
+
+func (p Policy) limit(scope string) int64 {
+	if scope == ScopeAccount {
+		return p.AccountLimit
+	}
+	return p.IPLimit
+}
+
+// Check returns how long the subject has to wait before the next attempt,
+// zero if the attempt is allowed.
+func (s *ThrottleService) Check(ctx context.Context, p Policy, subject Subject) (time.Duration, *exceptions.Error_) {
+	var wait time.Duration
+	for _, key := range p.keys(subject) {
+		lockedFor, err := s.tr.LockedFor(&ctx, key)
+		if err != nil {
+			return 0, &exceptions.ErrServiceUnavailable
+		}
+		wait = max(wait, lockedFor)
+	}
+	return wait, nil
+}
+
+// RecordFailure counts a failed attempt and locks the scopes which exceeded
+// their limit within the window.
+func (s *ThrottleService) RecordFailure(ctx context.Context, p Policy, subject Subject) ([]Lockout, *exceptions.Error_) {
+	var lockouts []Lockout
+	for scope, key := range p.keys(subject) {
+		attempts, err := s.tr.AddAttempt(&ctx, key, config.Config.ThrottleWindow)
+		if err != nil {
+			return nil, &exceptions.ErrServiceUnavailable
+		}
+		if attempts < p.limit(scope) {
+			continue
+		}
+
+		duration, err := s.tr.Lock(&ctx, key, config.Config.ThrottleLockout, config.Config.ThrottleMaxLockout)
+		if err != nil {
+			return nil, &exceptions.ErrServiceUnavailable
+		}
+		lockouts = append(lockouts, Lockout{Scope: scope, Duration: duration})
+	}
+	return lockouts, nil
+}
+
+// RecordSuccess forgets failed attempts of the account. Attempts from the IP
+// are kept, otherwise one valid account would let an attacker keep guessing
+// passwords of others.
+func (s *ThrottleService) RecordSuccess(ctx context.Context, p Policy, subject Subject) *exceptions.Error_ {
+	key, ok := p.keys(subject)[ScopeAccount]
+	if !ok {
+		return nil
+	}
+	if err := s.tr.Reset(&ctx, key); err != nil {
+		return &exceptions.ErrServiceUnavailable
+	}
+	return nil
+}
@@ -2,22 +2,54 @@ package user_service
 
 import (
 	"context"
+	"crypto/subtle"
+	"errors"
 	"fmt"
+	"mime/multipart"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
 	"time"
 
 	"example.com/m/internal/api/v1/adapters/repositories"
 	"example.com/m/internal/api/v1/core/application/dto"
 	"example.com/m/internal/api/v1/core/application/exceptions"
 	"example.com/m/internal/api/v1/utils"
+	"example.com/m/internal/config"
 )
 
+const (
+	codeLength = 6
+	// how many times a code may be checked before it is dropped
+	maxCodeAttempts = 5
+	// how often a code may be sent to the same email
+	codeResendInterval = time.Minute
+
+	usernameMinLength = 6
+	usernameMaxLength = 32
+
+	// avatars are stored as avatarSize x avatarSize JPEG images
+	avatarSize        = 512
+	maxAvatarFileSize = 10 << 20
+)
+
+var usernameCharacters = regexp.MustCompile(`[^a-zA-Z0-9_.]`)
+
 type UserService struct {
 	ur  repositories.UserRepository
 	ptr *repositories.PushTokenRepository
+	cr  *repositories.CodeRepository
+	mr  *repositories.MailRepository
+	ar  *repositories.AvatarRepository
 }
 
-func NewUserService(ur *repositories.UserRepository, ptr *repositories.PushTokenRepository) *UserService {
-	return &UserService{ur: *ur, ptr: ptr}
+func NewUserService(
+	ur *repositories.UserRepository, ptr *repositories.PushTokenRepository,
+	cr *repositories.CodeRepository, mr *repositories.MailRepository,
+	ar *repositories.AvatarRepository,
+) *UserService {
+	return &UserService{ur: *ur, ptr: ptr, cr: cr, mr: mr, ar: ar}
 }
 
 func (s *UserService) BindPushToken(ctx context.Context, email string, token string) *exceptions.Error_ {
@@ -59,17 +91,59 @@ func (s *UserService) GetUserByUsernameWithRating(ctx context.Context, username
 		return nil, &exceptions.ErrDatabaseError
 	}
 
+	places, err := s.ur.GetPreferredPlaces(ctx, user.Email)
+	if err != nil {
+		return nil, &exceptions.ErrDatabaseError
+	}
+
+	reliability, err := s.ur.GetReliability(ctx, user.Email)
+	if err != nil {
+		return nil, &exceptions.ErrDatabaseError
+	}
+
 	return &dto.UserWithRatingDto{
-		Username:         username,
-		Email:            user.Email,
-		CreatedAt:        user.CreatedAt,
-		UpdatedAt:        user.UpdatedAt,
-		TelegramUsername: user.TelegramUsername,
-		IsAdmin:          user.IsAdmin,
-		Rating:           rating,
+		Username:           username,
+		Email:              user.Email,
+		CreatedAt:          user.CreatedAt,
+		UpdatedAt:          user.UpdatedAt,
+		TelegramUsername:   user.TelegramUsername,
+		IsAdmin:            user.IsAdmin,
+		IsVerified:         user.IsVerified,
+		AvatarURL:          user.AvatarURL,
+		Bio:                user.Bio,
+		Faculty:            user.Faculty,
+		Rating:             rating,
+		EmailVisibility:    user.EmailVisibility,
+		TelegramVisibility: user.TelegramVisibility,
+		PreferredPlaces:    places,
+		Reliability:        *reliability,
 	}, nil
 }
 
+// GetUserProfileForViewer returns the profile as seen by another user, with
+// the contacts hidden according to the visibility settings.
+func (s *UserService) GetUserProfileForViewer(ctx context.Context, viewerEmail string, username string) (*dto.UserWithRatingDto, *exceptions.Error_) {
+	user, exception := s.GetUserByUsernameWithRating(ctx, username)
+	if exception != nil {
+		return nil, exception
+	}
+	if user.Email == viewerEmail {
+		return user, nil
+	}
+
+	isCounterpart := false
+	if user.EmailVisibility == dto.VisibilityCounterparts || user.TelegramVisibility == dto.VisibilityCounterparts {
+		var err error
+		isCounterpart, err = s.ur.HasActiveBooking(ctx, user.Email, viewerEmail)
+		if err != nil {
+			return nil, &exceptions.ErrDatabaseError
+		}
+	}
+
+	utils.HideUserContacts(user, isCounterpart)
+	return user, nil
+}
+
 func (s *UserService) CreateUser(ctx context.Context, u dto.CreateUserDto) (*dto.UserDto, *exceptions.Error_) {
 	userExists, exception := s.IsUserExist(ctx, u.Email, u.Username)
 	if exception != nil {
@@ -88,6 +162,7 @@ func (s *UserService) CreateUser(ctx context.Context, u dto.CreateUserDto) (*dto
 		UpdatedAt:        time.Now().UTC().Format("2006-01-02T15:04:05Z"),
 		TelegramUsername: u.TelegramUsername,
 		IsAdmin:          false,
+		IsVerified:       false,
 	}
 
 	err := s.ur.Create(ctx, &userToCreate)
@@ -95,9 +170,84 @@ func (s *UserService) CreateUser(ctx context.Context, u dto.CreateUserDto) (*dto
 		return nil, &exceptions.ErrDatabaseError
 	}
 
+	// the account is created anyway, the code can be requested again
+	_ = s.SendVerification(ctx, userToCreate.Email)
+
+	return &userToCreate, nil
+}
+
+// ProvisionUser creates an account for a user who logged in through an
+// identity provider. The account has no password until the user resets it.
+func (s *UserService) ProvisionUser(ctx context.Context, email string, usernameHint string, verified bool) (*dto.UserDto, *exceptions.Error_) {
+	username, exception := s.freeUsername(ctx, usernameHint, email)
+	if exception != nil {
+		return nil, exception
+	}
+
+	userToCreate := dto.UserDto{
+		Email:      email,
+		Username:   username,
+		CreatedAt:  time.Now().UTC().Format("2006-01-02T15:04:05Z"),
+		UpdatedAt:  time.Now().UTC().Format("2006-01-02T15:04:05Z"),
+		IsAdmin:    false,
+		IsVerified: verified,
+	}
+	if err := s.ur.Create(ctx, &userToCreate); err != nil {
+		return nil, &exceptions.ErrDatabaseError
+	}
+
+	if !verified {
+		_ = s.SendVerification(ctx, email)
+	}
 	return &userToCreate, nil
 }
 
+// freeUsername makes a valid username from the hint, or from the email when
+// the hint is empty, adding random digits until it isn't taken.
+func (s *UserService) freeUsername(ctx context.Context, hint string, email string) (string, *exceptions.Error_) {
+	if hint == "" {
+		hint, _, _ = strings.Cut(email, "@")
+	}
+	base := usernameCharacters.ReplaceAllString(hint, "")
+	if len(base) > usernameMaxLength-5 {
+		base = base[:usernameMaxLength-5]
+	}
+
+	username := base
+	for attempt := 0; attempt < 10; attempt++ {
+		if len(username) >= usernameMinLength {
+			found, err := s.ur.GetByUsername(ctx, &username)
+			if err != nil {
+				return "", &exceptions.ErrDatabaseError
+			}
+			if found == nil {
+				return username, nil
+			}
+		}
+
+		suffix, err := utils.GenerateCode(4)
+		if err != nil {
+			return "", &exceptions.InternalServerError
+		}
+		username = base + "_" + suffix
+		for len(username) < usernameMinLength {
+			username += "0"
+		}
+	}
+	return "", &exceptions.ErrUserAlreadyExists
+}
+
+// ClaimUnverified is used when the email of an unverified account was
+// confirmed by other means, e.g. by an identity provider. Whoever registered
+// the account may not own the email, so its password is removed; the owner can
+// set a new one with a password reset.
+func (s *UserService) ClaimUnverified(ctx context.Context, email string) *exceptions.Error_ {
+	if err := s.ur.ClaimUnverified(ctx, email); err != nil {
+		return &exceptions.ErrDatabaseError
+	}
+	return nil
+}
+
 func (s *UserService) GetUserByEmail(ctx context.Context, email string) (*dto.UserDto, *exceptions.Error_) {
 	user, err := s.ur.GetByEmail(ctx, &email)
 	if err != nil {
@@ -140,6 +290,18 @@ func (s *UserService) UpdateUserByEmail(ctx context.Context, email string, u dto
 
 	utils.UpdateUserTimestamps(&u)
 
+	if u.PreferredPlaceIDs != nil {
+		ok, err := s.ur.SetPreferredPlaces(ctx, email, *u.PreferredPlaceIDs)
+		if err != nil {
+			return nil, &exceptions.ErrDatabaseError
+		}
+		if !ok {
+			return nil, &exceptions.ErrPlaceNotFound
+		}
+		// places are stored separately from the other fields
+		u.PreferredPlaceIDs = nil
+	}
+
 	if err := s.ur.UpdateByEmail(ctx, &email, &u); err != nil {
 		return nil, &exceptions.ErrDatabaseError
 	}
@@ -151,3 +313,229 @@ func (s *UserService) UpdateUserByEmail(ctx context.Context, email string, u dto
 
 	return updatedUser, nil
 }
+
+// SetAvatar resizes the image and makes it the avatar of the user. The
+// previous avatar is removed from the storage.
+func (s *UserService) SetAvatar(
+	ctx context.Context, email string, header *multipart.FileHeader, image multipart.File,
+) (*dto.UserDto, *exceptions.Error_) {
+	user, exception := s.GetUserByEmail(ctx, email)
+	if exception != nil {
+		return nil, exception
+	}
+
+	if header.Size > maxAvatarFileSize {
+		return nil, &exceptions.ErrAvatarTooLarge
+	}
+	resized, err := utils.ResizeImage(image, avatarSize)
+	if errors.Is(err, utils.ErrImageTooLarge) {
+		return nil, &exceptions.ErrAvatarDimensionsTooLarge
+	}
+	if err != nil {
+		return nil, &exceptions.ErrUnsupportedImageType
+	}
+
+	avatarURL, err := s.ar.Upload(ctx, resized.Bytes())
+	if err != nil {
+		return nil, &exceptions.ErrServiceUnavailable
+	}
+	if err := s.ur.SetAvatarURL(ctx, email, avatarURL); err != nil {
+		_ = s.ar.Delete(ctx, avatarURL)
+		return nil, &exceptions.ErrDatabaseError
+	}
+
+	if user.AvatarURL != "" {
+		// the new avatar is already set, a failure is only logged
+		_ = s.ar.Delete(ctx, user.AvatarURL)
+	}
+	user.AvatarURL = avatarURL
+	return user, nil
+}
+
+func (s *UserService) DeleteAvatar(ctx context.Context, email string) *exceptions.Error_ {
+	user, exception := s.GetUserByEmail(ctx, email)
+	if exception != nil {
+		return exception
+	}
+	if user.AvatarURL == "" {
+		return nil
+	}
+
+	if err := s.ur.SetAvatarURL(ctx, email, ""); err != nil {
+		return &exceptions.ErrDatabaseError
+	}
+	_ = s.ar.Delete(ctx, user.AvatarURL)
+	return nil
+}
+
+// SendVerification emails a one-time code and a signed link confirming the
+// email of the user.
+func (s *UserService) SendVerification(ctx context.Context, email string) *exceptions.Error_ {
+	user, exc := s.GetUserByEmail(ctx, email)
+	if exc != nil {
+		return exc
+	}
+	if user.IsVerified {
+		return &exceptions.ErrUserAlreadyVerified
+	}
+
+	allowed, err := s.cr.Throttle(&ctx, repositories.CodePurposeVerification, email, codeResendInterval)
+	if err != nil {
+		return &exceptions.ErrServiceUnavailable
+	}
+	if !allowed {
+		return &exceptions.ErrTooManyCodeRequests
+	}
+
+	code, err := utils.GenerateCode(codeLength)
+	if err != nil {
+		return &exceptions.InternalServerError
+	}
+	ttl := config.Config.EmailVerificationTTL
+	if err := s.cr.Set(&ctx, repositories.CodePurposeVerification, email, utils.HashCode(code), ttl); err != nil {
+		return &exceptions.ErrServiceUnavailable
+	}
+
+	expires := strconv.FormatInt(time.Now().Add(ttl).Unix(), 10)
+	link := fmt.Sprintf("%s/api/v1/users/verify?%s", config.Config.AppBaseURL, url.Values{
+		"email":     {email},
+		"expires":   {expires},
+		"signature": {utils.SignValues(repositories.CodePurposeVerification, email, expires)},
+	}.Encode())
+
+	body := fmt.Sprintf(
+		"Здравствуйте, %s!\n\nКод подтверждения почты: %s\n\nИли перейдите по ссылке:\n%s\n\nЕсли вы не регистрировались, просто проигнорируйте это письмо.",
+		user.Username, code, link,
+	)
+	if err := s.mr.Send(ctx, email, "Подтверждение почты", body); err != nil {
+		return &exceptions.ErrServiceUnavailable
+	}
+	return nil
+}
+
+// VerifyEmailByCode checks the code sent by SendVerification.
+func (s *UserService) VerifyEmailByCode(ctx context.Context, email string, code string) *exceptions.Error_ {
+	user, exc := s.GetUserByEmail(ctx, email)
+	if exc != nil {
+		return exc
+	}
+	if user.IsVerified {
+		return &exceptions.ErrUserAlreadyVerified
+	}
+
+	if exc := s.ConsumeCode(ctx, repositories.CodePurposeVerification, email, code); exc != nil {
+		return exc
+	}
+
+	if err := s.ur.SetVerified(ctx, email); err != nil {
+		return &exceptions.ErrDatabaseError
+	}
+	return nil
+}
+
+// VerifyEmailByLink checks the link sent by SendVerification.
+func (s *UserService) VerifyEmailByLink(ctx context.Context, email string, expires string, signature string) *exceptions.Error_ {
+	if !utils.CheckSignature(signature, repositories.CodePurposeVerification, email, expires) {
+		return &exceptions.ErrInvalidVerificationLink
+	}
+	expiresAt, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || time.Now().Unix() > expiresAt {
+		return &exceptions.ErrInvalidVerificationLink
+	}
+
+	user, exc := s.GetUserByEmail(ctx, email)
+	if exc != nil {
+		return exc
+	}
+	if user.IsVerified {
+		return nil
+	}
+
+	if err := s.ur.SetVerified(ctx, email); err != nil {
+		return &exceptions.ErrDatabaseError
+	}
+	// the code sent in the same email is no longer needed
+	_, _ = s.cr.Delete(&ctx, repositories.CodePurposeVerification, email)
+	return nil
+}
+
+// SendPasswordReset emails a one-time code which lets the user set a new
+// password without the old one.
+func (s *UserService) SendPasswordReset(ctx context.Context, email string) *exceptions.Error_ {
+	user, exc := s.GetUserByEmail(ctx, email)
+	if exc != nil {
+		return exc
+	}
+
+	allowed, err := s.cr.Throttle(&ctx, repositories.CodePurposePasswordReset, email, codeResendInterval)
+	if err != nil {
+		return &exceptions.ErrServiceUnavailable
+	}
+	if !allowed {
+		return &exceptions.ErrTooManyCodeRequests
+	}
+
+	code, err := utils.GenerateCode(codeLength)
+	if err != nil {
+		return &exceptions.InternalServerError
+	}
+	ttl := config.Config.PasswordResetTTL
+	if err := s.cr.Set(&ctx, repositories.CodePurposePasswordReset, email, utils.HashCode(code), ttl); err != nil {
+		return &exceptions.ErrServiceUnavailable
+	}
+
+	body := fmt.Sprintf(
+		"Здравствуйте, %s!\n\nКод для сброса пароля: %s\nКод действует %d мин.\n\nЕсли вы не запрашивали сброс пароля, просто проигнорируйте это письмо.",
+		user.Username, code, int(ttl.Minutes()),
+	)
+	if err := s.mr.Send(ctx, email, "Сброс пароля", body); err != nil {
+		return &exceptions.ErrServiceUnavailable
+	}
+	return nil
+}
+
+// ConsumeCode checks a one-time code of the purpose sent to the email. A code
+// can be used once and checked at most maxCodeAttempts times.
+func (s *UserService) ConsumeCode(ctx context.Context, purpose string, email string, code string) *exceptions.Error_ {
+	if exc := s.CheckCode(ctx, purpose, email, code); exc != nil {
+		return exc
+	}
+
+	deleted, err := s.cr.Delete(&ctx, purpose, email)
+	if err != nil {
+		return &exceptions.ErrServiceUnavailable
+	}
+	if !deleted {
+		return &exceptions.ErrInvalidCode
+	}
+	return nil
+}
+
+// CheckCode is ConsumeCode without using up a matching code. It is for
+// callers which have to delete the code only once the action it allows has
+// been committed.
+func (s *UserService) CheckCode(ctx context.Context, purpose string, email string, code string) *exceptions.Error_ {
+	stored, err := s.cr.Get(&ctx, purpose, email)
+	if err != nil {
+		return &exceptions.ErrServiceUnavailable
+	}
+	if stored == nil {
+		return &exceptions.ErrInvalidCode
+	}
+
+	attempts, err := s.cr.IncrAttempts(&ctx, purpose, email)
+	if err != nil {
+		return &exceptions.ErrServiceUnavailable
+	}
+	if attempts > maxCodeAttempts {
+		if _, err := s.cr.Delete(&ctx, purpose, email); err != nil {
+			return &exceptions.ErrServiceUnavailable
+		}
+		return &exceptions.ErrTooManyCodeAttempts
+	}
+
+	if subtle.ConstantTimeCompare([]byte(utils.HashCode(code)), []byte(stored.Hash)) != 1 {
+		return &exceptions.ErrInvalidCode
+	}
+	return nil
+}
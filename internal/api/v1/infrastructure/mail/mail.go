@@ -0,0 +1,106 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+	"mime"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"example.com/m/internal/config"
+	"go.uber.org/zap"
+)
+
+// Mailer sends plain text emails.
+type Mailer interface {
+	Send(ctx context.Context, to string, subject string, body string) error
+}
+
+var Client Mailer
+
+// InitMailer uses SMTP when SMTP_HOST is set, otherwise mail is only written
+// to the log or to MAIL_DIR, which is enough for local development and tests.
+func InitMailer(logger *zap.Logger) {
+	if config.Config.SMTPHost == "" {
+		Client = NewLogMailer(config.Config.MailDir, logger)
+		return
+	}
+
+	Client = NewSMTPMailer(
+		config.Config.SMTPHost,
+		config.Config.SMTPPort,
+		config.Config.SMTPUsername,
+		config.Config.SMTPPassword,
+		config.Config.MailFrom,
+	)
+}
+
+func buildMessage(from string, to string, subject string, body string) []byte {
+	var b strings.Builder
+	b.WriteString("From: " + from + "\r\n")
+	b.WriteString("To: " + to + "\r\n")
+	b.WriteString("Subject: " + mime.QEncoding.Encode("utf-8", subject) + "\r\n")
+	b.WriteString("Date: " + time.Now().UTC().Format(time.RFC1123Z) + "\r\n")
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(body, "\n", "\r\n"))
+	return []byte(b.String())
+}
+
+type SMTPMailer struct {
+	addr string
+	auth smtp.Auth
+	from string
+}
+
+func NewSMTPMailer(host string, port string, username string, password string, from string) *SMTPMailer {
+	if port == "" {
+		port = "587"
+	}
+	var auth smtp.Auth
+	if username != "" {
+		auth = smtp.PlainAuth("", username, password, host)
+	}
+	return &SMTPMailer{
+		addr: host + ":" + port,
+		auth: auth,
+		from: from,
+	}
+}
+
+func (m *SMTPMailer) Send(ctx context.Context, to string, subject string, body string) error {
+	return smtp.SendMail(m.addr, m.auth, m.from, []string{to}, buildMessage(m.from, to, subject, body))
+}
+
+// LogMailer doesn't deliver anything. Messages are saved as .eml files to dir,
+// or logged when dir is empty.
+type LogMailer struct {
+	dir    string
+	logger *zap.Logger
+}
+
+func NewLogMailer(dir string, logger *zap.Logger) *LogMailer {
+	return &LogMailer{
+		dir:    dir,
+		logger: logger,
+	}
+}
+
+func (m *LogMailer) Send(ctx context.Context, to string, subject string, body string) error {
+	if m.dir == "" {
+		m.logger.Info(
+			"Mail",
+			zap.String("to", to),
+			zap.String("subject", subject),
+			zap.String("body", body),
+		)
+		return nil
+	}
+
+	name := fmt.Sprintf("%d_%s.eml", time.Now().UnixNano(), strings.NewReplacer("@", "_at_", "/", "_").Replace(to))
+	return os.WriteFile(filepath.Join(m.dir, name), buildMessage("noreply@localhost", to, subject, body), 0o644)
+}
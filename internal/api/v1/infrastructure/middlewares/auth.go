@@ -1,6 +1,7 @@
 package middlewares
 
 import (
+	"example.com/m/internal/api/v1/core/application/dto"
 	"example.com/m/internal/api/v1/core/application/services/auth_service"
 	"example.com/m/internal/api/v1/utils"
 	"github.com/gin-gonic/gin"
@@ -40,14 +41,21 @@ func (m *AuthMiddleware) Authenticate() gin.HandlerFunc {
 		}
 
 		email := payload["email"].(string)
+		// tokens issued before sessions have no sid and are rejected
+		sessionID, _ := payload["sid"].(string)
 
-		exception := m.as.CheckTokenExistance(c, email, *token)
+		session, exception := m.as.CheckSession(c, email, sessionID)
 		if exception != nil {
 			c.JSON(int(exception.StatusCode), exception)
 			c.Abort()
 			return
 		}
 
+		m.as.TouchSession(c, session, dto.SessionMetaDto{
+			IP:        c.ClientIP(),
+			UserAgent: c.Request.UserAgent(),
+		})
+
 		c.Next()
 	}
 }
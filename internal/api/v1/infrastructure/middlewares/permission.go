@@ -0,0 +1,83 @@
+package middlewares
+
+import (
+	"strconv"
+
+	"example.com/m/internal/api/v1/core/application/exceptions"
+	"example.com/m/internal/api/v1/core/application/services/role_service"
+	"example.com/m/internal/api/v1/utils"
+	"github.com/gin-gonic/gin"
+)
+
+type PermissionMiddleware struct {
+	rs role_service.RoleService
+}
+
+func NewPermissionMiddleware(rs *role_service.RoleService) *PermissionMiddleware {
+	return &PermissionMiddleware{
+		rs: *rs,
+	}
+}
+
+// RequirePermission allows the request if one of the user's roles grants the
+// permission regardless of the place.
+func (m *PermissionMiddleware) RequirePermission(permission string) gin.HandlerFunc {
+	return m.check(permission, "")
+}
+
+// RequirePlacePermission also allows roles scoped to the place whose id is the
+// path parameter.
+func (m *PermissionMiddleware) RequirePlacePermission(permission string, param string) gin.HandlerFunc {
+	return m.check(permission, param)
+}
+
+func (m *PermissionMiddleware) check(permission string, param string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+
+		token, err := utils.ExtractTokenFromHeaders(c)
+		if err != nil {
+			c.JSON(int(err.StatusCode), err)
+			c.Abort()
+			return
+		}
+
+		if err := utils.ValidateTokenSignature(*token); err != nil {
+			c.JSON(int(err.StatusCode), err)
+			c.Abort()
+			return
+		}
+
+		payload, err := utils.ExtractPayloadFromJWT(*token)
+		if err != nil {
+			c.JSON(int(err.StatusCode), err)
+			c.Abort()
+			return
+		}
+
+		email := payload["email"].(string)
+
+		var placeID *int64
+		if param != "" {
+			id, err := strconv.ParseInt(c.Param(param), 10, 64)
+			if err != nil {
+				c.JSON(400, gin.H{"error": "invalid " + param})
+				c.Abort()
+				return
+			}
+			placeID = &id
+		}
+
+		allowed, exception := m.rs.HasPermission(c, email, permission, placeID)
+		if exception != nil {
+			c.JSON(int(exception.StatusCode), exception)
+			c.Abort()
+			return
+		}
+		if !allowed {
+			c.JSON(int(exceptions.ErrPermissionDenied.StatusCode), exceptions.ErrPermissionDenied)
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
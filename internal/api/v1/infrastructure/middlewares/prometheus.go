@@ -17,6 +17,8 @@ type Metrics struct {
 	responseSize     *prometheus.SummaryVec
 	requestsInFlight prometheus.Gauge
 	errorsTotal      *prometheus.CounterVec
+	lockoutsTotal    *prometheus.CounterVec
+	throttledTotal   *prometheus.CounterVec
 }
 
 func NewPrometheusMetrics() *Metrics {
@@ -61,9 +63,31 @@ func NewPrometheusMetrics() *Metrics {
 			},
 			[]string{"method", "path", "status"},
 		),
+		lockoutsTotal: promauto.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "auth_lockouts_total",
+				Help: "Total number of lockouts after too many failed attempts",
+			},
+			[]string{"action", "scope"},
+		),
+		throttledTotal: promauto.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "auth_throttled_requests_total",
+				Help: "Total number of requests rejected during a lockout",
+			},
+			[]string{"action"},
+		),
 	}
 }
 
+func (m *Metrics) RecordLockout(action string, scope string) {
+	m.lockoutsTotal.With(prometheus.Labels{"action": action, "scope": scope}).Inc()
+}
+
+func (m *Metrics) RecordThrottled(action string) {
+	m.throttledTotal.With(prometheus.Labels{"action": action}).Inc()
+}
+
 func normalizePath(path string) string {
 	re := regexp.MustCompile(`/(\d+)`)
 	return re.ReplaceAllString(path, "/:id")
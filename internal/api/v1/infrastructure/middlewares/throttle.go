@@ -0,0 +1,108 @@
+package middlewares
+
+import (
+	"math"
+	"strconv"
+
+	"example.com/m/internal/api/v1/core/application/exceptions"
+	"example.com/m/internal/api/v1/core/application/services/throttle_service"
+	"example.com/m/internal/api/v1/utils"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type ThrottleMiddleware struct {
+	ts      throttle_service.ThrottleService
+	metrics *Metrics
+	logger  *zap.Logger
+}
+
+func NewThrottleMiddleware(ts *throttle_service.ThrottleService, metrics *Metrics, logger *zap.Logger) *ThrottleMiddleware {
+	return &ThrottleMiddleware{
+		ts:      *ts,
+		metrics: metrics,
+		logger:  logger,
+	}
+}
+
+// emailBody is any request body with an email, e.g. credentials.
+type emailBody struct {
+	Email string `json:"email"`
+}
+
+// subject identifies the account by the JWT of an authenticated request or by
+// the email in the body. The body is cached by gin, so handlers can still bind
+// it with ShouldBindBodyWithJSON.
+func subject(c *gin.Context, policy throttle_service.Policy) throttle_service.Subject {
+	s := throttle_service.Subject{IP: c.ClientIP()}
+
+	if policy.AccountFromToken {
+		token, err := utils.ExtractTokenFromHeaders(c)
+		if err != nil {
+			return s
+		}
+		if payload, err := utils.ExtractPayloadFromJWT(*token); err == nil {
+			s.Email, _ = payload["email"].(string)
+		}
+		return s
+	}
+
+	var body emailBody
+	if err := c.ShouldBindBodyWithJSON(&body); err == nil {
+		s.Email = body.Email
+	}
+	return s
+}
+
+func retryAfter(c *gin.Context, seconds float64) {
+	c.Header("Retry-After", strconv.Itoa(int(math.Ceil(seconds))))
+	c.JSON(int(exceptions.ErrTooManyAttempts.StatusCode), exceptions.ErrTooManyAttempts)
+	c.Abort()
+}
+
+// Throttle rejects requests while the account or the IP is locked out and
+// counts the responses the policy considers failed attempts.
+func (m *ThrottleMiddleware) Throttle(policy throttle_service.Policy) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		s := subject(c, policy)
+
+		wait, exception := m.ts.Check(c, policy, s)
+		if exception != nil {
+			c.JSON(int(exception.StatusCode), exception)
+			c.Abort()
+			return
+		}
+		if wait > 0 {
+			m.metrics.RecordThrottled(policy.Action)
+			retryAfter(c, wait.Seconds())
+			return
+		}
+
+		c.Next()
+
+		status := c.Writer.Status()
+		if !policy.IsFailure(status) {
+			if status < 300 {
+				// the request is already served
+				_ = m.ts.RecordSuccess(c, policy, s)
+			}
+			return
+		}
+
+		lockouts, exception := m.ts.RecordFailure(c, policy, s)
+		if exception != nil {
+			return
+		}
+		for _, lockout := range lockouts {
+			m.metrics.RecordLockout(policy.Action, lockout.Scope)
+			m.logger.Warn(
+				"Lockout",
+				zap.String("action", policy.Action),
+				zap.String("scope", lockout.Scope),
+				zap.String("email", s.Email),
+				zap.String("ip", s.IP),
+				zap.Duration("duration", lockout.Duration),
+			)
+		}
+	}
+}
@@ -0,0 +1,351 @@
+package oidc
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"example.com/m/internal/config"
+	"github.com/golang-jwt/jwt/v5"
+	"go.uber.org/zap"
+)
+
+// Client is nil when OIDC_ISSUER is not set and SSO login is disabled.
+var Client *Provider
+
+func InitProvider(logger *zap.Logger) {
+	if config.Config.OIDCIssuer == "" {
+		logger.Info("OIDC login is disabled")
+		return
+	}
+
+	Client = NewProvider(
+		config.Config.OIDCIssuer,
+		config.Config.OIDCClientID,
+		config.Config.OIDCClientSecret,
+		config.Config.OIDCRedirectURL,
+		strings.Fields(config.Config.OIDCScopes),
+	)
+}
+
+// Claims are the claims of an ID token used to find or create the user.
+type Claims struct {
+	Email             string `json:"email"`
+	EmailVerified     bool   `json:"email_verified"`
+	Name              string `json:"name"`
+	PreferredUsername string `json:"preferred_username"`
+	Nonce             string `json:"nonce"`
+	jwt.RegisteredClaims
+}
+
+type discovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+type jsonWebKey struct {
+	Kid string `json:"kid"`
+	Kty string `json:"kty"`
+	Crv string `json:"crv"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// Provider is a client of an OpenID Connect provider for the authorization
+// code flow. The discovery document and the signing keys are fetched on first
+// use, so the API starts even when the provider is unavailable.
+type Provider struct {
+	issuer       string
+	clientID     string
+	clientSecret string
+	redirectURL  string
+	scopes       []string
+	http         *http.Client
+
+	mu        sync.Mutex
+	endpoints *discovery
+	keys      map[string]interface{}
+	keysAt    time.Time
+}
+
+// keysRefreshInterval limits how often unknown key ids make us refetch JWKS.
+const keysRefreshInterval = time.Minute
+
+func NewProvider(issuer string, clientID string, clientSecret string, redirectURL string, scopes []string) *Provider {
+	if len(scopes) == 0 {
+		scopes = []string{"openid", "email", "profile"}
+	}
+	return &Provider{
+		issuer:       strings.TrimSuffix(issuer, "/"),
+		clientID:     clientID,
+		clientSecret: clientSecret,
+		redirectURL:  redirectURL,
+		scopes:       scopes,
+		http:         &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (p *Provider) Issuer() string {
+	return p.issuer
+}
+
+func (p *Provider) getJSON(ctx context.Context, url string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := p.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s: %s", url, resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// discover returns the provider's endpoints. The document is fetched without
+// holding the lock, so a slow provider doesn't block logins that already have
+// it; concurrent first calls may fetch it more than once.
+func (p *Provider) discover(ctx context.Context) (*discovery, error) {
+	p.mu.Lock()
+	endpoints := p.endpoints
+	p.mu.Unlock()
+	if endpoints != nil {
+		return endpoints, nil
+	}
+
+	var d discovery
+	if err := p.getJSON(ctx, p.issuer+"/.well-known/openid-configuration", &d); err != nil {
+		return nil, err
+	}
+	if strings.TrimSuffix(d.Issuer, "/") != p.issuer {
+		return nil, fmt.Errorf("issuer %q doesn't match %q", d.Issuer, p.issuer)
+	}
+	if d.AuthorizationEndpoint == "" || d.TokenEndpoint == "" || d.JWKSURI == "" {
+		return nil, errors.New("discovery document is incomplete")
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.endpoints == nil {
+		p.endpoints = &d
+	}
+	return p.endpoints, nil
+}
+
+// AuthCodeURL returns the address of the provider's login page. The challenge
+// is the S256 PKCE challenge of the verifier later passed to Exchange.
+func (p *Provider) AuthCodeURL(ctx context.Context, state string, nonce string, challenge string) (string, error) {
+	d, err := p.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	u, err := url.Parse(d.AuthorizationEndpoint)
+	if err != nil {
+		return "", err
+	}
+	q := u.Query()
+	q.Set("response_type", "code")
+	q.Set("client_id", p.clientID)
+	q.Set("redirect_uri", p.redirectURL)
+	q.Set("scope", strings.Join(p.scopes, " "))
+	q.Set("state", state)
+	q.Set("nonce", nonce)
+	q.Set("code_challenge", challenge)
+	q.Set("code_challenge_method", "S256")
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
+
+// Exchange redeems the authorization code and returns the verified claims of
+// the ID token.
+func (p *Provider) Exchange(ctx context.Context, code string, verifier string, nonce string) (*Claims, error) {
+	d, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {p.redirectURL},
+		"client_id":     {p.clientID},
+		"code_verifier": {verifier},
+	}
+	if p.clientSecret != "" {
+		form.Set("client_secret", p.clientSecret)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := p.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("token endpoint: %s", resp.Status)
+	}
+
+	var tokens struct {
+		IDToken string `json:"id_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&tokens); err != nil {
+		return nil, err
+	}
+	if tokens.IDToken == "" {
+		return nil, errors.New("token response has no id_token")
+	}
+
+	return p.verify(ctx, tokens.IDToken, nonce)
+}
+
+func (p *Provider) verify(ctx context.Context, idToken string, nonce string) (*Claims, error) {
+	var claims Claims
+	_, err := jwt.ParseWithClaims(idToken, &claims, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		return p.key(ctx, kid)
+	},
+		jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "ES256", "ES384", "ES512"}),
+		jwt.WithIssuer(p.issuer),
+		jwt.WithAudience(p.clientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithLeeway(time.Minute),
+	)
+	if err != nil {
+		return nil, err
+	}
+	if claims.Nonce != nonce {
+		return nil, errors.New("nonce mismatch")
+	}
+	if claims.Subject == "" {
+		return nil, errors.New("id_token has no subject")
+	}
+	return &claims, nil
+}
+
+// key returns the signing key with the id, refetching JWKS when the provider
+// may have rotated its keys. The keys are fetched without holding the lock;
+// keysAt is moved forward before the fetch so that only one caller refetches.
+func (p *Provider) key(ctx context.Context, kid string) (interface{}, error) {
+	d, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	if key := p.findKey(kid); key != nil {
+		p.mu.Unlock()
+		return key, nil
+	}
+	fetchedAt := p.keysAt
+	if time.Since(fetchedAt) < keysRefreshInterval {
+		p.mu.Unlock()
+		return nil, fmt.Errorf("unknown key %q", kid)
+	}
+	p.keysAt = time.Now()
+	p.mu.Unlock()
+
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := p.getJSON(ctx, d.JWKSURI, &set); err != nil {
+		p.mu.Lock()
+		p.keysAt = fetchedAt
+		p.mu.Unlock()
+		return nil, err
+	}
+	keys := map[string]interface{}{}
+	for _, k := range set.Keys {
+		if key, err := k.publicKey(); err == nil {
+			keys[k.Kid] = key
+		}
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.keys = keys
+	if key := p.findKey(kid); key != nil {
+		return key, nil
+	}
+	return nil, fmt.Errorf("unknown key %q", kid)
+}
+
+// findKey looks the key up by id. A token without kid is accepted only when
+// the provider has a single key.
+func (p *Provider) findKey(kid string) interface{} {
+	if kid == "" && len(p.keys) == 1 {
+		for _, key := range p.keys {
+			return key
+		}
+	}
+	return p.keys[kid]
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(b), nil
+}
+
+func (k jsonWebKey) publicKey() (interface{}, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	}
+	return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+}
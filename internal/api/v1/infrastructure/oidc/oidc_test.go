@@ -0,0 +1,114 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+type mockIdP struct {
+	server    *httptest.Server
+	key       *rsa.PrivateKey
+	challenge string
+	nonce     string
+}
+
+func newMockIdP(t *testing.T) *mockIdP {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	idp := &mockIdP{key: key}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 idp.server.URL,
+			"authorization_endpoint": idp.server.URL + "/authorize",
+			"token_endpoint":         idp.server.URL + "/token",
+			"jwks_uri":               idp.server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"keys": []map[string]string{{
+				"kid": "test",
+				"kty": "RSA",
+				"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			}},
+		})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		sum := sha256.Sum256([]byte(r.Form.Get("code_verifier")))
+		if r.Form.Get("code") != "code" || base64.RawURLEncoding.EncodeToString(sum[:]) != idp.challenge {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+			"iss":            idp.server.URL,
+			"aud":            "client",
+			"sub":            "42",
+			"email":          "student@university.ru",
+			"email_verified": true,
+			"nonce":          idp.nonce,
+			"iat":            time.Now().Unix(),
+			"exp":            time.Now().Add(time.Minute).Unix(),
+		})
+		token.Header["kid"] = "test"
+		signed, _ := token.SignedString(key)
+		json.NewEncoder(w).Encode(map[string]string{"id_token": signed})
+	})
+	idp.server = httptest.NewServer(mux)
+	t.Cleanup(idp.server.Close)
+	return idp
+}
+
+func TestProviderAuthorizationCodeFlow(t *testing.T) {
+	idp := newMockIdP(t)
+	p := NewProvider(idp.server.URL, "client", "", "http://localhost/callback", nil)
+	ctx := context.Background()
+
+	verifier := "verifier"
+	sum := sha256.Sum256([]byte(verifier))
+	challenge := base64.RawURLEncoding.EncodeToString(sum[:])
+
+	authURL, err := p.AuthCodeURL(ctx, "state", "nonce", challenge)
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, _ := url.Parse(authURL)
+	idp.challenge = u.Query().Get("code_challenge")
+	idp.nonce = u.Query().Get("nonce")
+	if u.Query().Get("code_challenge_method") != "S256" || u.Query().Get("state") != "state" {
+		t.Fatalf("unexpected auth url %s", authURL)
+	}
+
+	claims, err := p.Exchange(ctx, "code", verifier, "nonce")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claims.Subject != "42" || claims.Email != "student@university.ru" || !claims.EmailVerified {
+		t.Errorf("unexpected claims %+v", claims)
+	}
+
+	if _, err := p.Exchange(ctx, "code", "wrong verifier", "nonce"); err == nil {
+		t.Error("code redeemed with a wrong verifier")
+	}
+	if _, err := p.Exchange(ctx, "code", verifier, "other nonce"); err == nil {
+		t.Error("token accepted with a wrong nonce")
+	}
+}
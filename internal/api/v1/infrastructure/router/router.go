@@ -3,6 +3,8 @@ package router
 import (
 	docs "example.com/m/docs"
 	"example.com/m/internal/api/v1/adapters/controllers"
+	"example.com/m/internal/api/v1/core/application/dto"
+	"example.com/m/internal/api/v1/core/application/services/throttle_service"
 	"example.com/m/internal/api/v1/infrastructure/middlewares"
 	"github.com/gin-gonic/gin"
 	swaggerfiles "github.com/swaggo/files"
@@ -12,30 +14,50 @@ import (
 const prefix string = "/api/v1"
 
 type Router struct {
-	e   *gin.Engine
-	am  middlewares.AuthMiddleware
-	adm middlewares.AdminMiddleware
+	e  *gin.Engine
+	am middlewares.AuthMiddleware
+	pm middlewares.PermissionMiddleware
+	tm middlewares.ThrottleMiddleware
 }
 
-func NewRouter(e *gin.Engine, am *middlewares.AuthMiddleware, adm *middlewares.AdminMiddleware) *Router {
+func NewRouter(
+	e *gin.Engine, am *middlewares.AuthMiddleware,
+	pm *middlewares.PermissionMiddleware, tm *middlewares.ThrottleMiddleware,
+) *Router {
 	return &Router{
-		e:   e,
-		am:  *am,
-		adm: *adm,
+		e:  e,
+		am: *am,
+		pm: *pm,
+		tm: *tm,
 	}
 }
 
 func (r *Router) BindUserRoutes(uc *controllers.UserController) {
-	r.e.POST(prefix+"/users", uc.CreateUser)
+	r.e.POST(prefix+"/users", r.tm.Throttle(throttle_service.RegistrationPolicy()), uc.CreateUser)
 	r.e.GET(prefix+"/users/:username", r.am.Authenticate(), uc.GetUserByUsername)
 	r.e.GET(prefix+"/users/me", r.am.Authenticate(), uc.GetUserProfile)
 	r.e.PATCH(prefix+"/users/me", r.am.Authenticate(), uc.UpdateUserProfile)
+	r.e.POST(prefix+"/users/me/avatar", r.am.Authenticate(), uc.UploadAvatar)
+	r.e.DELETE(prefix+"/users/me/avatar", r.am.Authenticate(), uc.DeleteAvatar)
 	r.e.POST(prefix+"/users/bind_token", r.am.Authenticate(), uc.BindPushToken)
+	r.e.POST(prefix+"/users/verify", uc.VerifyEmail)
+	r.e.GET(prefix+"/users/verify", uc.VerifyEmailByLink)
+	r.e.POST(prefix+"/users/verify/resend", uc.ResendVerification)
 }
 
 func (r *Router) BindAuthRoutes(ac *controllers.AuthController) {
-	r.e.POST(prefix+"/auth", ac.AuthorizeUser)
-	r.e.PATCH(prefix+"/auth/changePassword", r.am.Authenticate(), ac.ChangePassword)
+	r.e.POST(prefix+"/auth", r.tm.Throttle(throttle_service.LoginPolicy()), ac.AuthorizeUser)
+	r.e.POST(prefix+"/auth/refresh", ac.Refresh)
+	r.e.GET(prefix+"/auth/oidc/login", ac.StartOIDCLogin)
+	r.e.GET(prefix+"/auth/oidc/callback", ac.FinishOIDCLogin)
+	r.e.POST(prefix+"/auth/logout", r.am.Authenticate(), ac.Logout)
+	r.e.POST(prefix+"/auth/logout-all", r.am.Authenticate(), ac.LogoutEverywhere)
+	r.e.GET(prefix+"/auth/sessions", r.am.Authenticate(), ac.GetSessions)
+	r.e.DELETE(prefix+"/auth/sessions", r.am.Authenticate(), ac.RevokeOtherSessions)
+	r.e.DELETE(prefix+"/auth/sessions/:id", r.am.Authenticate(), ac.RevokeSession)
+	r.e.PATCH(prefix+"/auth/changePassword", r.am.Authenticate(), r.tm.Throttle(throttle_service.PasswordChangePolicy()), ac.ChangePassword)
+	r.e.POST(prefix+"/auth/password/forgot", ac.ForgotPassword)
+	r.e.POST(prefix+"/auth/password/reset", ac.ResetPassword)
 }
 
 func (r *Router) BindSwaggerRoutes() {
@@ -55,6 +77,8 @@ func (r *Router) BindMetricsRoutes(m *controllers.MetricController) {
 func (r *Router) BindPostRoutes(pc *controllers.PostController) {
 	r.e.POST(prefix+"/posts", r.am.Authenticate(), pc.CreatePost)
 	r.e.GET(prefix+"/posts/:id", r.am.Authenticate(), pc.GetPost)
+	r.e.PATCH(prefix+"/posts/:id", r.am.Authenticate(), pc.UpdatePost)
+	r.e.DELETE(prefix+"/posts/:id", r.am.Authenticate(), pc.DeletePost)
 	r.e.GET(prefix+"/posts/my", r.am.Authenticate(), pc.GetMyPosts)
 	r.e.PUT(prefix+"/posts/:id/favorites", r.am.Authenticate(), pc.AddFavorite)
 	r.e.DELETE(prefix+"/posts/:id/favorites", r.am.Authenticate(), pc.DeleteFavorite)
@@ -63,26 +87,83 @@ func (r *Router) BindPostRoutes(pc *controllers.PostController) {
 	r.e.GET(prefix+"/posts/search", r.am.Authenticate(), pc.SearchByTitleOrAuthorOrGenre)
 	r.e.GET(prefix+"/posts/booked", r.am.Authenticate(), pc.GetAllMyBooked)
 	r.e.POST(prefix+"/posts/:id/image", r.am.Authenticate(), pc.AddImage)
+	r.e.PUT(prefix+"/posts/:id/images", r.am.Authenticate(), pc.ReorderImages)
+	r.e.PUT(prefix+"/posts/:id/images/primary", r.am.Authenticate(), pc.SetPrimaryImage)
+	r.e.DELETE(prefix+"/posts/:id/images", r.am.Authenticate(), pc.RemoveImage)
 }
 
 func (r *Router) BindBookingRoutes(bc *controllers.BookingController) {
 	r.e.POST(prefix+"/posts/:id/booking", r.am.Authenticate(), bc.BookBook)
 	r.e.DELETE(prefix+"/posts/:id/booking", r.am.Authenticate(), bc.DeleteBooking)
-	r.e.PUT(prefix+"/posts/:id/mark-taken", r.am.Authenticate(), bc.MarkAsTaken)
+	r.e.PUT(prefix+"/posts/:id/booking/accept", r.am.Authenticate(), bc.AcceptBooking)
+	r.e.PUT(prefix+"/posts/:id/booking/reject", r.am.Authenticate(), bc.RejectBooking)
+	r.e.PUT(prefix+"/posts/:id/booking/no-show", r.am.Authenticate(), bc.ReportNoShow)
+	r.e.PUT(prefix+"/posts/:id/booking/slot", r.am.Authenticate(), bc.ReservePickupSlot)
+	r.e.GET(prefix+"/posts/:id/booking/calendar.ics", r.am.Authenticate(), bc.GetPickupCalendar)
+	r.e.GET(prefix+"/bookings/incoming", r.am.Authenticate(), bc.GetIncomingBookings)
+	r.e.GET(prefix+"/posts/:id/waitlist", r.am.Authenticate(), bc.GetWaitlistPosition)
+	r.e.DELETE(prefix+"/posts/:id/waitlist", r.am.Authenticate(), bc.LeaveWaitlist)
+	r.e.GET(prefix+"/bookings/waitlist", r.am.Authenticate(), bc.GetMyWaitlist)
+	r.e.POST(prefix+"/posts/:id/handover", r.am.Authenticate(), bc.CreateHandoverCode)
+	r.e.POST(prefix+"/posts/:id/handover/confirm", r.am.Authenticate(), bc.ConfirmHandover)
 }
 
 func (r *Router) BindReviewRoutes(rc *controllers.ReviewController) {
 	r.e.POST(prefix+"/reviews", r.am.Authenticate(), rc.CreateReview)
 	r.e.GET(prefix+"/users/:username/reviews", r.am.Authenticate(), rc.GetReviewsForUser)
+	r.e.PATCH(prefix+"/reviews/:id", r.am.Authenticate(), rc.UpdateReview)
+	r.e.DELETE(prefix+"/reviews/:id", r.am.Authenticate(), rc.DeleteReview)
+	r.e.PUT(prefix+"/admin/reviews/:id/hide", r.am.Authenticate(), r.pm.RequirePermission(dto.PermissionModerateReviews), rc.HideReview)
+	r.e.DELETE(prefix+"/admin/reviews/:id/hide", r.am.Authenticate(), r.pm.RequirePermission(dto.PermissionModerateReviews), rc.UnhideReview)
 }
 
 func (r *Router) BindPlaceRoutes(pc *controllers.PlaceController) {
-	r.e.POST(prefix+"/places", r.am.Authenticate(), r.adm.CheckAdminStatus(), pc.CreatePlace)
+	r.e.POST(prefix+"/places", r.am.Authenticate(), r.pm.RequirePermission(dto.PermissionCreatePlaces), pc.CreatePlace)
 	r.e.GET(prefix+"/places", pc.GetPlaces)
-	r.e.DELETE(prefix+"/places/:id", r.am.Authenticate(), r.adm.CheckAdminStatus(), pc.DeletePlace)
+	r.e.DELETE(prefix+"/places/:id", r.am.Authenticate(), r.pm.RequirePlacePermission(dto.PermissionManagePlaces, "id"), pc.DeletePlace)
+	r.e.GET(prefix+"/places/:id/hours", pc.GetOpeningHours)
+	r.e.PUT(prefix+"/places/:id/hours", r.am.Authenticate(), r.pm.RequirePlacePermission(dto.PermissionManagePlaces, "id"), pc.SetOpeningHours)
+	r.e.GET(prefix+"/places/:id/slots", pc.GetPickupSlots)
+	r.e.POST(prefix+"/places/:id/slots", r.am.Authenticate(), r.pm.RequirePlacePermission(dto.PermissionManagePlaces, "id"), pc.CreatePickupSlot)
+	r.e.DELETE(prefix+"/places/:id/slots/:slot_id", r.am.Authenticate(), r.pm.RequirePlacePermission(dto.PermissionManagePlaces, "id"), pc.DeletePickupSlot)
 }
 
 func (r *Router) BindChatBotRoutes(cc *controllers.ChatBotController) {
 	r.e.POST(prefix+"/chat", r.am.Authenticate(), cc.SendMessage)
 	r.e.GET(prefix+"/chat", r.am.Authenticate(), cc.GetChat)
-}
\ No newline at end of file
+}
+
+func (r *Router) BindExchangeRoutes(ec *controllers.ExchangeController) {
+	r.e.GET(prefix+"/exchanges/given", r.am.Authenticate(), ec.GetGivenExchanges)
+	r.e.GET(prefix+"/exchanges/received", r.am.Authenticate(), ec.GetReceivedExchanges)
+	r.e.GET(prefix+"/admin/exchanges", r.am.Authenticate(), r.pm.RequirePermission(dto.PermissionViewExchanges), ec.GetAllExchanges)
+}
+
+func (r *Router) BindRoleRoutes(rc *controllers.RoleController) {
+	r.e.GET(prefix+"/users/me/roles", r.am.Authenticate(), rc.GetMyRoles)
+	r.e.GET(prefix+"/admin/users/:username/roles", r.am.Authenticate(), r.pm.RequirePermission(dto.PermissionManageRoles), rc.GetUserRoles)
+	r.e.POST(prefix+"/admin/users/:username/roles", r.am.Authenticate(), r.pm.RequirePermission(dto.PermissionManageRoles), rc.GrantRole)
+	r.e.DELETE(prefix+"/admin/users/:username/roles/:role", r.am.Authenticate(), r.pm.RequirePermission(dto.PermissionManageRoles), rc.RevokeRole)
+}
+
+func (r *Router) BindAccountRoutes(ac *controllers.AccountController) {
+	r.e.GET(prefix+"/users/me/export", r.am.Authenticate(), ac.ExportData)
+	r.e.POST(prefix+"/users/me/deletion", r.am.Authenticate(), ac.ScheduleDeletion)
+	r.e.DELETE(prefix+"/users/me/deletion", r.am.Authenticate(), ac.CancelDeletion)
+}
+
+func (r *Router) BindLoanRoutes(lc *controllers.LoanController) {
+	r.e.GET(prefix+"/loans/overdue", r.am.Authenticate(), lc.GetOverdueLoans)
+	r.e.POST(prefix+"/loans/:id/return", r.am.Authenticate(), lc.ConfirmReturn)
+}
+
+func (r *Router) BindSwapRoutes(sc *controllers.SwapController) {
+	r.e.POST(prefix+"/posts/:id/swaps", r.am.Authenticate(), sc.ProposeSwap)
+	r.e.GET(prefix+"/swaps/sent", r.am.Authenticate(), sc.GetSentOffers)
+	r.e.GET(prefix+"/swaps/received", r.am.Authenticate(), sc.GetReceivedOffers)
+	r.e.GET(prefix+"/swaps/:id", r.am.Authenticate(), sc.GetSwapOffer)
+	r.e.PUT(prefix+"/swaps/:id/accept", r.am.Authenticate(), sc.AcceptSwap)
+	r.e.PUT(prefix+"/swaps/:id/reject", r.am.Authenticate(), sc.RejectSwap)
+	r.e.POST(prefix+"/swaps/:id/counter", r.am.Authenticate(), sc.CounterSwap)
+	r.e.DELETE(prefix+"/swaps/:id", r.am.Authenticate(), sc.WithdrawSwap)
+}
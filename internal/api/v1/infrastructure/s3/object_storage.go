@@ -1,8 +1,10 @@
 package s3_storage
 
 import (
+	"bytes"
 	"context"
 	"fmt"
+	"io"
 	"mime/multipart"
 	"net/url"
 	"time"
@@ -49,6 +51,45 @@ func (basics ClientS3) UploadFile(ctx context.Context, bucketName string, object
 	return err
 }
 
+// UploadBytes uploads data which isn't a multipart file, e.g. a processed image.
+func (basics ClientS3) UploadBytes(ctx context.Context, bucketName string, objectKey string, data []byte, contentType string) error {
+	_, err := basics.S3Client.PutObject(ctx,
+		&s3.PutObjectInput{
+			Bucket:      aws.String(bucketName),
+			Key:         aws.String(objectKey),
+			Body:        bytes.NewReader(data),
+			ContentType: aws.String(contentType),
+			ACL:         "public-read",
+		})
+	if err != nil {
+		return err
+	}
+	return s3.NewObjectExistsWaiter(basics.S3Client).Wait(
+		ctx, &s3.HeadObjectInput{Bucket: aws.String(bucketName), Key: aws.String(objectKey)}, time.Minute)
+}
+
+func (basics ClientS3) DeleteFile(ctx context.Context, bucketName string, objectKey string) error {
+	_, err := basics.S3Client.DeleteObject(ctx,
+		&s3.DeleteObjectInput{
+			Bucket: aws.String(bucketName),
+			Key:    aws.String(objectKey),
+		})
+	return err
+}
+
+func (basics ClientS3) DownloadFile(ctx context.Context, bucketName string, objectKey string) ([]byte, error) {
+	out, err := basics.S3Client.GetObject(ctx,
+		&s3.GetObjectInput{
+			Bucket: aws.String(bucketName),
+			Key:    aws.String(objectKey),
+		})
+	if err != nil {
+		return nil, err
+	}
+	defer out.Body.Close()
+	return io.ReadAll(out.Body)
+}
+
 func (r *resolverV2) ResolveEndpoint(ctx context.Context, params s3.EndpointParameters) (
 	smithyendpoints.Endpoint, error,
 ) {
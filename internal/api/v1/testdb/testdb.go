@@ -0,0 +1,84 @@
+// Package testdb prepares a disposable database for the tests which run with
+// go test -tags integration.
+package testdb
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	_ "github.com/lib/pq"
+	"github.com/pressly/goose/v3"
+)
+
+// Open connects to the database at TEST_POSTGRES_CONNECTION_STRING and
+// applies the migrations from scripts/ to it.
+func Open(t *testing.T) *sql.DB {
+	dsn := os.Getenv("TEST_POSTGRES_CONNECTION_STRING")
+	if dsn == "" {
+		t.Fatal("TEST_POSTGRES_CONNECTION_STRING is not set")
+	}
+
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatalf("got error %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	// scripts/ is relative to this file, not to the package under test
+	_, file, _, _ := runtime.Caller(0)
+	scripts := filepath.Join(filepath.Dir(file), "..", "..", "..", "..", "scripts")
+
+	goose.SetLogger(goose.NopLogger())
+	if err := goose.SetDialect("postgres"); err != nil {
+		t.Fatalf("got error %v", err)
+	}
+	if err := goose.Up(db, scripts); err != nil {
+		t.Fatalf("got error %v", err)
+	}
+	return db
+}
+
+// CreateUsers creates verified users, they are deleted with everything they
+// own after the test.
+func CreateUsers(t *testing.T, db *sql.DB, emails ...string) {
+	suffix := time.Now().UnixNano()
+	now := time.Now().UTC().Format("2006-01-02T15:04:05Z")
+	for i, email := range emails {
+		_, err := db.Exec(
+			"INSERT INTO users (email, username, password, created_at, updated_at, is_verified) VALUES ($1, $2, '', $3, $3, true)",
+			email, fmt.Sprintf("u%d_%d", i, suffix), now,
+		)
+		if err != nil {
+			t.Fatalf("got error %v", err)
+		}
+	}
+	t.Cleanup(func() {
+		for _, email := range emails {
+			db.Exec("DELETE FROM users WHERE email = $1", email)
+		}
+	})
+}
+
+// CreatePost creates an available giveaway post of the owner at a new place.
+func CreatePost(t *testing.T, db *sql.DB, owner string) int64 {
+	now := time.Now().UTC().Format("2006-01-02T15:04:05Z")
+	var placeID, postID int64
+	if err := db.QueryRow("INSERT INTO places (name) VALUES ('test') RETURNING id").Scan(&placeID); err != nil {
+		t.Fatalf("got error %v", err)
+	}
+	t.Cleanup(func() { db.Exec("DELETE FROM places WHERE id = $1", placeID) })
+
+	err := db.QueryRow(
+		"INSERT INTO posts (user_email, place_id, title, status, created_at) VALUES ($1, $2, 'test', 'available', $3) RETURNING id",
+		owner, placeID, now,
+	).Scan(&postID)
+	if err != nil {
+		t.Fatalf("got error %v", err)
+	}
+	return postID
+}
@@ -1,6 +1,11 @@
 package utils
 
 import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"math/big"
 	"strings"
 
 	"example.com/m/internal/api/v1/core/application/exceptions"
@@ -51,3 +56,35 @@ func ExtractTokenFromHeaders(c *gin.Context) (*string, *exceptions.Error_) {
 
 	return &token[1], nil
 }
+
+// GenerateCode returns a random numeric code of the given length for
+// confirmations sent by email.
+func GenerateCode(length int) (string, error) {
+	code := make([]byte, length)
+	for i := range code {
+		n, err := rand.Int(rand.Reader, big.NewInt(10))
+		if err != nil {
+			return "", err
+		}
+		code[i] = byte('0' + n.Int64())
+	}
+	return string(code), nil
+}
+
+// HashCode is used to store one-time codes and secrets without the plain value.
+func HashCode(code string) string {
+	sum := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(sum[:])
+}
+
+// SignValues returns an HMAC of the values with the JWT secret, it is used for
+// links sent by email.
+func SignValues(values ...string) string {
+	mac := hmac.New(sha256.New, []byte(config.Config.JWTSecret))
+	mac.Write([]byte(strings.Join(values, "\n")))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func CheckSignature(signature string, values ...string) bool {
+	return hmac.Equal([]byte(signature), []byte(SignValues(values...)))
+}
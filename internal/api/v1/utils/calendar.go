@@ -0,0 +1,74 @@
+package utils
+
+import (
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"example.com/m/internal/api/v1/core/application/dto"
+)
+
+const icsTimeFormat = "20060102T150405Z"
+
+var icsEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+
+// foldICSLine splits the content line into lines of at most 75 octets as
+// RFC 5545 requires, without breaking UTF-8 characters.
+func foldICSLine(line string) string {
+	var b strings.Builder
+	limit := 75
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		b.WriteString(line[:cut])
+		b.WriteString("\r\n ")
+		line = line[cut:]
+		// the leading space of a continuation line counts too
+		limit = 74
+	}
+	b.WriteString(line)
+	b.WriteString("\r\n")
+	return b.String()
+}
+
+// BuildICS returns an iCalendar file with the single event. stamp is the
+// time the file is created.
+func BuildICS(e *dto.CalendarEventDto, stamp time.Time) (string, error) {
+	startsAt, err := time.Parse(time.RFC3339, e.StartsAt)
+	if err != nil {
+		return "", err
+	}
+	endsAt, err := time.Parse(time.RFC3339, e.EndsAt)
+	if err != nil {
+		return "", err
+	}
+
+	lines := []string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:-//Bookcrossing//Pickup//RU",
+		"CALSCALE:GREGORIAN",
+		"METHOD:PUBLISH",
+		"BEGIN:VEVENT",
+		"UID:" + e.UID,
+		"DTSTAMP:" + stamp.UTC().Format(icsTimeFormat),
+		"DTSTART:" + startsAt.UTC().Format(icsTimeFormat),
+		"DTEND:" + endsAt.UTC().Format(icsTimeFormat),
+		"SUMMARY:" + icsEscaper.Replace(e.Summary),
+	}
+	if e.Description != "" {
+		lines = append(lines, "DESCRIPTION:"+icsEscaper.Replace(e.Description))
+	}
+	if e.Location != "" {
+		lines = append(lines, "LOCATION:"+icsEscaper.Replace(e.Location))
+	}
+	lines = append(lines, "END:VEVENT", "END:VCALENDAR")
+
+	var b strings.Builder
+	for _, line := range lines {
+		b.WriteString(foldICSLine(line))
+	}
+	return b.String(), nil
+}
@@ -2,8 +2,10 @@ package utils
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"image"
+	"image/color"
 	"image/jpeg"
 	"image/png"
 	"io"
@@ -33,3 +35,69 @@ func CompressImage(file io.Reader, filename string) (*bytes.Buffer, error) {
 
 	return &buf, nil
 }
+
+// maxImagePixels limits the decoded size of an image: a small file can
+// declare huge dimensions and take gigabytes of memory to decode.
+const maxImagePixels = 40_000_000
+
+var ErrImageTooLarge = errors.New("изображение слишком большое")
+
+// ResizeImage crops the image to a centered square and scales it down to
+// size x size, smaller images are only cropped. The result is always a JPEG,
+// transparent areas become white. Images of more than maxImagePixels pixels
+// are rejected with ErrImageTooLarge before they are decoded.
+func ResizeImage(file io.Reader, size int) (*bytes.Buffer, error) {
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось прочитать изображение: %v", err)
+	}
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("не удалось декодировать изображение: %v", err)
+	}
+	if int64(config.Width)*int64(config.Height) > maxImagePixels {
+		return nil, ErrImageTooLarge
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("не удалось декодировать изображение: %v", err)
+	}
+
+	bounds := img.Bounds()
+	side := min(bounds.Dx(), bounds.Dy())
+	if side == 0 {
+		return nil, fmt.Errorf("пустое изображение")
+	}
+	x0 := bounds.Min.X + (bounds.Dx()-side)/2
+	y0 := bounds.Min.Y + (bounds.Dy()-side)/2
+	size = min(size, side)
+
+	// every pixel of the result is the average of the source pixels it covers
+	dst := image.NewRGBA(image.Rect(0, 0, size, size))
+	for y := 0; y < size; y++ {
+		sy0, sy1 := y0+y*side/size, y0+(y+1)*side/size
+		for x := 0; x < size; x++ {
+			sx0, sx1 := x0+x*side/size, x0+(x+1)*side/size
+			var r, g, b, n uint64
+			for sy := sy0; sy < sy1; sy++ {
+				for sx := sx0; sx < sx1; sx++ {
+					cr, cg, cb, ca := img.At(sx, sy).RGBA()
+					// the colors are premultiplied, so adding the missing
+					// alpha puts the pixel on a white background
+					r += uint64(cr + 0xffff - ca)
+					g += uint64(cg + 0xffff - ca)
+					b += uint64(cb + 0xffff - ca)
+					n++
+				}
+			}
+			dst.Set(x, y, color.RGBA64{R: uint16(r / n), G: uint16(g / n), B: uint16(b / n), A: 0xffff})
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, dst, &jpeg.Options{Quality: 85}); err != nil {
+		return nil, fmt.Errorf("ошибка при сжатии: %v", err)
+	}
+	return &buf, nil
+}
@@ -0,0 +1,33 @@
+package utils
+
+import (
+	"strconv"
+
+	"example.com/m/internal/api/v1/core/application/dto"
+	"example.com/m/internal/api/v1/core/application/exceptions"
+	"github.com/gin-gonic/gin"
+)
+
+// ParsePageRequest reads the cursor and limit query parameters of a list
+// endpoint. key is the type of the column the list is sorted by.
+func ParsePageRequest(ctx *gin.Context, key dto.CursorKey) (*dto.PageRequest, *exceptions.Error_) {
+	page := &dto.PageRequest{Limit: dto.DefaultPageLimit}
+
+	if limitStr := ctx.Query("limit"); limitStr != "" {
+		limit, err := strconv.ParseUint(limitStr, 10, 64)
+		if err != nil || limit < 1 || limit > dto.MaxPageLimit {
+			return nil, &exceptions.ErrInvalidLimit
+		}
+		page.Limit = uint(limit)
+	}
+
+	if cursorStr := ctx.Query("cursor"); cursorStr != "" {
+		cursor, exc := dto.DecodeCursor(cursorStr, key)
+		if exc != nil {
+			return nil, exc
+		}
+		page.Cursor = cursor
+	}
+
+	return page, nil
+}
@@ -14,15 +14,43 @@ func UpdateUserTimestamps(u *dto.UpdateUserDto) {
 func ExcludeUserCredentials(u *dto.UserDto) dto.GetUserDto {
 	// copy all fields except password
 	return dto.GetUserDto{
-		Username:         u.Username,
-		Email:            u.Email,
-		CreatedAt:        u.CreatedAt,
-		UpdatedAt:        u.UpdatedAt,
-		TelegramUsername: u.TelegramUsername,
-		IsAdmin:          u.IsAdmin,
+		Username:            u.Username,
+		Email:               u.Email,
+		CreatedAt:           u.CreatedAt,
+		UpdatedAt:           u.UpdatedAt,
+		TelegramUsername:    u.TelegramUsername,
+		IsAdmin:             u.IsAdmin,
+		IsVerified:          u.IsVerified,
+		AvatarURL:           u.AvatarURL,
+		Bio:                 u.Bio,
+		Faculty:             u.Faculty,
+		EmailVisibility:     u.EmailVisibility,
+		TelegramVisibility:  u.TelegramVisibility,
+		DeletionScheduledAt: u.DeletionScheduledAt,
 	}
 }
 
+// HideUserContacts removes the contacts of the profile which its visibility
+// settings don't show to another user. isCounterpart tells whether the other
+// user has an active booking with the owner of the profile.
+func HideUserContacts(u *dto.UserWithRatingDto, isCounterpart bool) {
+	// email is never shown publicly even if the setting says so
+	if u.EmailVisibility != dto.VisibilityCounterparts || !isCounterpart {
+		u.Email = ""
+	}
+	switch u.TelegramVisibility {
+	case dto.VisibilityEveryone:
+	case dto.VisibilityCounterparts:
+		if !isCounterpart {
+			u.TelegramUsername = ""
+		}
+	default:
+		u.TelegramUsername = ""
+	}
+	u.EmailVisibility = ""
+	u.TelegramVisibility = ""
+}
+
 func HashPassword(password string) (string, error) {
 	bytes, err := bcrypt.GenerateFromPassword([]byte(password), 7)
 	return string(bytes), err
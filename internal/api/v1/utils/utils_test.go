@@ -1,11 +1,23 @@
 package utils
 
 import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
 	"net/http"
+	"net/url"
+	"strings"
 	"testing"
 	"time"
+	"unicode/utf8"
 
 	"example.com/m/internal/api/v1/core/application/dto"
+	"example.com/m/internal/api/v1/core/application/exceptions"
 	"example.com/m/internal/config"
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
@@ -33,6 +45,47 @@ func TestExcludeUserCredentials(t *testing.T) {
 	}
 }
 
+func TestHideUserContacts(t *testing.T) {
+	profile := dto.UserWithRatingDto{
+		Username:           "test",
+		Email:              "email@email.com",
+		TelegramUsername:   "telegram",
+		EmailVisibility:    dto.VisibilityCounterparts,
+		TelegramVisibility: dto.VisibilityCounterparts,
+	}
+
+	got := profile
+	HideUserContacts(&got, true)
+	if got.Email != profile.Email || got.TelegramUsername != profile.TelegramUsername {
+		t.Errorf("got %v want contacts shown to a counterpart", got)
+	}
+	if got.EmailVisibility != "" || got.TelegramVisibility != "" {
+		t.Errorf("got %v want no visibility settings", got)
+	}
+
+	got = profile
+	HideUserContacts(&got, false)
+	if got.Email != "" || got.TelegramUsername != "" {
+		t.Errorf("got %v want contacts hidden from a stranger", got)
+	}
+
+	// test case when everyone may see the contacts
+	got = profile
+	got.EmailVisibility = dto.VisibilityEveryone
+	got.TelegramVisibility = dto.VisibilityEveryone
+	HideUserContacts(&got, false)
+	if got.Email != "" || got.TelegramUsername != profile.TelegramUsername {
+		t.Errorf("got %v want only telegram username", got)
+	}
+
+	got = profile
+	got.TelegramVisibility = dto.VisibilityNobody
+	HideUserContacts(&got, true)
+	if got.TelegramUsername != "" {
+		t.Errorf("got %v want telegram username hidden", got)
+	}
+}
+
 func TestHashPassword(t *testing.T) {
 	password := "password"
 	hashedPassword, err := HashPassword(password)
@@ -82,6 +135,48 @@ func TestExtractTokenFromHeaders(t *testing.T) {
 	}
 }
 
+func TestParsePageRequest(t *testing.T) {
+	tests := []struct {
+		name   string
+		cursor dto.Cursor
+		key    dto.CursorKey
+		valid  bool
+	}{
+		{"time", dto.Cursor{Key: "2025-03-20T10:00:00.123456Z", ID: 1}, dto.CursorKeyTime, true},
+		{"text on a time sort", dto.Cursor{Key: "abc", ID: 1}, dto.CursorKeyTime, false},
+		{"number", dto.Cursor{Key: "4.5", ID: 1}, dto.CursorKeyNumber, true},
+		{"text on a number sort", dto.Cursor{Key: "abc", ID: 1}, dto.CursorKeyNumber, false},
+		{"infinity on a number sort", dto.Cursor{Key: "inf", ID: 1}, dto.CursorKeyNumber, false},
+		{"text", dto.Cursor{Key: "abc", ID: 1}, dto.CursorKeyText, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &gin.Context{}
+			c.Request = &http.Request{URL: &url.URL{RawQuery: "cursor=" + tt.cursor.Encode()}}
+			page, exc := ParsePageRequest(c, tt.key)
+			if !tt.valid {
+				if exc != &exceptions.ErrInvalidCursor {
+					t.Errorf("got %v want ErrInvalidCursor", exc)
+				}
+				return
+			}
+			if exc != nil {
+				t.Fatalf("got error %v", exc.Message)
+			}
+			if *page.Cursor != tt.cursor {
+				t.Errorf("got %v want %v", *page.Cursor, tt.cursor)
+			}
+		})
+	}
+
+	// test case when the cursor is not base64
+	c := &gin.Context{}
+	c.Request = &http.Request{URL: &url.URL{RawQuery: "cursor=%21%21"}}
+	if _, exc := ParsePageRequest(c, dto.CursorKeyText); exc != &exceptions.ErrInvalidCursor {
+		t.Errorf("got %v want ErrInvalidCursor", exc)
+	}
+}
+
 func TestValidateTokenSignature(t *testing.T) {
 	// test case when token is invalid
 	err := ValidateTokenSignature("invalid token")
@@ -133,3 +228,128 @@ func TestExtractPayloadFromJWT(t *testing.T) {
 		t.Errorf("got %v want username", claims["username"])
 	}
 }
+
+func TestGenerateCode(t *testing.T) {
+	code, err := GenerateCode(6)
+	if err != nil {
+		t.Errorf("got error %v", err)
+	}
+
+	if len(code) != 6 {
+		t.Errorf("got %v want 6 digits", code)
+	}
+	for _, c := range code {
+		if c < '0' || c > '9' {
+			t.Errorf("got %v want only digits", code)
+		}
+	}
+}
+
+func TestCheckSignature(t *testing.T) {
+	signature := SignValues("verify", "email@email.com", "1700000000")
+
+	if !CheckSignature(signature, "verify", "email@email.com", "1700000000") {
+		t.Errorf("got invalid signature want valid")
+	}
+
+	// test case when a signed value is changed
+	if CheckSignature(signature, "verify", "other@email.com", "1700000000") {
+		t.Errorf("got valid signature want invalid")
+	}
+}
+
+func TestResizeImage(t *testing.T) {
+	src := image.NewRGBA(image.Rect(0, 0, 300, 200))
+	for y := 0; y < 200; y++ {
+		for x := 0; x < 300; x++ {
+			src.Set(x, y, color.RGBA{R: 255, A: 255})
+		}
+	}
+	var file bytes.Buffer
+	png.Encode(&file, src)
+
+	buf, err := ResizeImage(&file, 100)
+	if err != nil {
+		t.Fatalf("got error %v", err)
+	}
+	img, err := jpeg.Decode(buf)
+	if err != nil {
+		t.Fatalf("got error %v", err)
+	}
+	if img.Bounds().Dx() != 100 || img.Bounds().Dy() != 100 {
+		t.Errorf("got %v want 100x100", img.Bounds())
+	}
+	if r, g, _, _ := img.At(50, 50).RGBA(); r < 0xf000 || g > 0x1000 {
+		t.Errorf("got color %v want red", img.At(50, 50))
+	}
+
+	// test case when the image is smaller than the size
+	file.Reset()
+	png.Encode(&file, image.NewRGBA(image.Rect(0, 0, 40, 60)))
+	buf, err = ResizeImage(&file, 100)
+	if err != nil {
+		t.Fatalf("got error %v", err)
+	}
+	img, _ = jpeg.Decode(buf)
+	if img.Bounds().Dx() != 40 || img.Bounds().Dy() != 40 {
+		t.Errorf("got %v want 40x40", img.Bounds())
+	}
+}
+
+func TestResizeImageTooLarge(t *testing.T) {
+	var file bytes.Buffer
+	png.Encode(&file, image.NewRGBA(image.Rect(0, 0, 1, 1)))
+
+	// declare 100000x100000 in the IHDR chunk, which follows the 8 byte
+	// signature and the chunk length and type, and fix its checksum
+	data := file.Bytes()
+	binary.BigEndian.PutUint32(data[16:], 100000)
+	binary.BigEndian.PutUint32(data[20:], 100000)
+	binary.BigEndian.PutUint32(data[29:], crc32.ChecksumIEEE(data[12:29]))
+
+	if _, err := ResizeImage(bytes.NewReader(data), 100); !errors.Is(err, ErrImageTooLarge) {
+		t.Errorf("got error %v want %v", err, ErrImageTooLarge)
+	}
+}
+
+func TestBuildICS(t *testing.T) {
+	event := dto.CalendarEventDto{
+		UID:         "booking-1-slot-2@bookcrossing",
+		Summary:     "Забрать книгу \"Война и мир\"",
+		Description: "Покажите владельцу код передачи из приложения, чтобы подтвердить получение книги.",
+		Location:    "Библиотека, Гашека 7; 2 этаж",
+		StartsAt:    "2025-03-20T10:00:00Z",
+		EndsAt:      "2025-03-20T10:30:00+03:00",
+	}
+	ics, err := BuildICS(&event, time.Date(2025, 3, 19, 8, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("got error %v", err)
+	}
+
+	for _, want := range []string{
+		"BEGIN:VCALENDAR\r\n",
+		"DTSTAMP:20250319T080000Z\r\n",
+		"DTSTART:20250320T100000Z\r\n",
+		"DTEND:20250320T073000Z\r\n",
+		`LOCATION:Библиотека\, Гашека 7\; 2 этаж`,
+		"\r\n ",
+		"END:VCALENDAR\r\n",
+	} {
+		if !strings.Contains(ics, want) {
+			t.Errorf("got %q want it to contain %q", ics, want)
+		}
+	}
+
+	for _, line := range strings.Split(strings.TrimSuffix(ics, "\r\n"), "\r\n") {
+		if len(line) > 75 {
+			t.Errorf("got line of %d octets want at most 75", len(line))
+		}
+		if !utf8.ValidString(line) {
+			t.Errorf("got line %q which splits a character", line)
+		}
+	}
+
+	if _, err := BuildICS(&dto.CalendarEventDto{StartsAt: "tomorrow"}, time.Now()); err == nil {
+		t.Errorf("got no error for an invalid time")
+	}
+}
@@ -3,6 +3,7 @@ package config
 import (
 	"fmt"
 	"os"
+	"strconv"
 	"time"
 )
 
@@ -12,9 +13,12 @@ var (
 )
 
 type AppConfig struct {
-	PostgresConnectionString  string
-	JWTSecret                 string
-	JWTExpiration             time.Duration
+	PostgresConnectionString string
+	JWTSecret                string
+	// lifetime of an access token
+	JWTExpiration time.Duration
+	// lifetime of a session, every refresh prolongs it
+	RefreshTokenExpiration    time.Duration
 	RedisConnectionString     string
 	RedisPassword             string
 	S3Region                  string
@@ -25,6 +29,81 @@ type AppConfig struct {
 	YandexCatalogID           string
 	FirebasePathToCredentials string
 	ChatBotPrompt             string
+	// how long a pending booking waits for the owner's decision
+	BookingApprovalDeadline time.Duration
+	// how long a confirmed booking waits for the pickup
+	BookingPickupDeadline          time.Duration
+	BookingExpirationCheckInterval time.Duration
+	// how many pending or confirmed bookings a user may have at once
+	MaxActiveBookings int
+	// bookings are suspended for BookingSuspension once a user has
+	// NoShowSuspensionThreshold no-shows within NoShowWindow
+	NoShowSuspensionThreshold int
+	NoShowWindow              time.Duration
+	BookingSuspension         time.Duration
+	// how long the author may edit or delete a review
+	ReviewEditWindow time.Duration
+	// how long a code confirming the handover of a booked book is valid
+	HandoverCodeTTL time.Duration
+	// how long before a reserved pickup slot both sides are reminded
+	PickupReminderLeadTime      time.Duration
+	PickupReminderCheckInterval time.Duration
+	// how long before the due date the borrower of a loan is reminded
+	LoanDueReminder time.Duration
+	// how often the borrower of an overdue loan is reminded
+	LoanOverdueReminderInterval time.Duration
+	LoanReminderCheckInterval   time.Duration
+	// mail is written to the log (or to MailDir) when SMTPHost is empty
+	SMTPHost     string
+	SMTPPort     string
+	SMTPUsername string
+	SMTPPassword string
+	MailFrom     string
+	MailDir      string
+	// public address of the API used in links sent by email
+	AppBaseURL string
+	// how long an email verification code or link is valid
+	EmailVerificationTTL time.Duration
+	// how long a password reset code is valid
+	PasswordResetTTL time.Duration
+
+	// OpenID Connect provider for SSO login, disabled when the issuer is empty
+	OIDCIssuer       string
+	OIDCClientID     string
+	OIDCClientSecret string
+	// callback address registered at the provider, ends with /auth/oidc/callback
+	OIDCRedirectURL string
+	OIDCScopes      string
+
+	// failed attempts allowed per account and per IP within ThrottleWindow
+	// before a lockout, which doubles with every next lockout up to ThrottleMaxLockout
+	ThrottleAccountAttempts int
+	ThrottleIPAttempts      int
+	ThrottleWindow          time.Duration
+	ThrottleLockout         time.Duration
+	ThrottleMaxLockout      time.Duration
+	// registrations allowed per IP within ThrottleWindow
+	ThrottleRegistrations int
+
+	// how long the user may cancel the deletion of the account
+	AccountDeletionGracePeriod   time.Duration
+	AccountDeletionCheckInterval time.Duration
+}
+
+func getDurationEnv(key string, fallback time.Duration) time.Duration {
+	value, err := time.ParseDuration(os.Getenv(key))
+	if err != nil || value <= 0 {
+		return fallback
+	}
+	return value
+}
+
+func getIntEnv(key string, fallback int) int {
+	value, err := strconv.Atoi(os.Getenv(key))
+	if err != nil || value <= 0 {
+		return fallback
+	}
+	return value
 }
 
 func InitConfig() {
@@ -37,7 +116,8 @@ func InitConfig() {
 			os.Getenv("DB_PASSWORD"),
 			os.Getenv("DB_NAME")),
 		JWTSecret:                 os.Getenv("JWT_SECRET"),
-		JWTExpiration:             time.Hour * 24 * 90,
+		JWTExpiration:             getDurationEnv("JWT_EXPIRATION", time.Minute*15),
+		RefreshTokenExpiration:    getDurationEnv("REFRESH_TOKEN_EXPIRATION", time.Hour*24*90),
 		RedisConnectionString:     os.Getenv("REDIS_CONNECTION"),
 		RedisPassword:             "",
 		S3Region:                  os.Getenv("S3_REGION"),
@@ -48,5 +128,46 @@ func InitConfig() {
 		YandexCatalogID:           os.Getenv("YANDEX_CATALOG_ID"),
 		FirebasePathToCredentials: os.Getenv("FIREBASE_PATH_TO_CREDENTIALS"),
 		ChatBotPrompt:             os.Getenv("CHAT_BOT_PROMPT"),
+
+		BookingApprovalDeadline:        getDurationEnv("BOOKING_APPROVAL_DEADLINE", time.Hour*48),
+		BookingPickupDeadline:          getDurationEnv("BOOKING_PICKUP_DEADLINE", time.Hour*72),
+		BookingExpirationCheckInterval: getDurationEnv("BOOKING_EXPIRATION_CHECK_INTERVAL", time.Minute*5),
+		MaxActiveBookings:              getIntEnv("MAX_ACTIVE_BOOKINGS", 3),
+		NoShowSuspensionThreshold:      getIntEnv("NO_SHOW_SUSPENSION_THRESHOLD", 3),
+		NoShowWindow:                   getDurationEnv("NO_SHOW_WINDOW", time.Hour*24*90),
+		BookingSuspension:              getDurationEnv("BOOKING_SUSPENSION", time.Hour*24*14),
+		ReviewEditWindow:               getDurationEnv("REVIEW_EDIT_WINDOW", time.Hour*48),
+		HandoverCodeTTL:                getDurationEnv("HANDOVER_CODE_TTL", time.Minute*10),
+		PickupReminderLeadTime:         getDurationEnv("PICKUP_REMINDER_LEAD_TIME", time.Hour*2),
+		PickupReminderCheckInterval:    getDurationEnv("PICKUP_REMINDER_CHECK_INTERVAL", time.Minute*5),
+		LoanDueReminder:                getDurationEnv("LOAN_DUE_REMINDER", time.Hour*48),
+		LoanOverdueReminderInterval:    getDurationEnv("LOAN_OVERDUE_REMINDER_INTERVAL", time.Hour*24),
+		LoanReminderCheckInterval:      getDurationEnv("LOAN_REMINDER_CHECK_INTERVAL", time.Hour),
+
+		SMTPHost:             os.Getenv("SMTP_HOST"),
+		SMTPPort:             os.Getenv("SMTP_PORT"),
+		SMTPUsername:         os.Getenv("SMTP_USERNAME"),
+		SMTPPassword:         os.Getenv("SMTP_PASSWORD"),
+		MailFrom:             os.Getenv("MAIL_FROM"),
+		MailDir:              os.Getenv("MAIL_DIR"),
+		AppBaseURL:           os.Getenv("APP_BASE_URL"),
+		EmailVerificationTTL: getDurationEnv("EMAIL_VERIFICATION_TTL", time.Hour*24),
+		PasswordResetTTL:     getDurationEnv("PASSWORD_RESET_TTL", time.Minute*15),
+
+		OIDCIssuer:       os.Getenv("OIDC_ISSUER"),
+		OIDCClientID:     os.Getenv("OIDC_CLIENT_ID"),
+		OIDCClientSecret: os.Getenv("OIDC_CLIENT_SECRET"),
+		OIDCRedirectURL:  os.Getenv("OIDC_REDIRECT_URL"),
+		OIDCScopes:       os.Getenv("OIDC_SCOPES"),
+
+		ThrottleAccountAttempts: getIntEnv("THROTTLE_ACCOUNT_ATTEMPTS", 5),
+		ThrottleIPAttempts:      getIntEnv("THROTTLE_IP_ATTEMPTS", 20),
+		ThrottleWindow:          getDurationEnv("THROTTLE_WINDOW", time.Minute*15),
+		ThrottleLockout:         getDurationEnv("THROTTLE_LOCKOUT", time.Minute),
+		ThrottleMaxLockout:      getDurationEnv("THROTTLE_MAX_LOCKOUT", time.Hour),
+		ThrottleRegistrations:   getIntEnv("THROTTLE_REGISTRATIONS", 10),
+
+		AccountDeletionGracePeriod:   getDurationEnv("ACCOUNT_DELETION_GRACE_PERIOD", time.Hour*24*14),
+		AccountDeletionCheckInterval: getDurationEnv("ACCOUNT_DELETION_CHECK_INTERVAL", time.Hour),
 	}
 }
@@ -0,0 +1,28 @@
+-- +goose Up
+-- pending, confirmed, expired, cancelled, completed
+ALTER TABLE bookings ADD status TEXT NOT NULL DEFAULT 'confirmed';
+ALTER TABLE bookings ADD expires_at timestamp;
+ALTER TABLE bookings ADD updated_at timestamp;
+
+UPDATE bookings SET expires_at = created_at + interval '3 days', updated_at = created_at;
+
+-- finished bookings are kept as history, so a user may book the same post again
+ALTER TABLE bookings DROP CONSTRAINT IF EXISTS bookings_user_email_post_id_key;
+CREATE UNIQUE INDEX IF NOT EXISTS bookings_active_post_id_idx ON bookings (post_id)
+    WHERE status IN ('pending', 'confirmed');
+CREATE INDEX IF NOT EXISTS bookings_status_expires_at_idx ON bookings (status, expires_at);
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- +goose StatementEnd
+
+-- +goose Down
+DROP INDEX IF EXISTS bookings_status_expires_at_idx;
+DROP INDEX IF EXISTS bookings_active_post_id_idx;
+DELETE FROM bookings WHERE status <> 'confirmed';
+ALTER TABLE bookings ADD CONSTRAINT bookings_user_email_post_id_key UNIQUE (user_email, post_id);
+ALTER TABLE bookings DROP COLUMN updated_at;
+ALTER TABLE bookings DROP COLUMN expires_at;
+ALTER TABLE bookings DROP COLUMN status;
+-- +goose StatementBegin
+SELECT 'down SQL query';
+-- +goose StatementEnd
@@ -0,0 +1,25 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS booking_waitlist (
+    id SERIAL PRIMARY KEY,
+    post_id int,
+    user_email varchar(64),
+    created_at timestamp,
+
+    CONSTRAINT fk_user_email FOREIGN KEY (user_email) REFERENCES users(email)
+        ON DELETE CASCADE
+        ON UPDATE CASCADE,
+
+    CONSTRAINT fk_post_id FOREIGN KEY (post_id) REFERENCES posts(id)
+        ON DELETE CASCADE
+        ON UPDATE CASCADE,
+    UNIQUE(post_id, user_email)
+);
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- +goose StatementEnd
+
+-- +goose Down
+DROP TABLE booking_waitlist;
+-- +goose StatementBegin
+SELECT 'down SQL query';
+-- +goose StatementEnd
@@ -0,0 +1,44 @@
+-- +goose Up
+-- post and place may be deleted later, so the title is kept in the record
+CREATE TABLE IF NOT EXISTS exchanges (
+    id SERIAL PRIMARY KEY,
+    post_id int,
+    post_title TEXT,
+    place_id int,
+    booking_id int,
+    owner_email varchar(64),
+    recipient_email varchar(64),
+    booked_at timestamp,
+    taken_at timestamp,
+
+    CONSTRAINT fk_post_id FOREIGN KEY (post_id) REFERENCES posts(id)
+        ON DELETE SET NULL
+        ON UPDATE CASCADE,
+
+    CONSTRAINT fk_place_id FOREIGN KEY (place_id) REFERENCES places(id)
+        ON DELETE SET NULL
+        ON UPDATE CASCADE,
+
+    CONSTRAINT fk_booking_id FOREIGN KEY (booking_id) REFERENCES bookings(id)
+        ON DELETE SET NULL
+        ON UPDATE CASCADE,
+
+    CONSTRAINT fk_owner_email FOREIGN KEY (owner_email) REFERENCES users(email)
+        ON DELETE CASCADE
+        ON UPDATE CASCADE,
+
+    CONSTRAINT fk_recipient_email FOREIGN KEY (recipient_email) REFERENCES users(email)
+        ON DELETE CASCADE
+        ON UPDATE CASCADE
+);
+CREATE INDEX IF NOT EXISTS exchanges_owner_email_idx ON exchanges (owner_email);
+CREATE INDEX IF NOT EXISTS exchanges_recipient_email_idx ON exchanges (recipient_email);
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- +goose StatementEnd
+
+-- +goose Down
+DROP TABLE exchanges;
+-- +goose StatementBegin
+SELECT 'down SQL query';
+-- +goose StatementEnd
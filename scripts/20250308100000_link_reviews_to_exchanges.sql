@@ -0,0 +1,27 @@
+-- +goose Up
+-- reviews written before exchanges were recorded keep exchange_id empty
+ALTER TABLE reviews ADD exchange_id int;
+ALTER TABLE reviews ADD updated_at timestamp;
+ALTER TABLE reviews ADD is_hidden BOOLEAN NOT NULL DEFAULT false;
+
+UPDATE reviews SET updated_at = created_at;
+
+ALTER TABLE reviews ADD CONSTRAINT fk_exchange_id FOREIGN KEY (exchange_id) REFERENCES exchanges(id)
+    ON DELETE SET NULL
+    ON UPDATE CASCADE;
+-- one review per exchange from each side
+CREATE UNIQUE INDEX IF NOT EXISTS reviews_exchange_id_reviewer_idx ON reviews (exchange_id, reviewer_user_email)
+    WHERE exchange_id IS NOT NULL;
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- +goose StatementEnd
+
+-- +goose Down
+DROP INDEX IF EXISTS reviews_exchange_id_reviewer_idx;
+ALTER TABLE reviews DROP CONSTRAINT IF EXISTS fk_exchange_id;
+ALTER TABLE reviews DROP COLUMN is_hidden;
+ALTER TABLE reviews DROP COLUMN updated_at;
+ALTER TABLE reviews DROP COLUMN exchange_id;
+-- +goose StatementBegin
+SELECT 'down SQL query';
+-- +goose StatementEnd
@@ -0,0 +1,21 @@
+-- +goose Up
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+-- the expressions must match the ones used in
+-- PostRepository.SearchByTitleOrAuthorOrGenre
+CREATE INDEX IF NOT EXISTS posts_search_russian_idx ON posts USING GIN (
+    to_tsvector('russian', coalesce(title, '') || ' ' || coalesce(author, '') || ' ' || coalesce(description, '') || ' ' || coalesce(summary, ''))
+);
+CREATE INDEX IF NOT EXISTS posts_search_english_idx ON posts USING GIN (
+    to_tsvector('english', coalesce(title, '') || ' ' || coalesce(author, '') || ' ' || coalesce(description, '') || ' ' || coalesce(summary, ''))
+);
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- +goose StatementEnd
+
+-- +goose Down
+DROP INDEX IF EXISTS posts_search_english_idx;
+DROP INDEX IF EXISTS posts_search_russian_idx;
+-- +goose StatementBegin
+SELECT 'down SQL query';
+-- +goose StatementEnd
@@ -0,0 +1,14 @@
+-- +goose Up
+-- messages are paginated by (created_at, id), existing rows get ids from the sequence
+ALTER TABLE chat_messages ADD COLUMN id SERIAL PRIMARY KEY;
+CREATE INDEX IF NOT EXISTS chat_messages_email_created_at_idx ON chat_messages (email, created_at DESC, id DESC);
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- +goose StatementEnd
+
+-- +goose Down
+DROP INDEX IF EXISTS chat_messages_email_created_at_idx;
+ALTER TABLE chat_messages DROP COLUMN id;
+-- +goose StatementBegin
+SELECT 'down SQL query';
+-- +goose StatementEnd
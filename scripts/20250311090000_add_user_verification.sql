@@ -0,0 +1,13 @@
+-- +goose Up
+-- accounts created before verification was introduced are trusted
+ALTER TABLE users ADD COLUMN IF NOT EXISTS is_verified BOOLEAN NOT NULL DEFAULT false;
+UPDATE users SET is_verified = true;
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- +goose StatementEnd
+
+-- +goose Down
+ALTER TABLE users DROP COLUMN IF EXISTS is_verified;
+-- +goose StatementBegin
+SELECT 'down SQL query';
+-- +goose StatementEnd
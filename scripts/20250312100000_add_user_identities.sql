@@ -0,0 +1,23 @@
+-- +goose Up
+-- accounts of external identity providers linked to users
+CREATE TABLE IF NOT EXISTS user_identities (
+    issuer TEXT NOT NULL,
+    subject TEXT NOT NULL,
+    user_email varchar(64) NOT NULL,
+    created_at timestamp NOT NULL,
+    PRIMARY KEY (issuer, subject),
+
+    CONSTRAINT fk_user_email FOREIGN KEY (user_email) REFERENCES users(email)
+        ON DELETE CASCADE
+        ON UPDATE CASCADE
+);
+CREATE INDEX IF NOT EXISTS user_identities_user_email_idx ON user_identities (user_email);
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- +goose StatementEnd
+
+-- +goose Down
+DROP TABLE IF EXISTS user_identities;
+-- +goose StatementBegin
+SELECT 'down SQL query';
+-- +goose StatementEnd
@@ -0,0 +1,35 @@
+-- +goose Up
+-- roles replace users.is_admin, a place_manager role is scoped to one place
+CREATE TABLE IF NOT EXISTS user_roles (
+    id SERIAL PRIMARY KEY,
+    user_email varchar(64) NOT NULL,
+    role TEXT NOT NULL,
+    place_id int,
+    granted_by varchar(64),
+    created_at timestamp NOT NULL,
+
+    CONSTRAINT fk_user_email FOREIGN KEY (user_email) REFERENCES users(email)
+        ON DELETE CASCADE
+        ON UPDATE CASCADE,
+
+    CONSTRAINT fk_place_id FOREIGN KEY (place_id) REFERENCES places(id)
+        ON DELETE CASCADE
+        ON UPDATE CASCADE
+);
+CREATE UNIQUE INDEX IF NOT EXISTS user_roles_unique_idx ON user_roles (user_email, role, COALESCE(place_id, 0));
+INSERT INTO user_roles (user_email, role, created_at)
+SELECT email, 'admin', now() FROM users WHERE is_admin;
+ALTER TABLE users DROP COLUMN IF EXISTS is_admin;
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- +goose StatementEnd
+
+-- +goose Down
+ALTER TABLE users ADD COLUMN IF NOT EXISTS is_admin BOOLEAN;
+UPDATE users SET is_admin = EXISTS (
+    SELECT 1 FROM user_roles WHERE user_roles.user_email = users.email AND user_roles.role = 'admin'
+);
+DROP TABLE IF EXISTS user_roles;
+-- +goose StatementBegin
+SELECT 'down SQL query';
+-- +goose StatementEnd
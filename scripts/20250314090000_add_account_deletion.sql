@@ -0,0 +1,54 @@
+-- +goose Up
+ALTER TABLE users ADD COLUMN IF NOT EXISTS deletion_scheduled_at timestamp;
+CREATE INDEX IF NOT EXISTS users_deletion_scheduled_at_idx ON users (deletion_scheduled_at)
+    WHERE deletion_scheduled_at IS NOT NULL;
+
+-- reviews and exchanges of a deleted user are kept without the author
+ALTER TABLE reviews DROP CONSTRAINT IF EXISTS fk_reviewer_user_email;
+ALTER TABLE reviews ADD CONSTRAINT fk_reviewer_user_email FOREIGN KEY (reviewer_user_email) REFERENCES users(email)
+    ON DELETE SET NULL
+    ON UPDATE CASCADE;
+ALTER TABLE reviews DROP CONSTRAINT IF EXISTS fk_target_user_email;
+ALTER TABLE reviews ADD CONSTRAINT fk_target_user_email FOREIGN KEY (target_user_email) REFERENCES users(email)
+    ON DELETE SET NULL
+    ON UPDATE CASCADE;
+
+ALTER TABLE exchanges DROP CONSTRAINT IF EXISTS fk_owner_email;
+ALTER TABLE exchanges ADD CONSTRAINT fk_owner_email FOREIGN KEY (owner_email) REFERENCES users(email)
+    ON DELETE SET NULL
+    ON UPDATE CASCADE;
+ALTER TABLE exchanges DROP CONSTRAINT IF EXISTS fk_recipient_email;
+ALTER TABLE exchanges ADD CONSTRAINT fk_recipient_email FOREIGN KEY (recipient_email) REFERENCES users(email)
+    ON DELETE SET NULL
+    ON UPDATE CASCADE;
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- +goose StatementEnd
+
+-- +goose Down
+DELETE FROM reviews WHERE reviewer_user_email IS NULL OR target_user_email IS NULL;
+DELETE FROM exchanges WHERE owner_email IS NULL OR recipient_email IS NULL;
+
+ALTER TABLE exchanges DROP CONSTRAINT IF EXISTS fk_recipient_email;
+ALTER TABLE exchanges ADD CONSTRAINT fk_recipient_email FOREIGN KEY (recipient_email) REFERENCES users(email)
+    ON DELETE CASCADE
+    ON UPDATE CASCADE;
+ALTER TABLE exchanges DROP CONSTRAINT IF EXISTS fk_owner_email;
+ALTER TABLE exchanges ADD CONSTRAINT fk_owner_email FOREIGN KEY (owner_email) REFERENCES users(email)
+    ON DELETE CASCADE
+    ON UPDATE CASCADE;
+
+ALTER TABLE reviews DROP CONSTRAINT IF EXISTS fk_target_user_email;
+ALTER TABLE reviews ADD CONSTRAINT fk_target_user_email FOREIGN KEY (target_user_email) REFERENCES users(email)
+    ON DELETE CASCADE
+    ON UPDATE CASCADE;
+ALTER TABLE reviews DROP CONSTRAINT IF EXISTS fk_reviewer_user_email;
+ALTER TABLE reviews ADD CONSTRAINT fk_reviewer_user_email FOREIGN KEY (reviewer_user_email) REFERENCES users(email)
+    ON DELETE CASCADE
+    ON UPDATE CASCADE;
+
+DROP INDEX IF EXISTS users_deletion_scheduled_at_idx;
+ALTER TABLE users DROP COLUMN IF EXISTS deletion_scheduled_at;
+-- +goose StatementBegin
+SELECT 'down SQL query';
+-- +goose StatementEnd
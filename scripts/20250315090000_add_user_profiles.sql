@@ -0,0 +1,30 @@
+-- +goose Up
+ALTER TABLE users ADD COLUMN IF NOT EXISTS avatar_url TEXT NOT NULL DEFAULT '';
+ALTER TABLE users ADD COLUMN IF NOT EXISTS bio TEXT NOT NULL DEFAULT '';
+ALTER TABLE users ADD COLUMN IF NOT EXISTS faculty varchar(128) NOT NULL DEFAULT '';
+
+-- places where the user prefers to pick up and hand over books
+CREATE TABLE IF NOT EXISTS user_preferred_places (
+    user_email varchar(64) NOT NULL,
+    place_id int NOT NULL,
+    PRIMARY KEY (user_email, place_id),
+
+    CONSTRAINT fk_user_email FOREIGN KEY (user_email) REFERENCES users(email)
+        ON DELETE CASCADE
+        ON UPDATE CASCADE,
+    CONSTRAINT fk_place_id FOREIGN KEY (place_id) REFERENCES places(id)
+        ON DELETE CASCADE
+        ON UPDATE CASCADE
+);
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- +goose StatementEnd
+
+-- +goose Down
+DROP TABLE IF EXISTS user_preferred_places;
+ALTER TABLE users DROP COLUMN IF EXISTS faculty;
+ALTER TABLE users DROP COLUMN IF EXISTS bio;
+ALTER TABLE users DROP COLUMN IF EXISTS avatar_url;
+-- +goose StatementBegin
+SELECT 'down SQL query';
+-- +goose StatementEnd
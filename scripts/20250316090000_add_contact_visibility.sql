@@ -0,0 +1,15 @@
+-- +goose Up
+-- who may see the contacts of the user: nobody, counterparts of active
+-- bookings or everyone; email is never shown to everyone
+ALTER TABLE users ADD COLUMN IF NOT EXISTS email_visibility TEXT NOT NULL DEFAULT 'nobody';
+ALTER TABLE users ADD COLUMN IF NOT EXISTS telegram_visibility TEXT NOT NULL DEFAULT 'counterparts';
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- +goose StatementEnd
+
+-- +goose Down
+ALTER TABLE users DROP COLUMN IF EXISTS telegram_visibility;
+ALTER TABLE users DROP COLUMN IF EXISTS email_visibility;
+-- +goose StatementBegin
+SELECT 'down SQL query';
+-- +goose StatementEnd
@@ -0,0 +1,27 @@
+-- +goose Up
+-- a loaned book is returned to the owner after loan_period_days
+ALTER TABLE posts ADD COLUMN IF NOT EXISTS listing_type TEXT NOT NULL DEFAULT 'giveaway';
+ALTER TABLE posts ADD COLUMN IF NOT EXISTS loan_period_days int;
+
+-- due_at is set for loans only, the reminder times keep reminders from repeating
+ALTER TABLE exchanges ADD COLUMN IF NOT EXISTS due_at timestamp;
+ALTER TABLE exchanges ADD COLUMN IF NOT EXISTS returned_at timestamp;
+ALTER TABLE exchanges ADD COLUMN IF NOT EXISTS due_reminder_sent_at timestamp;
+ALTER TABLE exchanges ADD COLUMN IF NOT EXISTS overdue_reminder_sent_at timestamp;
+CREATE INDEX IF NOT EXISTS exchanges_outstanding_due_at_idx ON exchanges (due_at)
+    WHERE due_at IS NOT NULL AND returned_at IS NULL;
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- +goose StatementEnd
+
+-- +goose Down
+DROP INDEX IF EXISTS exchanges_outstanding_due_at_idx;
+ALTER TABLE exchanges DROP COLUMN IF EXISTS overdue_reminder_sent_at;
+ALTER TABLE exchanges DROP COLUMN IF EXISTS due_reminder_sent_at;
+ALTER TABLE exchanges DROP COLUMN IF EXISTS returned_at;
+ALTER TABLE exchanges DROP COLUMN IF EXISTS due_at;
+ALTER TABLE posts DROP COLUMN IF EXISTS loan_period_days;
+ALTER TABLE posts DROP COLUMN IF EXISTS listing_type;
+-- +goose StatementBegin
+SELECT 'down SQL query';
+-- +goose StatementEnd
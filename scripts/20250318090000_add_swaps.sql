@@ -0,0 +1,59 @@
+-- +goose Up
+-- a counter offer is a new offer in the opposite direction, parent_id points
+-- to the offer it answers
+CREATE TABLE IF NOT EXISTS swap_offers (
+    id SERIAL PRIMARY KEY,
+    proposer_email varchar(64),
+    recipient_email varchar(64),
+    parent_id int,
+    status TEXT,
+    created_at timestamp,
+    updated_at timestamp,
+
+    CONSTRAINT fk_proposer_email FOREIGN KEY (proposer_email) REFERENCES users(email)
+        ON DELETE CASCADE
+        ON UPDATE CASCADE,
+
+    CONSTRAINT fk_recipient_email FOREIGN KEY (recipient_email) REFERENCES users(email)
+        ON DELETE CASCADE
+        ON UPDATE CASCADE,
+
+    CONSTRAINT fk_parent_id FOREIGN KEY (parent_id) REFERENCES swap_offers(id)
+        ON DELETE SET NULL
+        ON UPDATE CASCADE
+);
+CREATE INDEX IF NOT EXISTS swap_offers_proposer_email_idx ON swap_offers (proposer_email);
+CREATE INDEX IF NOT EXISTS swap_offers_recipient_email_idx ON swap_offers (recipient_email);
+
+-- side is "offered" for posts of the proposer and "requested" for posts of the
+-- recipient; the title is kept in case the post is deleted
+CREATE TABLE IF NOT EXISTS swap_offer_posts (
+    offer_id int,
+    post_id int,
+    post_title TEXT,
+    side TEXT,
+
+    CONSTRAINT fk_offer_id FOREIGN KEY (offer_id) REFERENCES swap_offers(id)
+        ON DELETE CASCADE
+        ON UPDATE CASCADE,
+
+    CONSTRAINT fk_post_id FOREIGN KEY (post_id) REFERENCES posts(id)
+        ON DELETE SET NULL
+        ON UPDATE CASCADE
+);
+CREATE INDEX IF NOT EXISTS swap_offer_posts_offer_id_idx ON swap_offer_posts (offer_id);
+CREATE INDEX IF NOT EXISTS swap_offer_posts_post_id_idx ON swap_offer_posts (post_id);
+
+-- bookings made by an accepted swap
+ALTER TABLE bookings ADD swap_id int REFERENCES swap_offers(id) ON DELETE SET NULL;
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- +goose StatementEnd
+
+-- +goose Down
+ALTER TABLE bookings DROP COLUMN swap_id;
+DROP TABLE swap_offer_posts;
+DROP TABLE swap_offers;
+-- +goose StatementBegin
+SELECT 'down SQL query';
+-- +goose StatementEnd
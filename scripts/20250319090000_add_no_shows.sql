@@ -0,0 +1,37 @@
+-- +goose Up
+-- no_show_count is the reliability counter shown in profiles; bookings are
+-- refused until booking_suspended_until after repeated no-shows
+ALTER TABLE users ADD COLUMN IF NOT EXISTS no_show_count int NOT NULL DEFAULT 0;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS booking_suspended_until timestamp;
+
+CREATE TABLE IF NOT EXISTS no_show_reports (
+    id SERIAL PRIMARY KEY,
+    booking_id int UNIQUE,
+    reporter_email varchar(64),
+    user_email varchar(64),
+    created_at timestamp,
+
+    CONSTRAINT fk_booking_id FOREIGN KEY (booking_id) REFERENCES bookings(id)
+        ON DELETE SET NULL
+        ON UPDATE CASCADE,
+
+    CONSTRAINT fk_reporter_email FOREIGN KEY (reporter_email) REFERENCES users(email)
+        ON DELETE SET NULL
+        ON UPDATE CASCADE,
+
+    CONSTRAINT fk_user_email FOREIGN KEY (user_email) REFERENCES users(email)
+        ON DELETE CASCADE
+        ON UPDATE CASCADE
+);
+CREATE INDEX IF NOT EXISTS no_show_reports_user_email_created_at_idx ON no_show_reports (user_email, created_at);
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- +goose StatementEnd
+
+-- +goose Down
+DROP TABLE no_show_reports;
+ALTER TABLE users DROP COLUMN IF EXISTS booking_suspended_until;
+ALTER TABLE users DROP COLUMN IF EXISTS no_show_count;
+-- +goose StatementBegin
+SELECT 'down SQL query';
+-- +goose StatementEnd
@@ -0,0 +1,45 @@
+-- +goose Up
+-- weekday is 0 for Sunday as in Go; times are "HH:MM" in UTC like every
+-- other time of the API
+CREATE TABLE IF NOT EXISTS place_opening_hours (
+    place_id int,
+    weekday smallint CHECK (weekday BETWEEN 0 AND 6),
+    opens_at TEXT,
+    closes_at TEXT,
+
+    CONSTRAINT fk_place_id FOREIGN KEY (place_id) REFERENCES places(id)
+        ON DELETE CASCADE
+        ON UPDATE CASCADE
+);
+CREATE INDEX IF NOT EXISTS place_opening_hours_place_id_idx ON place_opening_hours (place_id);
+
+CREATE TABLE IF NOT EXISTS pickup_slots (
+    id SERIAL PRIMARY KEY,
+    place_id int,
+    starts_at timestamp,
+    ends_at timestamp,
+    capacity int,
+    created_at timestamp,
+
+    CONSTRAINT fk_place_id FOREIGN KEY (place_id) REFERENCES places(id)
+        ON DELETE CASCADE
+        ON UPDATE CASCADE
+);
+CREATE INDEX IF NOT EXISTS pickup_slots_place_id_starts_at_idx ON pickup_slots (place_id, starts_at);
+
+ALTER TABLE bookings ADD slot_id int REFERENCES pickup_slots(id) ON DELETE SET NULL;
+ALTER TABLE bookings ADD slot_reminder_sent_at timestamp;
+CREATE INDEX IF NOT EXISTS bookings_slot_id_idx ON bookings (slot_id) WHERE slot_id IS NOT NULL;
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- +goose StatementEnd
+
+-- +goose Down
+DROP INDEX IF EXISTS bookings_slot_id_idx;
+ALTER TABLE bookings DROP COLUMN slot_reminder_sent_at;
+ALTER TABLE bookings DROP COLUMN slot_id;
+DROP TABLE pickup_slots;
+DROP TABLE place_opening_hours;
+-- +goose StatementBegin
+SELECT 'down SQL query';
+-- +goose StatementEnd
@@ -0,0 +1,12 @@
+-- +goose Up
+-- the expressions must match the ones used in
+-- PostRepository.SearchByTitleOrAuthorOrGenre so that the fuzzy conditions
+-- are index-backed
+CREATE INDEX IF NOT EXISTS posts_search_title_trgm_idx ON posts USING GIN (coalesce(title, '') gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS posts_search_author_trgm_idx ON posts USING GIN (coalesce(author, '') gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS posts_search_genre_trgm_idx ON posts USING GIN (genre gin_trgm_ops);
+
+-- +goose Down
+DROP INDEX IF EXISTS posts_search_genre_trgm_idx;
+DROP INDEX IF EXISTS posts_search_author_trgm_idx;
+DROP INDEX IF EXISTS posts_search_title_trgm_idx;